@@ -38,14 +38,20 @@ import (
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/codegen"
 	"fiber-ent-apollo-pg/internal/config"
 	"fiber-ent-apollo-pg/internal/db"
 	"fiber-ent-apollo-pg/internal/esx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
 	"fiber-ent-apollo-pg/internal/httpx"
 	"fiber-ent-apollo-pg/internal/logx"
 	"fiber-ent-apollo-pg/internal/mqx"
 	"fiber-ent-apollo-pg/internal/redisx"
 	"fiber-ent-apollo-pg/internal/server"
+	"fiber-ent-apollo-pg/internal/svgx"
+	"fiber-ent-apollo-pg/internal/tokens"
 
 	_ "fiber-ent-apollo-pg/docs" // swagger docs
 )
@@ -87,10 +93,14 @@ func main() {
 	// Auto-migrate (demo purpose)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if err := client.Schema.Create(ctx); err != nil {
+	if err := db.Migrate(ctx, client); err != nil {
 		mainLogger.Sugar().Error("auto migrate error", "err", err)
 		panic(err)
 	}
+	if err := db.Backfill(ctx, client); err != nil {
+		mainLogger.Sugar().Error("data backfill error", "err", err)
+		panic(err)
+	}
 
 	// Optional deps: Redis, MQ, ES
 	var (
@@ -120,6 +130,59 @@ func main() {
 		}
 	}
 
+	// Export jobs go through RabbitMQ when it is up, else an in-process bus
+	var (
+		exportPub mqx.Publisher
+		exportSub mqx.Subscriber
+	)
+	if publisher != nil {
+		if consumer, err := mqx.NewRabbitConsumer(cfg.MQ.URL, "events", "exports"); err != nil {
+			mainLogger.Sugar().Warn("mq consumer init failed", "err", err)
+		} else {
+			exportPub, exportSub = publisher, consumer
+		}
+	}
+	if exportSub == nil {
+		bus := mqx.NewLocalBus(2)
+		exportPub, exportSub = bus, bus
+	}
+	defer func() { _ = exportSub.Close() }()
+	figmaClient := figmax.Open(cfg)
+	if figmaClient == nil {
+		mainLogger.Info("figma token not set; project sync disabled")
+	}
+
+	// Export artifacts live in a local content-addressed store
+	var blobs blobx.Store
+	if fs, err := blobx.NewFSStore(cfg.Blob.Dir); err != nil {
+		mainLogger.Sugar().Warn("blob store init failed; artifacts disabled", "err", err)
+	} else {
+		blobs = fs
+		sweepCtx, stopSweep := context.WithCancel(context.Background())
+		defer stopSweep()
+		go exportx.RunSweeper(sweepCtx, client, blobs, time.Hour,
+			time.Duration(cfg.Blob.RetentionDays)*24*time.Hour,
+			time.Duration(cfg.Blob.GCGraceMin)*time.Minute)
+	}
+	exports := exportx.NewService(client, exportPub, blobs, tokens.Step(client, figmaClient, codegen.Emit), svgx.Step(client, figmaClient))
+	if err := exportSub.Subscribe(exportx.RoutingKey, exports.Handle); err != nil {
+		mainLogger.Sugar().Error("export subscribe error", "err", err)
+		panic(err)
+	}
+	// Jobs queued in the in-process bus, or held by a worker, when the
+	// process stopped are queued again
+	recoverCtx, stopRecover := context.WithCancel(context.Background())
+	if n, err := exports.Recover(recoverCtx); err != nil {
+		mainLogger.Sugar().Warn("export job recovery failed", "err", err)
+	} else if n > 0 {
+		mainLogger.Sugar().Info("recovered export jobs", "jobs", n)
+	}
+	defer stopRecover()
+	go exports.RunRecoverer(recoverCtx, exportx.StaleAfter)
+	// Figma webhook events schedule debounced exports of opted-in projects
+	autoExport := exportx.NewAutoExporter(exports, time.Duration(cfg.Figma.WebhookDebounceSec)*time.Second)
+	defer autoExport.Close()
+
 	esClient, esClose, err := esx.Open(cfg)
 	if err != nil {
 		mainLogger.Sugar().Warn("es init failed", "err", err)
@@ -131,7 +194,7 @@ func main() {
 	app := fiber.New(fiber.Config{ErrorHandler: httpx.ErrorHandler()})
 	httpx.RegisterCommonMiddlewares(app)
 	_ = rdb // reserved for future http handlers
-	providers := &httpx.Providers{MQ: publisher, ES: esClient, RDB: rdb}
+	providers := &httpx.Providers{MQ: publisher, ES: esClient, RDB: rdb, Figma: figmaClient, Exports: exports, Blobs: blobs, AutoExport: autoExport}
 	httpx.Register(app, client, providers)
 
 	// Watch for dynamic config changes (Apollo)
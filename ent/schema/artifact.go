@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// Artifact is a named output file of an export job. The content lives in the
+// blob store under its digest; identical outputs share one blob.
+type Artifact struct{ ent.Schema }
+
+// Fields defines the fields for the Artifact entity.
+func (Artifact) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("job_id", uuid.UUID{}).Immutable(),
+		// relative path inside the export, e.g. "icons/home.svg"
+		field.String("name").NotEmpty().MaxLen(512).Immutable(),
+		// hex SHA-256 of the content
+		field.String("digest").MinLen(64).MaxLen(64).Immutable(),
+		field.Int64("size").Min(0).Immutable(),
+		field.String("content_type").Default("application/octet-stream").Immutable(),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the Artifact entity.
+func (Artifact) Edges() []ent.Edge {
+	return []ent.Edge{
+		// export job that produced the artifact (required)
+		edge.To("job", ExportJob.Type).Field("job_id").Unique().Required().Immutable(),
+	}
+}
+
+// Indexes defines indexes for the Artifact entity.
+func (Artifact) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Edges("job").Fields("name").Unique(),
+		// blob GC looks up references by digest
+		index.Fields("digest"),
+	}
+}
@@ -20,6 +20,15 @@ func (ConfigItem) Fields() []ent.Field {
 		field.UUID("id", uuid.UUID{}).Default(uuid.New),
 		field.String("name").NotEmpty().MaxLen(255),
 		field.JSON("data", map[string]any{}),
+		// optional ConfigSchema kind (e.g. "figma-export/v1") that data must satisfy
+		field.String("kind").Optional().MaxLen(100),
+		// head revision number; bumped on every save
+		field.Int("revision").Default(0),
+		// upstream config this one was forked from, and its head revision at fork time
+		field.UUID("forked_from_id", uuid.UUID{}).Optional().Nillable(),
+		field.Int("forked_from_revision").Optional(),
+		// ordered parent configs whose resolved data this config is layered on (later parents win)
+		field.JSON("parent_ids", []uuid.UUID{}).Optional(),
 		field.Time("created_at").Default(time.Now).Immutable(),
 		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
 	}
@@ -30,10 +39,19 @@ func (ConfigItem) Edges() []ent.Edge {
 	return []ent.Edge{
 		// owner user (required)
 		edge.To("owner", User.Type).Unique().Required(),
-		// shared to groups (many-to-many)
-		edge.To("shared_groups", Group.Type),
+		// shared to groups, with a permission (many-to-many)
+		edge.To("shared_groups", Group.Type).Through("group_shares", GroupShare.Type),
+		// shared directly to users, with a permission (many-to-many)
+		edge.To("shared_users", User.Type).Through("shares", ConfigShare.Type),
 		// project configs (inverse of ProjectConfig.config_item)
 		edge.From("project_configs", ProjectConfig.Type).Ref("config_item"),
+		// revision history (inverse of ConfigRevision.config)
+		edge.From("revisions", ConfigRevision.Type).Ref("config"),
+		// fork lineage (self-referencing one-to-many)
+		edge.To("forks", ConfigItem.Type).
+			From("forked_from").
+			Field("forked_from_id").
+			Unique(),
 	}
 }
 
@@ -42,5 +60,6 @@ func (ConfigItem) Indexes() []ent.Index {
 	return []ent.Index{
 		index.Edges("owner"),
 		index.Fields("updated_at"),
+		index.Fields("forked_from_id"),
 	}
 }
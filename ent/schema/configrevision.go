@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ConfigRevision is an immutable snapshot of a ConfigItem's data written on every save.
+type ConfigRevision struct{ ent.Schema }
+
+// Fields defines the fields for the ConfigRevision entity.
+func (ConfigRevision) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("config_id", uuid.UUID{}).Immutable(),
+		field.UUID("author_id", uuid.UUID{}).Immutable(),
+		// sequential per config, starting at 1
+		field.Int("revision").Positive().Immutable(),
+		field.JSON("data", map[string]any{}).Immutable(),
+		field.String("message").Optional().MaxLen(500).Immutable(),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the ConfigRevision entity.
+func (ConfigRevision) Edges() []ent.Edge {
+	return []ent.Edge{
+		// revised config (required)
+		edge.To("config", ConfigItem.Type).Field("config_id").Unique().Required().Immutable(),
+		// user who saved this revision (required)
+		edge.To("author", User.Type).Field("author_id").Unique().Required().Immutable(),
+	}
+}
+
+// Indexes defines indexes for the ConfigRevision entity.
+func (ConfigRevision) Indexes() []ent.Index {
+	return []ent.Index{
+		// revision numbers are unique per config
+		index.Edges("config").Fields("revision").Unique(),
+	}
+}
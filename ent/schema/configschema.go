@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"regexp"
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"github.com/google/uuid"
+)
+
+// KindPattern matches a config kind with its version, e.g. "figma-export/v1".
+var KindPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*/v[1-9][0-9]*$`)
+
+// ConfigSchema is a JSON Schema registered for one config kind and version.
+type ConfigSchema struct{ ent.Schema }
+
+// Fields defines the fields for the ConfigSchema entity.
+func (ConfigSchema) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		// kind and version, e.g. "figma-export/v1"; a registered schema never changes
+		field.String("kind").NotEmpty().MaxLen(100).Match(KindPattern).Unique().Immutable(),
+		field.JSON("schema", map[string]any{}).Immutable(),
+		field.String("description").Optional().MaxLen(1000),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ConfigShare is the ConfigItem-User edge of a config shared directly with a
+// user, carrying the permission granted.
+type ConfigShare struct{ ent.Schema }
+
+// Annotations keys rows by config and user.
+func (ConfigShare) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		field.ID("config_id", "user_id"),
+	}
+}
+
+// Fields defines the fields for the ConfigShare entity.
+func (ConfigShare) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("config_id", uuid.UUID{}),
+		field.UUID("user_id", uuid.UUID{}),
+		// view reads and resolves the config; edit also changes its content;
+		// manage also shares it further
+		field.Enum("permission").Values("view", "edit", "manage").Default("view"),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the ConfigShare entity.
+func (ConfigShare) Edges() []ent.Edge {
+	return []ent.Edge{
+		// shares go with their config or user
+		edge.To("config", ConfigItem.Type).Field("config_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("user", User.Type).Field("user_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
+}
+
+// Indexes defines indexes for the ConfigShare entity.
+func (ConfigShare) Indexes() []ent.Index {
+	return []ent.Index{
+		// configs shared with a user
+		index.Fields("user_id"),
+	}
+}
@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ExportJob is one export run of a project against a snapshot of its active config.
+type ExportJob struct{ ent.Schema }
+
+// Fields defines the fields for the ExportJob entity.
+func (ExportJob) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("project_id", uuid.UUID{}).Immutable(),
+		field.UUID("requested_by_id", uuid.UUID{}).Immutable(),
+		// active config at request time; referenced by id only so the job
+		// outlives the config
+		field.UUID("config_id", uuid.UUID{}).Immutable(),
+		field.Int("config_revision").Immutable(),
+		// resolved config data the export runs with
+		field.JSON("config", map[string]any{}).Immutable(),
+		// what started the job: a user request or a Figma webhook
+		field.Enum("trigger").Values("manual", "webhook").Default("manual").Immutable(),
+		field.Enum("status").Values("queued", "running", "succeeded", "failed", "canceled").Default("queued"),
+		// percent complete, 0-100
+		field.Int("progress").Default(0).Min(0).Max(100),
+		field.String("error").Optional().MaxLen(2000),
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Time("started_at").Optional().Nillable(),
+		field.Time("finished_at").Optional().Nillable(),
+		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
+	}
+}
+
+// Edges defines the relationships for the ExportJob entity.
+func (ExportJob) Edges() []ent.Edge {
+	return []ent.Edge{
+		// exported project (required)
+		edge.To("project", Project.Type).Field("project_id").Unique().Required().Immutable(),
+		// user who requested the export (required)
+		edge.To("requested_by", User.Type).Field("requested_by_id").Unique().Required().Immutable(),
+		// output files of the job
+		edge.From("artifacts", Artifact.Type).Ref("job"),
+	}
+}
+
+// Indexes defines indexes for the ExportJob entity.
+func (ExportJob) Indexes() []ent.Index {
+	return []ent.Index{
+		// jobs are listed newest first per project
+		index.Edges("project").Fields("created_at"),
+		index.Fields("status"),
+	}
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/snapshot"
+)
+
+// FileSnapshot is the component and style summary of one version of a
+// project's Figma file. Versions never change, so snapshots are immutable
+// and diffs between stored versions need no Figma calls.
+type FileSnapshot struct{ ent.Schema }
+
+// Fields defines the fields for the FileSnapshot entity.
+func (FileSnapshot) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("project_id", uuid.UUID{}).Immutable(),
+		// Figma file the version belongs to: the branch key for branch
+		// projects, else the file key. Empty for snapshots captured before it
+		// was recorded, which are never matched.
+		field.String("file_key").Default("").MaxLen(128).Immutable(),
+		// Figma version id
+		field.String("version").NotEmpty().MaxLen(64).Immutable(),
+		field.String("file_name").Optional().MaxLen(255).Immutable(),
+		field.Time("last_modified").Optional().Nillable().Immutable(),
+		field.JSON("entries", []snapshot.Entry{}).Immutable(),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the FileSnapshot entity.
+func (FileSnapshot) Edges() []ent.Edge {
+	return []ent.Edge{
+		// project whose file was captured (required)
+		edge.To("project", Project.Type).Field("project_id").Unique().Required().Immutable(),
+	}
+}
+
+// Indexes defines indexes for the FileSnapshot entity.
+func (FileSnapshot) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Edges("project").Fields("file_key", "version").Unique(),
+		// the latest snapshot is the default diff base
+		index.Edges("project").Fields("file_key", "created_at"),
+	}
+}
@@ -25,8 +25,8 @@ func (Group) Fields() []ent.Field {
 func (Group) Edges() []ent.Edge {
 	return []ent.Edge{
 		// many-to-many members
-		edge.From("members", User.Type).Ref("groups"),
+		edge.From("members", User.Type).Ref("groups").Through("memberships", GroupMembership.Type),
 		// many-to-many configs shared to this group
-		edge.From("configs", ConfigItem.Type).Ref("shared_groups"),
+		edge.From("configs", ConfigItem.Type).Ref("shared_groups").Through("config_shares", GroupShare.Type),
 	}
 }
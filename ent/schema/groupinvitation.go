@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// GroupInvitation lets users join a group by presenting a token.
+type GroupInvitation struct{ ent.Schema }
+
+// Fields defines the fields for the GroupInvitation entity.
+func (GroupInvitation) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("group_id", uuid.UUID{}).Immutable(),
+		field.UUID("inviter_id", uuid.UUID{}).Immutable(),
+		// SHA-256 of the token; the token itself is only returned on creation
+		field.String("token_hash").NotEmpty().MaxLen(64).Immutable().Sensitive(),
+		// when set, only a user with this login identifier can accept
+		field.String("email").Optional().MaxLen(320).Immutable(),
+		// role granted on accept
+		field.Enum("role").Values("admin", "member").Default("member").Immutable(),
+		field.Time("expires_at").Immutable(),
+		field.Int("max_uses").Default(1).Min(1).Immutable(),
+		field.Int("uses").Default(0).Min(0),
+		field.Time("revoked_at").Optional().Nillable(),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the GroupInvitation entity.
+func (GroupInvitation) Edges() []ent.Edge {
+	return []ent.Edge{
+		// invitations go with their group
+		edge.To("group", Group.Type).Field("group_id").Unique().Required().Immutable().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("inviter", User.Type).Field("inviter_id").Unique().Required().Immutable(),
+	}
+}
+
+// Indexes defines indexes for the GroupInvitation entity.
+func (GroupInvitation) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("token_hash").Unique(),
+		// invitations are listed newest first per group
+		index.Fields("group_id", "created_at"),
+	}
+}
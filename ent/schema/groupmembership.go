@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// GroupMembership is the User-Group edge, carrying the member's role.
+type GroupMembership struct{ ent.Schema }
+
+// Annotations keeps the table of the former plain many-to-many edge and
+// keys rows by user and group.
+func (GroupMembership) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "user_groups"},
+		field.ID("user_id", "group_id"),
+	}
+}
+
+// Fields defines the fields for the GroupMembership entity.
+func (GroupMembership) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("user_id", uuid.UUID{}),
+		field.UUID("group_id", uuid.UUID{}),
+		// a group has one owner; owners and admins manage members
+		field.Enum("role").Values("owner", "admin", "member").Default("member"),
+		// rows of the former edge predate the column, so it needs a database default
+		field.Time("created_at").Default(time.Now).Immutable().
+			Annotations(entsql.Default("CURRENT_TIMESTAMP")),
+	}
+}
+
+// Edges defines the relationships for the GroupMembership entity.
+func (GroupMembership) Edges() []ent.Edge {
+	return []ent.Edge{
+		// memberships go with their user or group, as rows of the former edge did
+		edge.To("user", User.Type).Field("user_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("group", Group.Type).Field("group_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
+}
+
+// Indexes defines indexes for the GroupMembership entity.
+func (GroupMembership) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("group_id", "role"),
+	}
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"github.com/google/uuid"
+)
+
+// GroupShare is the ConfigItem-Group edge of a config shared to a group,
+// carrying the permission granted to its members.
+type GroupShare struct{ ent.Schema }
+
+// Annotations keeps the table of the former plain many-to-many edge and
+// keys rows by config and group.
+func (GroupShare) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "config_item_shared_groups"},
+		field.ID("config_item_id", "group_id"),
+	}
+}
+
+// Fields defines the fields for the GroupShare entity.
+func (GroupShare) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("config_item_id", uuid.UUID{}),
+		field.UUID("group_id", uuid.UUID{}),
+		// existing shares were read-only and become view
+		field.Enum("permission").Values("view", "edit", "manage").Default("view"),
+		// rows of the former edge predate the column, so it needs a database default
+		field.Time("created_at").Default(time.Now).Immutable().
+			Annotations(entsql.Default("CURRENT_TIMESTAMP")),
+	}
+}
+
+// Edges defines the relationships for the GroupShare entity.
+func (GroupShare) Edges() []ent.Edge {
+	return []ent.Edge{
+		// shares go with their config or group
+		edge.To("config", ConfigItem.Type).Field("config_item_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("group", Group.Type).Field("group_id").Unique().Required().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// PendingShare is a config shared with a login identifier that has no
+// account yet. It becomes a ConfigShare when, after that identifier
+// registered, someone who manages the config confirms it.
+type PendingShare struct{ ent.Schema }
+
+// Fields defines the fields for the PendingShare entity.
+func (PendingShare) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("config_id", uuid.UUID{}).Immutable(),
+		field.UUID("sharer_id", uuid.UUID{}).Immutable(),
+		// password login identifier, stored in lower case and matched
+		// case-insensitively, as older rows kept their case
+		field.String("identifier").NotEmpty().MaxLen(320).Immutable(),
+		// permission of the share created on registration
+		field.Enum("permission").Values("view", "edit", "manage").Default("view"),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the PendingShare entity.
+func (PendingShare) Edges() []ent.Edge {
+	return []ent.Edge{
+		// pending shares go with their config and with their sharer
+		edge.To("config", ConfigItem.Type).Field("config_id").Unique().Required().Immutable().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("sharer", User.Type).Field("sharer_id").Unique().Required().Immutable().
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
+}
+
+// Indexes defines indexes for the PendingShare entity.
+func (PendingShare) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("config_id", "identifier").Unique(),
+		// looked up on registration
+		index.Fields("identifier"),
+	}
+}
@@ -20,7 +20,22 @@ func (Project) Fields() []ent.Field {
 		field.UUID("id", uuid.UUID{}).Default(uuid.New),
 		field.String("name").NotEmpty().MaxLen(255),
 		field.String("url").NotEmpty().MaxLen(255),
+		// parsed from url; optional only for rows created before url parsing
+		field.String("file_key").Optional().MaxLen(128),
+		field.String("branch_key").Optional().MaxLen(128),
+		field.String("node_id").Optional().MaxLen(64),
+		// Figma file metadata, filled by sync
+		field.String("file_name").Optional().MaxLen(255),
+		field.String("thumbnail_url").Optional().MaxLen(2048),
+		field.Time("last_modified").Optional().Nillable(),
+		field.String("figma_version").Optional().MaxLen(64),
+		field.Time("synced_at").Optional().Nillable(),
 		field.String("description").Optional().MaxLen(1000),
+		// queue an export with the active config when a Figma webhook reports
+		// the file changed
+		field.Bool("auto_export").Default(false),
+		// version counter for optimistic concurrency; bumped on every update
+		field.Int("version").Default(1),
 		field.Time("created_at").Default(time.Now).Immutable(),
 		field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now),
 	}
@@ -43,5 +58,7 @@ func (Project) Indexes() []ent.Index {
 		index.Fields("updated_at"),
 		// url is unique per owner
 		index.Edges("owner").Fields("url").Unique(),
+		// one project per Figma file (or branch of it) per owner
+		index.Edges("owner").Fields("file_key", "branch_key").Unique(),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
 	"entgo.io/ent/schema/index"
@@ -43,5 +44,7 @@ func (ProjectConfig) Indexes() []ent.Index {
 		index.Fields("active"),
 		// index for project to find active config quickly
 		index.Edges("project").Fields("active"),
+		// at most one active config per project
+		index.Edges("project").Unique().Annotations(entsql.IndexWhere("active")),
 	}
 }
@@ -0,0 +1,48 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ProjectConfigActivation records one change of a project's active config.
+type ProjectConfigActivation struct{ ent.Schema }
+
+// Fields defines the fields for the ProjectConfigActivation entity.
+func (ProjectConfigActivation) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).Default(uuid.New),
+		field.UUID("project_id", uuid.UUID{}).Immutable(),
+		field.UUID("actor_id", uuid.UUID{}).Immutable(),
+		// configs are referenced by id only so history survives their deletion;
+		// nil means the project had no active config
+		field.UUID("from_config_id", uuid.UUID{}).Optional().Nillable().Immutable(),
+		field.UUID("to_config_id", uuid.UUID{}).Optional().Nillable().Immutable(),
+		// set when this activation reverted an earlier one
+		field.UUID("rollback_of_id", uuid.UUID{}).Optional().Nillable().Immutable(),
+		field.Time("created_at").Default(time.Now).Immutable(),
+	}
+}
+
+// Edges defines the relationships for the ProjectConfigActivation entity.
+func (ProjectConfigActivation) Edges() []ent.Edge {
+	return []ent.Edge{
+		// project whose active config changed (required)
+		edge.To("project", Project.Type).Field("project_id").Unique().Required().Immutable(),
+		// user who made the change (required)
+		edge.To("actor", User.Type).Field("actor_id").Unique().Required().Immutable(),
+	}
+}
+
+// Indexes defines indexes for the ProjectConfigActivation entity.
+func (ProjectConfigActivation) Indexes() []ent.Index {
+	return []ent.Index{
+		// history is listed newest first per project
+		index.Edges("project").Fields("created_at"),
+	}
+}
@@ -28,7 +28,8 @@ func (User) Edges() []ent.Edge {
 	return []ent.Edge{
 		edge.From("identities", Identity.Type).Ref("user"),
 		edge.From("devices", Device.Type).Ref("user"),
-		edge.To("groups", Group.Type),
+		edge.To("groups", Group.Type).Through("memberships", GroupMembership.Type),
 		edge.From("configs", ConfigItem.Type).Ref("owner"),
+		edge.From("shared_configs", ConfigItem.Type).Ref("shared_users").Through("config_shares", ConfigShare.Type),
 	}
 }
@@ -0,0 +1,145 @@
+package blobx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FSStore keeps blobs under a local directory as <root>/ab/cd/<digest>.
+type FSStore struct {
+	root string
+}
+
+// NewFSStore creates a store rooted at dir, creating it if needed.
+func NewFSStore(dir string) (*FSStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FSStore{root: dir}, nil
+}
+
+func (s *FSStore) path(digest string) string {
+	return filepath.Join(s.root, digest[:2], digest[2:4], digest)
+}
+
+// Put streams r to a temporary file while hashing it, then moves it into place.
+// Content already stored is kept and its modification time refreshed.
+func (s *FSStore) Put(ctx context.Context, r io.Reader) (Info, error) {
+	tmp, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return Info{}, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), readerWithContext(ctx, r))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Info{}, err
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	dst := s.path(digest)
+	// Stored content is touched as if uploaded again, so GC keeps it through
+	// the grace period while the caller records its reference.
+	now := time.Now()
+	if err := os.Chtimes(dst, now, now); err == nil {
+		return Info{Digest: digest, Size: n, ModTime: now}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Info{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return Info{}, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return Info{}, err
+	}
+	st, err := os.Stat(dst)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Digest: digest, Size: n, ModTime: st.ModTime()}, nil
+}
+
+// Open opens the blob file.
+func (s *FSStore) Open(_ context.Context, digest string) (Blob, error) {
+	if err := checkDigest(digest); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &fileBlob{File: f, size: st.Size()}, nil
+}
+
+// Delete removes the blob file.
+func (s *FSStore) Delete(_ context.Context, digest string) error {
+	if err := checkDigest(digest); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Walk visits every blob file, skipping in-progress uploads.
+func (s *FSStore) Walk(ctx context.Context, fn func(Info) error) error {
+	return filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") || !ValidDigest(d.Name()) {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(Info{Digest: d.Name(), Size: st.Size(), ModTime: st.ModTime()})
+	})
+}
+
+type fileBlob struct {
+	*os.File
+	size int64
+}
+
+func (b *fileBlob) Size() int64 { return b.size }
+
+// readerWithContext stops reading once ctx is done.
+func readerWithContext(ctx context.Context, r io.Reader) io.Reader {
+	return readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return r.Read(p)
+	})
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,119 @@
+package blobx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFSStore_PutOpenDedupe(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	a, err := s.Put(ctx, strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if a.Digest != Digest([]byte("hello world")) || a.Size != 11 {
+		t.Fatalf("unexpected info: %+v", a)
+	}
+	b, err := s.Put(ctx, strings.NewReader("hello world"))
+	if err != nil || b.Digest != a.Digest {
+		t.Fatalf("second put: %+v, %v", b, err)
+	}
+	n := 0
+	_ = s.Walk(ctx, func(Info) error { n++; return nil })
+	if n != 1 {
+		t.Fatalf("walk saw %d blobs, want 1", n)
+	}
+
+	blob, err := s.Open(ctx, a.Digest)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer blob.Close()
+	if _, err := blob.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	rest, _ := io.ReadAll(blob)
+	if string(rest) != "world" || blob.Size() != 11 {
+		t.Fatalf("read %q size %d", rest, blob.Size())
+	}
+
+	if _, err := s.Open(ctx, Digest([]byte("missing"))); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("open missing: %v", err)
+	}
+	if _, err := s.Open(ctx, "../../etc/passwd"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("open invalid digest: %v", err)
+	}
+}
+
+func TestFSStore_PutTouchesExistingBlob(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	a, err := s.Put(ctx, strings.NewReader("shared"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	// The blob aged past the GC grace period with no references left
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(s.path(a.Digest), old, old); err != nil {
+		t.Fatalf("age blob: %v", err)
+	}
+
+	b, err := s.Put(ctx, strings.NewReader("shared"))
+	if err != nil || b.Digest != a.Digest || b.Size != a.Size {
+		t.Fatalf("second put: %+v, %v", b, err)
+	}
+	st, err := os.Stat(s.path(a.Digest))
+	if err != nil || !st.ModTime().After(old) || !b.ModTime.After(old) {
+		t.Fatalf("blob not touched: %v %v", st.ModTime(), err)
+	}
+	// A GC pass between the upload and recording the reference keeps it
+	stats, err := GC(ctx, s, func(context.Context, string) (bool, error) { return false, nil }, time.Hour)
+	if err != nil || stats.Deleted != 0 {
+		t.Fatalf("gc removed re-uploaded blob: %+v %v", stats, err)
+	}
+}
+
+func TestGC(t *testing.T) {
+	ctx := context.Background()
+	s, _ := NewFSStore(t.TempDir())
+	keep, _ := s.Put(ctx, strings.NewReader("keep"))
+	drop, _ := s.Put(ctx, strings.NewReader("drop"))
+	fresh, _ := s.Put(ctx, strings.NewReader("fresh"))
+	old := time.Now().Add(-2 * time.Hour)
+	for _, d := range []string{keep.Digest, drop.Digest} {
+		if err := os.Chtimes(s.path(d), old, old); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	referenced := func(_ context.Context, d string) (bool, error) { return d == keep.Digest, nil }
+	stats, err := GC(ctx, s, referenced, time.Hour)
+	if err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if stats.Scanned != 3 || stats.Deleted != 1 || stats.Freed != drop.Size {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for d, want := range map[string]bool{keep.Digest: true, drop.Digest: false, fresh.Digest: true} {
+		b, err := s.Open(ctx, d)
+		if (err == nil) != want {
+			t.Fatalf("blob %s present=%v, want %v", d[:8], err == nil, want)
+		}
+		if b != nil {
+			_ = b.Close()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package blobx
+
+import (
+	"context"
+	"time"
+)
+
+// GCStats summarizes a garbage collection pass.
+type GCStats struct {
+	Scanned int
+	Deleted int
+	Freed   int64
+}
+
+// GC deletes blobs that referenced reports as unused. Blobs younger than grace
+// are kept, so content uploaded by a writer that has not recorded its
+// reference yet survives.
+func GC(ctx context.Context, store Store, referenced func(ctx context.Context, digest string) (bool, error), grace time.Duration) (GCStats, error) {
+	var (
+		stats   GCStats
+		garbage []Info
+	)
+	cutoff := time.Now().Add(-grace)
+	err := store.Walk(ctx, func(info Info) error {
+		stats.Scanned++
+		if info.ModTime.After(cutoff) {
+			return nil
+		}
+		used, err := referenced(ctx, info.Digest)
+		if err != nil {
+			return err
+		}
+		if !used {
+			garbage = append(garbage, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	// Delete after walking; stores need not support mutation during Walk
+	for _, info := range garbage {
+		if err := store.Delete(ctx, info.Digest); err != nil {
+			return stats, err
+		}
+		stats.Deleted++
+		stats.Freed += info.Size
+	}
+	return stats, nil
+}
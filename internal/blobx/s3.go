@@ -0,0 +1,180 @@
+package blobx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3API is the subset of an S3-compatible object API used by S3Store. Adapters
+// for a concrete SDK must report missing objects as ErrNotFound.
+type S3API interface {
+	PutObject(ctx context.Context, bucket, key string, body io.ReadSeeker, size int64) error
+	// GetObject reads length bytes from offset; a negative length reads to the end.
+	GetObject(ctx context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error)
+	HeadObject(ctx context.Context, bucket, key string) (size int64, modTime time.Time, err error)
+	// TouchObject sets the modification time of an object to now, e.g. by
+	// copying it onto itself with replaced metadata.
+	TouchObject(ctx context.Context, bucket, key string) (modTime time.Time, err error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+	ListObjects(ctx context.Context, bucket, prefix string, fn func(key string, size int64, modTime time.Time) error) error
+}
+
+// S3Store keeps blobs in a bucket as <prefix>/ab/<digest>.
+type S3Store struct {
+	api    S3API
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a store in bucket under prefix.
+func NewS3Store(api S3API, bucket, prefix string) *S3Store {
+	return &S3Store{api: api, bucket: bucket, prefix: strings.Trim(prefix, "/")}
+}
+
+func (s *S3Store) key(digest string) string {
+	return path.Join(s.prefix, digest[:2], digest)
+}
+
+// Put spools r to a temporary file to learn its digest, then uploads it unless
+// the object already exists, in which case the object is touched instead.
+func (s *S3Store) Put(ctx context.Context, r io.Reader) (Info, error) {
+	tmp, err := os.CreateTemp("", "blobx-*")
+	if err != nil {
+		return Info{}, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), readerWithContext(ctx, r))
+	if err != nil {
+		return Info{}, err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	// Stored content is touched as if uploaded again, so GC keeps it through
+	// the grace period while the caller records its reference.
+	if mod, err := s.api.TouchObject(ctx, s.bucket, s.key(digest)); err == nil {
+		return Info{Digest: digest, Size: n, ModTime: mod}, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return Info{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+	if err := s.api.PutObject(ctx, s.bucket, s.key(digest), tmp, n); err != nil {
+		return Info{}, err
+	}
+	return Info{Digest: digest, Size: n, ModTime: time.Now()}, nil
+}
+
+// Open returns a blob that fetches ranges of the object as it is read.
+func (s *S3Store) Open(ctx context.Context, digest string) (Blob, error) {
+	if err := checkDigest(digest); err != nil {
+		return nil, err
+	}
+	size, _, err := s.api.HeadObject(ctx, s.bucket, s.key(digest))
+	if err != nil {
+		return nil, err
+	}
+	return &s3Blob{ctx: ctx, store: s, key: s.key(digest), size: size}, nil
+}
+
+// Delete removes the object.
+func (s *S3Store) Delete(ctx context.Context, digest string) error {
+	if err := checkDigest(digest); err != nil {
+		return err
+	}
+	err := s.api.DeleteObject(ctx, s.bucket, s.key(digest))
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Walk lists the objects under the store prefix.
+func (s *S3Store) Walk(ctx context.Context, fn func(Info) error) error {
+	prefix := s.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+	return s.api.ListObjects(ctx, s.bucket, prefix, func(key string, size int64, mod time.Time) error {
+		digest := path.Base(key)
+		if !ValidDigest(digest) {
+			return nil
+		}
+		return fn(Info{Digest: digest, Size: size, ModTime: mod})
+	})
+}
+
+// s3Blob reads an object lazily from the current offset; seeking drops the
+// open body so the next read starts a new ranged request.
+type s3Blob struct {
+	ctx   context.Context
+	store *S3Store
+	key   string
+	size  int64
+	off   int64
+	body  io.ReadCloser
+}
+
+func (b *s3Blob) Size() int64 { return b.size }
+
+func (b *s3Blob) Read(p []byte) (int, error) {
+	if b.off >= b.size {
+		return 0, io.EOF
+	}
+	if b.body == nil {
+		body, err := b.store.api.GetObject(b.ctx, b.store.bucket, b.key, b.off, -1)
+		if err != nil {
+			return 0, err
+		}
+		b.body = body
+	}
+	n, err := b.body.Read(p)
+	b.off += int64(n)
+	if err == io.EOF && b.off < b.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *s3Blob) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = b.off + offset
+	case io.SeekEnd:
+		abs = b.size + offset
+	default:
+		return 0, fmt.Errorf("blobx: invalid whence %d", whence)
+	}
+	if abs < 0 {
+		return 0, fmt.Errorf("blobx: negative position %d", abs)
+	}
+	if abs != b.off && b.body != nil {
+		_ = b.body.Close()
+		b.body = nil
+	}
+	b.off = abs
+	return abs, nil
+}
+
+func (b *s3Blob) Close() error {
+	if b.body != nil {
+		return b.body.Close()
+	}
+	return nil
+}
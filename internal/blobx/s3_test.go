@@ -0,0 +1,174 @@
+package blobx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memS3 is an in-memory S3API.
+type memS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	mods    map[string]time.Time
+	puts    int
+	gets    int
+}
+
+func newMemS3() *memS3 {
+	return &memS3{objects: map[string][]byte{}, mods: map[string]time.Time{}}
+}
+
+func (m *memS3) PutObject(_ context.Context, bucket, key string, body io.ReadSeeker, size int64) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(b)) != size {
+		return io.ErrShortWrite
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.puts++
+	m.objects[bucket+"/"+key] = b
+	m.mods[bucket+"/"+key] = time.Now()
+	return nil
+}
+
+func (m *memS3) GetObject(_ context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m.gets++
+	b = b[offset:]
+	if length >= 0 && length < int64(len(b)) {
+		b = b[:length]
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memS3) HeadObject(_ context.Context, bucket, key string) (int64, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return 0, time.Time{}, ErrNotFound
+	}
+	return int64(len(b)), m.mods[bucket+"/"+key], nil
+}
+
+func (m *memS3) TouchObject(_ context.Context, bucket, key string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[bucket+"/"+key]; !ok {
+		return time.Time{}, ErrNotFound
+	}
+	now := time.Now()
+	m.mods[bucket+"/"+key] = now
+	return now, nil
+}
+
+func (m *memS3) DeleteObject(_ context.Context, bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, bucket+"/"+key)
+	delete(m.mods, bucket+"/"+key)
+	return nil
+}
+
+func (m *memS3) ListObjects(_ context.Context, bucket, prefix string, fn func(string, int64, time.Time) error) error {
+	m.mu.Lock()
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, bucket+"/"+prefix) {
+			keys = append(keys, k)
+		}
+	}
+	m.mu.Unlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(strings.TrimPrefix(k, bucket+"/"), int64(len(m.objects[k])), m.mods[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	api := newMemS3()
+	s := NewS3Store(api, "bucket", "/artifacts/")
+
+	a, err := s.Put(ctx, strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := s.Put(ctx, strings.NewReader("0123456789")); err != nil || api.puts != 1 {
+		t.Fatalf("duplicate put uploaded again: puts=%d err=%v", api.puts, err)
+	}
+	if _, ok := api.objects["bucket/artifacts/"+a.Digest[:2]+"/"+a.Digest]; !ok {
+		t.Fatalf("unexpected object keys: %v", api.objects)
+	}
+
+	blob, err := s.Open(ctx, a.Digest)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer blob.Close()
+	if api.gets != 0 {
+		t.Fatalf("open fetched the body eagerly")
+	}
+	if _, err := blob.Seek(-4, io.SeekEnd); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	tail, err := io.ReadAll(blob)
+	if err != nil || string(tail) != "6789" {
+		t.Fatalf("read tail %q: %v", tail, err)
+	}
+
+	var seen []string
+	_ = s.Walk(ctx, func(i Info) error { seen = append(seen, i.Digest); return nil })
+	if len(seen) != 1 || seen[0] != a.Digest {
+		t.Fatalf("walk saw %v", seen)
+	}
+	if err := s.Delete(ctx, a.Digest); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := s.Open(ctx, a.Digest); err != ErrNotFound {
+		t.Fatalf("open deleted: %v", err)
+	}
+}
+
+func TestS3Store_PutTouchesExistingObject(t *testing.T) {
+	ctx := context.Background()
+	api := newMemS3()
+	s := NewS3Store(api, "bucket", "artifacts")
+
+	a, err := s.Put(ctx, strings.NewReader("shared"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	// The object aged past the GC grace period with no references left
+	old := time.Now().Add(-2 * time.Hour)
+	api.mods["bucket/"+s.key(a.Digest)] = old
+
+	b, err := s.Put(ctx, strings.NewReader("shared"))
+	if err != nil || api.puts != 1 {
+		t.Fatalf("second put: puts=%d err=%v", api.puts, err)
+	}
+	if !b.ModTime.After(old) || b.Size != a.Size {
+		t.Fatalf("second put info: %+v", b)
+	}
+	stats, err := GC(ctx, s, func(context.Context, string) (bool, error) { return false, nil }, time.Hour)
+	if err != nil || stats.Deleted != 0 {
+		t.Fatalf("gc removed re-uploaded object: %+v %v", stats, err)
+	}
+}
@@ -0,0 +1,63 @@
+// Package blobx stores immutable blobs addressed by the SHA-256 of their
+// content. Identical content is stored once, whatever the number of writers.
+package blobx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+)
+
+// ErrNotFound is returned when a digest is not in the store.
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob.
+type Info struct {
+	// Digest is the lowercase hex SHA-256 of the content.
+	Digest  string
+	Size    int64
+	ModTime time.Time
+}
+
+// Blob is an open blob. Reads and seeks may be served lazily, so Close must
+// always be called.
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// Store is a content-addressed blob store.
+type Store interface {
+	// Put stores the content of r and returns its digest. Storing content that
+	// is already present is cheap and returns the existing blob.
+	Put(ctx context.Context, r io.Reader) (Info, error)
+	// Open returns the blob with digest or ErrNotFound.
+	Open(ctx context.Context, digest string) (Blob, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, digest string) error
+	// Walk calls fn for every stored blob until fn returns an error.
+	Walk(ctx context.Context, fn func(Info) error) error
+}
+
+var digestPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ValidDigest reports whether s is a lowercase hex SHA-256 digest.
+func ValidDigest(s string) bool { return digestPattern.MatchString(s) }
+
+func checkDigest(s string) error {
+	if !ValidDigest(s) {
+		return fmt.Errorf("blobx: invalid digest %q", s)
+	}
+	return nil
+}
+
+// Digest returns the digest of b.
+func Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
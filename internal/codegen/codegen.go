@@ -0,0 +1,407 @@
+// Package codegen renders a design token tree as source for platform teams:
+// CSS custom properties, SCSS variables and maps, TypeScript constants, Swift
+// and Kotlin declarations, and Style Dictionary JSON. Which targets are
+// generated and how names are formed is read from config data.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// Target is an output format.
+type Target string
+
+// Supported targets.
+const (
+	CSS             Target = "css"
+	SCSS            Target = "scss"
+	TypeScript      Target = "ts"
+	Swift           Target = "swift"
+	Kotlin          Target = "kotlin"
+	StyleDictionary Target = "style-dictionary"
+)
+
+// Targets lists all targets.
+var Targets = []Target{CSS, SCSS, TypeScript, Swift, Kotlin, StyleDictionary}
+
+// header starts every generated file, in the comment syntax of the target.
+const header = "Generated from Figma design tokens. Do not edit."
+
+// TargetOptions controls naming for one target. Empty fields take the
+// target's defaults.
+type TargetOptions struct {
+	// Prefix is prepended to every token name, e.g. "ds" for --ds-color-primary.
+	// Style Dictionary output ignores it; prefixes are applied at build time there.
+	Prefix string `json:"prefix,omitempty"`
+	Case   Case   `json:"case,omitempty"`
+	// Name is the generated SCSS map, TypeScript constant, Swift enum or
+	// Kotlin object.
+	Name string `json:"name,omitempty"`
+	// Package is the Kotlin package.
+	Package string `json:"package,omitempty"`
+	// ModeSelector is the CSS selector of a mode block; {mode} is replaced by
+	// the kebab-case mode name.
+	ModeSelector string `json:"modeSelector,omitempty"`
+}
+
+// Options is read from the "codegen" section of a config's data:
+//
+//	{"codegen": {"targets": ["css", "swift"], "prefix": "ds",
+//	             "overrides": {"swift": {"name": "Tokens", "prefix": "DS"}}}}
+//
+// Shared fields apply to every target; non-empty override fields replace them
+// for one target.
+type Options struct {
+	Targets []Target `json:"targets"`
+	TargetOptions
+	Overrides map[Target]*TargetOptions `json:"overrides,omitempty"`
+}
+
+// ConfigKey is the config data key holding Options.
+const ConfigKey = "codegen"
+
+var defaults = map[Target]TargetOptions{
+	CSS:             {Case: Kebab, ModeSelector: `[data-theme="{mode}"]`},
+	SCSS:            {Case: Kebab, Name: "tokens"},
+	TypeScript:      {Case: Camel, Name: "tokens"},
+	Swift:           {Case: Camel, Name: "DesignTokens"},
+	Kotlin:          {Case: Camel, Name: "DesignTokens"},
+	StyleDictionary: {Case: Kebab},
+}
+
+// OptionsFromConfig reads Options from resolved config data. ok is false when
+// the config has no codegen section.
+func OptionsFromConfig(data map[string]any) (opts Options, ok bool, err error) {
+	raw, ok := data[ConfigKey]
+	if !ok || raw == nil {
+		return Options{}, false, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return Options{}, false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		return Options{}, false, fmt.Errorf("codegen: invalid config: %w", err)
+	}
+	return opts, true, opts.Validate()
+}
+
+// Validate checks targets and cases.
+func (o *Options) Validate() error {
+	if len(o.Targets) == 0 {
+		return errors.New("codegen: no targets")
+	}
+	for _, t := range o.Targets {
+		if _, ok := defaults[t]; !ok {
+			return fmt.Errorf("codegen: unknown target %q", t)
+		}
+	}
+	for t := range o.Overrides {
+		if _, ok := defaults[t]; !ok {
+			return fmt.Errorf("codegen: unknown target %q in overrides", t)
+		}
+	}
+	for _, t := range o.Targets {
+		if c := o.For(t).Case; !validCase(c) {
+			return fmt.Errorf("codegen: unknown case %q", c)
+		}
+	}
+	return nil
+}
+
+// For returns the effective options of target t.
+func (o *Options) For(t Target) TargetOptions {
+	out := defaults[t]
+	merge := func(src *TargetOptions) {
+		if src == nil {
+			return
+		}
+		if src.Prefix != "" {
+			out.Prefix = src.Prefix
+		}
+		if src.Case != "" {
+			out.Case = src.Case
+		}
+		if src.Name != "" {
+			out.Name = src.Name
+		}
+		if src.Package != "" {
+			out.Package = src.Package
+		}
+		if src.ModeSelector != "" {
+			out.ModeSelector = src.ModeSelector
+		}
+	}
+	merge(&o.TargetOptions)
+	merge(o.Overrides[t])
+	return out
+}
+
+// File is a generated file; Name is a slash separated relative path.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Generate renders tree for every target in opts, in order.
+func Generate(tree *tokens.Tree, opts Options) ([]File, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var (
+		out  []File
+		done = map[Target]bool{}
+	)
+	for _, t := range opts.Targets {
+		if done[t] {
+			continue
+		}
+		done[t] = true
+		g := &gen{tree: tree, opts: opts.For(t), modes: modeNames(tree)}
+		var (
+			files []File
+			err   error
+		)
+		switch t {
+		case CSS:
+			files, err = g.css()
+		case SCSS:
+			files, err = g.scss()
+		case TypeScript:
+			files, err = g.typescript()
+		case Swift:
+			files, err = g.swift()
+		case Kotlin:
+			files, err = g.kotlin()
+		case StyleDictionary:
+			files, err = g.styleDictionary()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t, err)
+		}
+		out = append(out, files...)
+	}
+	return out, nil
+}
+
+// gen holds the state shared by the target renderers.
+type gen struct {
+	tree  *tokens.Tree
+	opts  TargetOptions
+	modes []string
+}
+
+// modeNames lists the mode names of the tree, collections in name order.
+func modeNames(tree *tokens.Tree) []string {
+	colls := make([]string, 0, len(tree.Modes))
+	for c := range tree.Modes {
+		colls = append(colls, c)
+	}
+	sort.Strings(colls)
+	var out []string
+	seen := map[string]bool{}
+	for _, c := range colls {
+		for _, m := range tree.Modes[c] {
+			if !seen[m] {
+				seen[m] = true
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// modeValue returns the value of tok in mode when it differs from the default.
+func modeValue(tok *tokens.Token, mode string) (any, bool) {
+	v, ok := tok.Modes[mode]
+	if !ok {
+		return nil, false
+	}
+	a, _ := json.Marshal(v)
+	b, _ := json.Marshal(tok.Value)
+	return v, !bytes.Equal(a, b)
+}
+
+// aliasTarget returns the token an alias value refers to.
+func (g *gen) aliasTarget(v any) (*tokens.Token, bool, error) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, false, nil
+	}
+	tok := g.tree.Find(s[1 : len(s)-1])
+	if tok == nil {
+		return nil, true, fmt.Errorf("alias %s refers to a missing token", s)
+	}
+	return tok, true, nil
+}
+
+// resolve follows aliases to a literal value.
+func (g *gen) resolve(v any) (any, error) {
+	for i := 0; i < 16; i++ {
+		tok, ok, err := g.aliasTarget(v)
+		if err != nil || !ok {
+			return v, err
+		}
+		v = tok.Value
+	}
+	return nil, errors.New("alias chain too deep")
+}
+
+// dimension parses a "8px" value.
+func dimension(v any) (float64, error) {
+	s, _ := v.(string)
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s, "px"), 64)
+	if err != nil || !strings.HasSuffix(s, "px") {
+		return 0, fmt.Errorf("invalid dimension %v", v)
+	}
+	return n, nil
+}
+
+// rgba parses a "#rrggbb" or "#rrggbbaa" color into 0-255 channels.
+func rgba(v any) ([4]int, error) {
+	s, _ := v.(string)
+	c := [4]int{0, 0, 0, 255}
+	if !strings.HasPrefix(s, "#") || (len(s) != 7 && len(s) != 9) {
+		return c, fmt.Errorf("invalid color %v", v)
+	}
+	for i := 0; i < (len(s)-1)/2; i++ {
+		n, err := strconv.ParseUint(s[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return c, fmt.Errorf("invalid color %v", v)
+		}
+		c[i] = int(n)
+	}
+	return c, nil
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+}
+
+// typography is a typography token value in typed form.
+type typography struct {
+	FontFamily    string
+	FontWeight    int
+	FontSize      float64
+	LineHeight    float64 // pixels; 0 is normal
+	LetterSpacing float64
+	Italic        bool
+	TextCase      string
+	Decoration    string
+}
+
+func parseTypography(v any) (typography, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return typography{}, fmt.Errorf("invalid typography %v", v)
+	}
+	var (
+		t   typography
+		err error
+	)
+	t.FontFamily, _ = m["fontFamily"].(string)
+	w, _ := m["fontWeight"].(float64)
+	t.FontWeight = int(w)
+	if t.FontSize, err = dimension(m["fontSize"]); err != nil {
+		return t, err
+	}
+	switch lh := m["lineHeight"].(type) {
+	case float64:
+		t.LineHeight = lh * t.FontSize
+	case string:
+		if lh != "normal" {
+			if t.LineHeight, err = dimension(lh); err != nil {
+				return t, err
+			}
+		}
+	}
+	if ls, ok := m["letterSpacing"]; ok {
+		if t.LetterSpacing, err = dimension(ls); err != nil {
+			return t, err
+		}
+	}
+	t.Italic = m["fontStyle"] == "italic"
+	t.TextCase, _ = m["textCase"].(string)
+	t.Decoration, _ = m["textDecoration"].(string)
+	return t, nil
+}
+
+// shadow is one shadow of an effect token.
+type shadow struct {
+	Inset              bool
+	X, Y, Blur, Spread float64
+	Color              string
+}
+
+// blur is a layer or background blur of an effect token.
+type blur struct {
+	Radius   float64
+	Backdrop bool
+}
+
+// parseEffect splits an effect value into shadows and blurs.
+func parseEffect(v any) ([]shadow, []blur, error) {
+	list, ok := v.([]map[string]any)
+	if !ok {
+		// Values decoded from JSON arrive as []any
+		raw, ok := v.([]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid effect %v", v)
+		}
+		for _, e := range raw {
+			m, ok := e.(map[string]any)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid effect %v", v)
+			}
+			list = append(list, m)
+		}
+	}
+	var (
+		shadows []shadow
+		blurs   []blur
+	)
+	for _, e := range list {
+		switch e["type"] {
+		case "dropShadow", "innerShadow":
+			s := shadow{Inset: e["type"] == "innerShadow", Color: "#000000"}
+			for _, f := range []struct {
+				key string
+				dst *float64
+			}{{"offsetX", &s.X}, {"offsetY", &s.Y}, {"blur", &s.Blur}, {"spread", &s.Spread}} {
+				n, err := dimension(e[f.key])
+				if err != nil {
+					return nil, nil, err
+				}
+				*f.dst = n
+			}
+			if c, ok := e["color"].(string); ok {
+				s.Color = c
+			}
+			shadows = append(shadows, s)
+		case "layerBlur", "backgroundBlur":
+			r, err := dimension(e["radius"])
+			if err != nil {
+				return nil, nil, err
+			}
+			blurs = append(blurs, blur{Radius: r, Backdrop: e["type"] == "backgroundBlur"})
+		}
+	}
+	return shadows, blurs, nil
+}
+
+// nameParts returns the parts a token name is built from.
+func nameParts(tok *tokens.Token) []string {
+	return append([]string{string(tok.Type)}, tok.Path...)
+}
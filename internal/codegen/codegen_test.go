@@ -0,0 +1,132 @@
+package codegen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// sampleTree covers every token type, an alias, a multi-mode token and an
+// override that equals the default.
+func sampleTree() *tokens.Tree {
+	return &tokens.Tree{
+		Modes: map[string][]string{"Theme": {"Light", "Dark"}},
+		Tokens: []tokens.Token{
+			{Path: []string{"Brand", "Primary"}, Type: tokens.Color, Value: "#1064f0", Description: "Main brand color"},
+			{Path: []string{"Surface"}, Type: tokens.Color, Value: "#ffffff", Modes: map[string]any{"Light": "#ffffff", "Dark": "#121212"}},
+			{Path: []string{"Text", "Link"}, Type: tokens.Color, Value: "{color.Brand.Primary}", Modes: map[string]any{"Light": "{color.Brand.Primary}", "Dark": "#8ab4f8"}},
+			{Path: []string{"Text", "Heading 1"}, Type: tokens.Typography, Value: map[string]any{
+				"fontFamily": "Inter", "fontWeight": 700.0, "fontSize": "32px", "lineHeight": "40px",
+				"letterSpacing": "-0.5px", "textCase": "upper",
+			}},
+			{Path: []string{"Text", "Body"}, Type: tokens.Typography, Value: map[string]any{
+				"fontFamily": "Inter", "fontWeight": 400.0, "fontSize": "16px", "lineHeight": 1.5, "letterSpacing": "0px",
+				"fontStyle": "italic",
+			}},
+			{Path: []string{"2"}, Type: tokens.Spacing, Value: "8px", Modes: map[string]any{"Light": "8px", "Dark": "8px"}},
+			{Path: []string{"Card"}, Type: tokens.Radius, Value: "{spacing.2}"},
+			{Path: []string{"Elevation", "1"}, Type: tokens.Effect, Value: []map[string]any{
+				{"type": "dropShadow", "offsetX": "0px", "offsetY": "2px", "blur": "8px", "spread": "0px", "color": "#00000040"},
+				{"type": "backgroundBlur", "radius": "4px"},
+			}},
+		},
+	}
+}
+
+func TestGenerate_Golden(t *testing.T) {
+	opts := Options{
+		Targets:       Targets,
+		TargetOptions: TargetOptions{Prefix: "ds"},
+		Overrides: map[Target]*TargetOptions{
+			Kotlin: {Package: "com.example.tokens"},
+		},
+	}
+	files, err := Generate(sampleTree(), opts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+		golden := filepath.Join("testdata", strings.ReplaceAll(f.Name, "/", "_")+".golden")
+		if *update {
+			if err := os.WriteFile(golden, f.Data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("read %s (run with -update to create): %v", golden, err)
+		}
+		if !bytes.Equal(f.Data, want) {
+			t.Errorf("%s differs from %s:\n%s", f.Name, golden, f.Data)
+		}
+	}
+	want := "css/tokens.css scss/_tokens.scss ts/tokens.ts swift/DesignTokens.swift kotlin/DesignTokens.kt " +
+		"style-dictionary/tokens.json style-dictionary/tokens.dark.json"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("files = %s, want %s", got, want)
+	}
+}
+
+func TestGenerate_Collision(t *testing.T) {
+	tree := &tokens.Tree{Tokens: []tokens.Token{
+		{Path: []string{"Brand", "Primary"}, Type: tokens.Color, Value: "#000000"},
+		{Path: []string{"brand-primary"}, Type: tokens.Color, Value: "#ffffff"},
+	}}
+	_, err := Generate(tree, Options{Targets: []Target{CSS}})
+	if err == nil || !strings.Contains(err.Error(), "both map to") {
+		t.Fatalf("err = %v, want a name collision", err)
+	}
+}
+
+func TestGenerate_MissingAlias(t *testing.T) {
+	tree := &tokens.Tree{Tokens: []tokens.Token{
+		{Path: []string{"Link"}, Type: tokens.Color, Value: "{color.Nope}"},
+	}}
+	if _, err := Generate(tree, Options{Targets: []Target{TypeScript}}); err == nil {
+		t.Fatal("expected an error for a dangling alias")
+	}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	_, ok, err := OptionsFromConfig(map[string]any{"tokens": map[string]any{}})
+	if ok || err != nil {
+		t.Fatalf("no section: ok=%v err=%v", ok, err)
+	}
+	opts, ok, err := OptionsFromConfig(map[string]any{"codegen": map[string]any{
+		"targets":   []any{"css", "swift"},
+		"prefix":    "ds",
+		"case":      "snake",
+		"overrides": map[string]any{"swift": map[string]any{"name": "Tokens", "case": "camel"}},
+	}})
+	if !ok || err != nil {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if got := opts.For(CSS); got.Case != Snake || got.Prefix != "ds" || got.ModeSelector == "" {
+		t.Fatalf("css options = %+v", got)
+	}
+	if got := opts.For(Swift); got.Case != Camel || got.Name != "Tokens" || got.Prefix != "ds" {
+		t.Fatalf("swift options = %+v", got)
+	}
+
+	for _, bad := range []map[string]any{
+		{"targets": []any{}},
+		{"targets": []any{"java"}},
+		{"targets": []any{"css"}, "case": "title"},
+		{"targets": []any{"css"}, "overrides": map[string]any{"java": map[string]any{}}},
+		{"targets": []any{"css"}, "unknown": true},
+	} {
+		if _, _, err := OptionsFromConfig(map[string]any{"codegen": bad}); err == nil {
+			t.Fatalf("%v: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// cssDecl is one custom property.
+type cssDecl struct {
+	name, value string
+}
+
+// css renders custom properties: defaults on :root and one block per mode
+// holding the values that differ from the default.
+func (g *gen) css() ([]File, error) {
+	n := newNamer(g.opts.Case, g.opts.Prefix)
+	var b strings.Builder
+	b.WriteString("/* " + header + " */\n\n:root {\n")
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		decls, err := g.cssDecls(n, tok, tok.Value)
+		if err != nil {
+			return nil, err
+		}
+		writeCSSDecls(&b, decls)
+	}
+	b.WriteString("}\n")
+
+	for _, mode := range g.modes {
+		var block strings.Builder
+		for i := range g.tree.Tokens {
+			tok := &g.tree.Tokens[i]
+			v, ok := modeValue(tok, mode)
+			if !ok {
+				continue
+			}
+			decls, err := g.cssDecls(n, tok, v)
+			if err != nil {
+				return nil, err
+			}
+			writeCSSDecls(&block, decls)
+		}
+		if block.Len() > 0 {
+			sel := strings.ReplaceAll(g.opts.ModeSelector, "{mode}", format(Kebab, words(mode)))
+			b.WriteString("\n" + sel + " {\n" + block.String() + "}\n")
+		}
+	}
+	return []File{{Name: "css/tokens.css", ContentType: "text/css", Data: []byte(b.String())}}, nil
+}
+
+func writeCSSDecls(b *strings.Builder, decls []cssDecl) {
+	for _, d := range decls {
+		fmt.Fprintf(b, "  --%s: %s;\n", d.name, d.value)
+	}
+}
+
+// cssDecls renders the custom properties of a token with value v. Aliases
+// become var() references; typography is split into one property per field.
+func (g *gen) cssDecls(n *namer, tok *tokens.Token, v any) ([]cssDecl, error) {
+	id := tok.Name()
+	name, err := n.name(id, nameParts(tok)...)
+	if err != nil {
+		return nil, err
+	}
+	if target, ok, err := g.aliasTarget(v); err != nil {
+		return nil, err
+	} else if ok {
+		ref, err := n.name(target.Name(), nameParts(target)...)
+		if err != nil {
+			return nil, err
+		}
+		return []cssDecl{{name, "var(--" + ref + ")"}}, nil
+	}
+
+	switch tok.Type {
+	case tokens.Typography:
+		t, err := parseTypography(v)
+		if err != nil {
+			return nil, err
+		}
+		var decls []cssDecl
+		add := func(field, value string) error {
+			fn, err := n.name(id+"#"+field, append(nameParts(tok), field)...)
+			if err == nil {
+				decls = append(decls, cssDecl{fn, value})
+			}
+			return err
+		}
+		lineHeight := "normal"
+		if t.LineHeight > 0 {
+			lineHeight = num(t.LineHeight) + "px"
+		}
+		fields := [][2]string{
+			{"font-family", cssString(t.FontFamily)},
+			{"font-weight", fmt.Sprint(t.FontWeight)},
+			{"font-size", num(t.FontSize) + "px"},
+			{"line-height", lineHeight},
+			{"letter-spacing", num(t.LetterSpacing) + "px"},
+		}
+		if t.Italic {
+			fields = append(fields, [2]string{"font-style", "italic"})
+		}
+		if tt := cssTextTransform(t.TextCase); tt != "" {
+			fields = append(fields, [2]string{"text-transform", tt})
+		}
+		if t.Decoration != "" {
+			fields = append(fields, [2]string{"text-decoration", strings.ReplaceAll(t.Decoration, "_", "-")})
+		}
+		for _, f := range fields {
+			if err := add(f[0], f[1]); err != nil {
+				return nil, err
+			}
+		}
+		return decls, nil
+	case tokens.Effect:
+		shadows, blurs, err := parseEffect(v)
+		if err != nil {
+			return nil, err
+		}
+		var decls []cssDecl
+		if len(shadows) > 0 {
+			decls = append(decls, cssDecl{name, cssShadows(shadows)})
+		}
+		for _, bl := range blurs {
+			field := "blur"
+			if bl.Backdrop {
+				field = "backdrop-blur"
+			}
+			fn, err := n.name(id+"#"+field, append(nameParts(tok), field)...)
+			if err != nil {
+				return nil, err
+			}
+			decls = append(decls, cssDecl{fn, "blur(" + num(bl.Radius) + "px)"})
+		}
+		return decls, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: unexpected value %v", id, v)
+	}
+	return []cssDecl{{name, s}}, nil
+}
+
+func cssShadows(shadows []shadow) string {
+	parts := make([]string, len(shadows))
+	for i, s := range shadows {
+		p := fmt.Sprintf("%spx %spx %spx %spx %s", num(s.X), num(s.Y), num(s.Blur), num(s.Spread), s.Color)
+		if s.Inset {
+			p = "inset " + p
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, ", ")
+}
+
+func cssTextTransform(textCase string) string {
+	switch textCase {
+	case "upper":
+		return "uppercase"
+	case "lower":
+		return "lowercase"
+	case "title":
+		return "capitalize"
+	}
+	return ""
+}
+
+// cssString quotes s as a CSS string.
+func cssString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,29 @@
+package codegen
+
+import (
+	"bytes"
+	"context"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// Emit is a tokens.Emitter storing the generated files of a job as
+// artifacts. Jobs whose config has no codegen section are left alone.
+func Emit(ctx context.Context, job *ent.ExportJob, tree *tokens.Tree, out *exportx.Output) error {
+	opts, ok, err := OptionsFromConfig(job.Config)
+	if err != nil || !ok {
+		return err
+	}
+	files, err := Generate(tree, opts)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := out.Put(ctx, f.Name, f.ContentType, bytes.NewReader(f.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
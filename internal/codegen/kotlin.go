@@ -0,0 +1,150 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+const kotlinImports = `import androidx.compose.ui.graphics.Color
+import androidx.compose.ui.unit.Dp
+import androidx.compose.ui.unit.TextUnit
+import androidx.compose.ui.unit.dp
+import androidx.compose.ui.unit.sp
+`
+
+const kotlinTypes = `
+    data class TextStyle(
+        val fontFamily: String,
+        val fontWeight: Int,
+        val fontSize: TextUnit,
+        val lineHeight: TextUnit,
+        val letterSpacing: TextUnit,
+        val italic: Boolean,
+    )
+
+    data class Shadow(
+        val color: Color,
+        val x: Dp,
+        val y: Dp,
+        val blur: Dp,
+        val spread: Dp,
+        val inset: Boolean,
+    )
+`
+
+// kotlin renders a Jetpack Compose object of constants, with a nested object
+// per mode holding the values that differ from the default. Aliases are
+// resolved and blur effects are left out.
+func (g *gen) kotlin() ([]File, error) {
+	n := newNamer(g.opts.Case, g.opts.Prefix)
+	obj := identifier(format(Pascal, words(g.opts.Name)))
+
+	var b strings.Builder
+	b.WriteString("// " + header + "\n\n")
+	if g.opts.Package != "" {
+		fmt.Fprintf(&b, "package %s\n\n", g.opts.Package)
+	}
+	b.WriteString(kotlinImports)
+	fmt.Fprintf(&b, "\nobject %s {\n", obj)
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		if err := g.kotlinDecl(&b, n, tok, tok.Value, "    ", true); err != nil {
+			return nil, err
+		}
+	}
+	for _, mode := range g.modes {
+		var block strings.Builder
+		for i := range g.tree.Tokens {
+			tok := &g.tree.Tokens[i]
+			if v, ok := modeValue(tok, mode); ok {
+				if err := g.kotlinDecl(&block, n, tok, v, "        ", false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if block.Len() > 0 {
+			fmt.Fprintf(&b, "\n    object %s {\n%s    }\n", identifier(format(Pascal, words(mode))), block.String())
+		}
+	}
+	b.WriteString(kotlinTypes)
+	b.WriteString("}\n")
+	return []File{{Name: "kotlin/" + obj + ".kt", ContentType: "text/x-kotlin", Data: []byte(b.String())}}, nil
+}
+
+func (g *gen) kotlinDecl(b *strings.Builder, n *namer, tok *tokens.Token, v any, indent string, doc bool) error {
+	name, err := n.name(tok.Name(), nameParts(tok)...)
+	if err != nil {
+		return err
+	}
+	if v, err = g.resolve(v); err != nil {
+		return err
+	}
+	var lit string
+	switch tok.Type {
+	case tokens.Color:
+		if lit, err = kotlinColor(v); err != nil {
+			return err
+		}
+	case tokens.Spacing, tokens.Radius:
+		d, err := dimension(v)
+		if err != nil {
+			return err
+		}
+		lit = kotlinUnit(d, "dp")
+	case tokens.Typography:
+		t, err := parseTypography(v)
+		if err != nil {
+			return err
+		}
+		lineHeight := "TextUnit.Unspecified"
+		if t.LineHeight > 0 {
+			lineHeight = kotlinUnit(t.LineHeight, "sp")
+		}
+		lit = fmt.Sprintf("TextStyle(fontFamily = %s, fontWeight = %d, fontSize = %s, lineHeight = %s, letterSpacing = %s, italic = %t)",
+			strconv.Quote(t.FontFamily), t.FontWeight, kotlinUnit(t.FontSize, "sp"), lineHeight, kotlinUnit(t.LetterSpacing, "sp"), t.Italic)
+	case tokens.Effect:
+		shadows, _, err := parseEffect(v)
+		if err != nil {
+			return err
+		}
+		parts := make([]string, len(shadows))
+		for i, s := range shadows {
+			c, err := kotlinColor(s.Color)
+			if err != nil {
+				return err
+			}
+			parts[i] = fmt.Sprintf("Shadow(color = %s, x = %s, y = %s, blur = %s, spread = %s, inset = %t)",
+				c, kotlinUnit(s.X, "dp"), kotlinUnit(s.Y, "dp"), kotlinUnit(s.Blur, "dp"), kotlinUnit(s.Spread, "dp"), s.Inset)
+		}
+		lit = "listOf<Shadow>(" + strings.Join(parts, ", ") + ")"
+	default:
+		return fmt.Errorf("%s: unsupported type %s", tok.Name(), tok.Type)
+	}
+	if doc && tok.Description != "" {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(tok.Description, "*/", "* /"))
+	}
+	fmt.Fprintf(b, "%sval %s = %s\n", indent, identifier(name), lit)
+	return nil
+}
+
+// kotlinColor formats a Compose Color(0xAARRGGBB).
+func kotlinColor(v any) (string, error) {
+	c, err := rgba(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Color(0x%02X%02X%02X%02X)", c[3], c[0], c[1], c[2]), nil
+}
+
+// kotlinUnit formats a dp or sp value; negative numbers are parenthesized
+// so the extension applies to the literal.
+func kotlinUnit(v float64, unit string) string {
+	s := num(v)
+	if v < 0 {
+		s = "(" + s + ")"
+	}
+	return s + "." + unit
+}
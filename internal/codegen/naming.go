@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"fmt"
+	"unicode"
+
+	"fiber-ent-apollo-pg/internal/naming"
+)
+
+// Case is a naming convention for generated identifiers.
+type Case = naming.Case
+
+// Supported cases.
+const (
+	Kebab    = naming.Kebab
+	Snake    = naming.Snake
+	Camel    = naming.Camel
+	Pascal   = naming.Pascal
+	Constant = naming.Constant
+)
+
+func validCase(c Case) bool { return c.Valid() }
+
+func words(parts ...string) []string { return naming.Words(parts...) }
+
+func format(c Case, ws []string) string { return naming.Format(c, ws) }
+
+// identifier makes s a valid identifier in languages that forbid a leading
+// digit.
+func identifier(s string) string {
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		return "_" + s
+	}
+	return s
+}
+
+// namer assigns unique names to tokens.
+type namer struct {
+	c      Case
+	prefix []string
+	seen   map[string]string
+}
+
+func newNamer(c Case, prefix string) *namer {
+	return &namer{c: c, prefix: words(prefix), seen: map[string]string{}}
+}
+
+// name formats the prefix and parts; two tokens may not share a name.
+func (n *namer) name(token string, parts ...string) (string, error) {
+	s := format(n.c, append(append([]string(nil), n.prefix...), words(parts...)...))
+	if other, ok := n.seen[s]; ok && other != token {
+		return "", fmt.Errorf("codegen: %s and %s both map to name %q", other, token, s)
+	}
+	n.seen[s] = token
+	return s, nil
+}
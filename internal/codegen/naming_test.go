@@ -0,0 +1,20 @@
+package codegen
+
+import "testing"
+
+func TestNamer(t *testing.T) {
+	n := newNamer(Camel, "ds")
+	got, err := n.name("color.Brand.Primary", "color", "Brand", "Primary")
+	if err != nil || got != "dsColorBrandPrimary" {
+		t.Fatalf("name = %q, %v", got, err)
+	}
+	if _, err := n.name("color.Brand.Primary", "color", "Brand", "Primary"); err != nil {
+		t.Fatalf("same token twice: %v", err)
+	}
+	if _, err := n.name("color.brand-primary", "color", "brand-primary"); err == nil {
+		t.Fatal("expected a collision")
+	}
+	if identifier("2xl") != "_2xl" || identifier("xl") != "xl" {
+		t.Fatal("identifier should guard a leading digit")
+	}
+}
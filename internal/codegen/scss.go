@@ -0,0 +1,126 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// scss renders one variable per token, a map of all tokens and one map per
+// mode holding the values that differ from the default.
+func (g *gen) scss() ([]File, error) {
+	vars := newNamer(g.opts.Case, g.opts.Prefix)
+	keys := newNamer(g.opts.Case, "")
+	mapName := format(g.opts.Case, words(g.opts.Prefix, g.opts.Name))
+
+	var b strings.Builder
+	b.WriteString("// " + header + "\n\n")
+	defined := map[string]string{}
+	var entries [][2]string
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		name, err := vars.name(tok.Name(), nameParts(tok)...)
+		if err != nil {
+			return nil, err
+		}
+		key, err := keys.name(tok.Name(), nameParts(tok)...)
+		if err != nil {
+			return nil, err
+		}
+		value, err := g.scssValue(tok, tok.Value, defined)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "$%s: %s;\n", name, value)
+		defined[tok.Name()] = "$" + name
+		entries = append(entries, [2]string{key, "$" + name})
+	}
+	writeSCSSMap(&b, mapName, entries)
+
+	for _, mode := range g.modes {
+		var entries [][2]string
+		for i := range g.tree.Tokens {
+			tok := &g.tree.Tokens[i]
+			v, ok := modeValue(tok, mode)
+			if !ok {
+				continue
+			}
+			key, _ := keys.name(tok.Name(), nameParts(tok)...)
+			value, err := g.scssValue(tok, v, defined)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, [2]string{key, value})
+		}
+		if len(entries) > 0 {
+			writeSCSSMap(&b, format(g.opts.Case, append(words(g.opts.Prefix, g.opts.Name), words(mode)...)), entries)
+		}
+	}
+	return []File{{Name: "scss/_tokens.scss", ContentType: "text/x-scss", Data: []byte(b.String())}}, nil
+}
+
+func writeSCSSMap(b *strings.Builder, name string, entries [][2]string) {
+	fmt.Fprintf(b, "\n$%s: (\n", name)
+	for _, e := range entries {
+		fmt.Fprintf(b, "  %q: %s,\n", e[0], e[1])
+	}
+	b.WriteString(");\n")
+}
+
+// scssValue renders v. Aliases refer to the variable of their target when it
+// is already defined and are resolved otherwise.
+func (g *gen) scssValue(tok *tokens.Token, v any, defined map[string]string) (string, error) {
+	if target, ok, err := g.aliasTarget(v); err != nil {
+		return "", err
+	} else if ok {
+		if ref, ok := defined[target.Name()]; ok {
+			return ref, nil
+		}
+		if v, err = g.resolve(v); err != nil {
+			return "", err
+		}
+	}
+	switch tok.Type {
+	case tokens.Typography:
+		t, err := parseTypography(v)
+		if err != nil {
+			return "", err
+		}
+		lineHeight := "normal"
+		if t.LineHeight > 0 {
+			lineHeight = num(t.LineHeight) + "px"
+		}
+		fields := []string{
+			fmt.Sprintf(`"font-family": %s`, cssString(t.FontFamily)),
+			fmt.Sprintf(`"font-weight": %d`, t.FontWeight),
+			fmt.Sprintf(`"font-size": %spx`, num(t.FontSize)),
+			fmt.Sprintf(`"line-height": %s`, lineHeight),
+			fmt.Sprintf(`"letter-spacing": %spx`, num(t.LetterSpacing)),
+		}
+		if t.Italic {
+			fields = append(fields, `"font-style": italic`)
+		}
+		if tt := cssTextTransform(t.TextCase); tt != "" {
+			fields = append(fields, `"text-transform": `+tt)
+		}
+		return "(" + strings.Join(fields, ", ") + ")", nil
+	case tokens.Effect:
+		shadows, blurs, err := parseEffect(v)
+		if err != nil {
+			return "", err
+		}
+		if len(shadows) > 0 {
+			return cssShadows(shadows), nil
+		}
+		if len(blurs) > 0 {
+			return "blur(" + num(blurs[0].Radius) + "px)", nil
+		}
+		return "none", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: unexpected value %v", tok.Name(), v)
+	}
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package codegen
+
+import (
+	"encoding/json"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// styleDictionary renders Style Dictionary source JSON: nested groups with
+// value, type and comment. Path segments are cased individually and aliases
+// keep the {group.name} reference syntax. Each mode with overrides gets its
+// own file, meant to be layered over tokens.json.
+func (g *gen) styleDictionary() ([]File, error) {
+	n := newNamer(g.opts.Case, "")
+	root, err := g.sdTree(n, func(tok *tokens.Token) (any, bool) { return tok.Value, true })
+	if err != nil {
+		return nil, err
+	}
+	files := []File{{Name: "style-dictionary/tokens.json", ContentType: "application/json", Data: root}}
+	for _, mode := range g.modes {
+		mode := mode
+		data, err := g.sdTree(n, func(tok *tokens.Token) (any, bool) { return modeValue(tok, mode) })
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			files = append(files, File{Name: "style-dictionary/tokens." + format(Kebab, words(mode)) + ".json", ContentType: "application/json", Data: data})
+		}
+	}
+	return files, nil
+}
+
+// sdPath cases each segment of the token path.
+func (g *gen) sdPath(n *namer, tok *tokens.Token) ([]string, error) {
+	parts := nameParts(tok)
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		out[i] = format(g.opts.Case, words(p))
+	}
+	_, err := n.name(tok.Name(), strings.Join(out, "/"))
+	return out, err
+}
+
+func (g *gen) sdTree(n *namer, value func(*tokens.Token) (any, bool)) ([]byte, error) {
+	root := map[string]any{}
+	count := 0
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		v, ok := value(tok)
+		if !ok {
+			continue
+		}
+		path, err := g.sdPath(n, tok)
+		if err != nil {
+			return nil, err
+		}
+		if target, ok, err := g.aliasTarget(v); err != nil {
+			return nil, err
+		} else if ok {
+			tp, err := g.sdPath(n, target)
+			if err != nil {
+				return nil, err
+			}
+			v = "{" + strings.Join(tp, ".") + "}"
+		}
+		group := root
+		for _, seg := range path[:len(path)-1] {
+			next, ok := group[seg].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				group[seg] = next
+			}
+			group = next
+		}
+		leaf := map[string]any{"value": v, "type": string(tok.Type)}
+		if tok.Description != "" {
+			leaf["comment"] = tok.Description
+		}
+		group[path[len(path)-1]] = leaf
+		count++
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	b, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
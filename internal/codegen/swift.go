@@ -0,0 +1,132 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+const swiftTypes = `
+    public struct TextStyle {
+        public let fontFamily: String
+        public let fontWeight: Int
+        public let fontSize: CGFloat
+        /// nil keeps the font's natural line height
+        public let lineHeight: CGFloat?
+        public let letterSpacing: CGFloat
+        public let italic: Bool
+    }
+
+    public struct Shadow {
+        public let color: Color
+        public let x: CGFloat
+        public let y: CGFloat
+        public let blur: CGFloat
+        public let spread: CGFloat
+        public let inset: Bool
+    }
+`
+
+// swift renders a SwiftUI enum of static constants, with a nested enum per
+// mode holding the values that differ from the default. Aliases are resolved
+// and blur effects, which SwiftUI applies as view modifiers, are left out.
+func (g *gen) swift() ([]File, error) {
+	n := newNamer(g.opts.Case, g.opts.Prefix)
+	typ := identifier(format(Pascal, words(g.opts.Name)))
+
+	var b strings.Builder
+	b.WriteString("// " + header + "\n\nimport SwiftUI\n\n")
+	fmt.Fprintf(&b, "public enum %s {\n", typ)
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		if err := g.swiftDecl(&b, n, tok, tok.Value, "    ", true); err != nil {
+			return nil, err
+		}
+	}
+	for _, mode := range g.modes {
+		var block strings.Builder
+		for i := range g.tree.Tokens {
+			tok := &g.tree.Tokens[i]
+			if v, ok := modeValue(tok, mode); ok {
+				if err := g.swiftDecl(&block, n, tok, v, "        ", false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if block.Len() > 0 {
+			fmt.Fprintf(&b, "\n    public enum %s {\n%s    }\n", identifier(format(Pascal, words(mode))), block.String())
+		}
+	}
+	b.WriteString(swiftTypes)
+	b.WriteString("}\n")
+	return []File{{Name: "swift/" + typ + ".swift", ContentType: "text/x-swift", Data: []byte(b.String())}}, nil
+}
+
+func (g *gen) swiftDecl(b *strings.Builder, n *namer, tok *tokens.Token, v any, indent string, doc bool) error {
+	name, err := n.name(tok.Name(), nameParts(tok)...)
+	if err != nil {
+		return err
+	}
+	if v, err = g.resolve(v); err != nil {
+		return err
+	}
+	var typ, lit string
+	switch tok.Type {
+	case tokens.Color:
+		typ = "Color"
+		if lit, err = swiftColor(v); err != nil {
+			return err
+		}
+	case tokens.Spacing, tokens.Radius:
+		d, err := dimension(v)
+		if err != nil {
+			return err
+		}
+		typ, lit = "CGFloat", num(d)
+	case tokens.Typography:
+		t, err := parseTypography(v)
+		if err != nil {
+			return err
+		}
+		lineHeight := "nil"
+		if t.LineHeight > 0 {
+			lineHeight = num(t.LineHeight)
+		}
+		typ = "TextStyle"
+		lit = fmt.Sprintf("TextStyle(fontFamily: %s, fontWeight: %d, fontSize: %s, lineHeight: %s, letterSpacing: %s, italic: %t)",
+			strconv.Quote(t.FontFamily), t.FontWeight, num(t.FontSize), lineHeight, num(t.LetterSpacing), t.Italic)
+	case tokens.Effect:
+		shadows, _, err := parseEffect(v)
+		if err != nil {
+			return err
+		}
+		parts := make([]string, len(shadows))
+		for i, s := range shadows {
+			c, err := swiftColor(s.Color)
+			if err != nil {
+				return err
+			}
+			parts[i] = fmt.Sprintf("Shadow(color: %s, x: %s, y: %s, blur: %s, spread: %s, inset: %t)",
+				c, num(s.X), num(s.Y), num(s.Blur), num(s.Spread), s.Inset)
+		}
+		typ, lit = "[Shadow]", "["+strings.Join(parts, ", ")+"]"
+	default:
+		return fmt.Errorf("%s: unsupported type %s", tok.Name(), tok.Type)
+	}
+	if doc && tok.Description != "" {
+		fmt.Fprintf(b, "%s/// %s\n", indent, strings.ReplaceAll(tok.Description, "\n", " "))
+	}
+	fmt.Fprintf(b, "%spublic static let %s: %s = %s\n", indent, identifier(name), typ, lit)
+	return nil
+}
+
+func swiftColor(v any) (string, error) {
+	c, err := rgba(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Color(red: %s, green: %s, blue: %s, opacity: %s)",
+		num(float64(c[0])/255), num(float64(c[1])/255), num(float64(c[2])/255), num(float64(c[3])/255)), nil
+}
@@ -0,0 +1,70 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/tokens"
+)
+
+// typescript renders a const object of all tokens with aliases resolved, a
+// TokenName type and, when the tree has modes, a const object of per-mode
+// overrides.
+func (g *gen) typescript() ([]File, error) {
+	n := newNamer(g.opts.Case, g.opts.Prefix)
+	obj := identifier(format(Camel, words(g.opts.Name)))
+
+	var b strings.Builder
+	b.WriteString("// " + header + "\n\n")
+	fmt.Fprintf(&b, "export const %s = {\n", obj)
+	for i := range g.tree.Tokens {
+		tok := &g.tree.Tokens[i]
+		if err := g.tsEntry(&b, n, tok, tok.Value, "  ", true); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("} as const;\n\n")
+	fmt.Fprintf(&b, "export type %s = keyof typeof %s;\n", identifier(format(Pascal, words(g.opts.Name, "name"))), obj)
+
+	var modes strings.Builder
+	for _, mode := range g.modes {
+		var block strings.Builder
+		for i := range g.tree.Tokens {
+			tok := &g.tree.Tokens[i]
+			if v, ok := modeValue(tok, mode); ok {
+				if err := g.tsEntry(&block, n, tok, v, "    ", false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if block.Len() > 0 {
+			fmt.Fprintf(&modes, "  %s: {\n%s  },\n", identifier(format(Camel, words(mode))), block.String())
+		}
+	}
+	if modes.Len() > 0 {
+		fmt.Fprintf(&b, "\nexport const %s = {\n%s} as const;\n", identifier(format(Camel, words(g.opts.Name, "modes"))), modes.String())
+	}
+	return []File{{Name: "ts/tokens.ts", ContentType: "text/typescript", Data: []byte(b.String())}}, nil
+}
+
+func (g *gen) tsEntry(b *strings.Builder, n *namer, tok *tokens.Token, v any, indent string, doc bool) error {
+	name, err := n.name(tok.Name(), nameParts(tok)...)
+	if err != nil {
+		return err
+	}
+	if v, err = g.resolve(v); err != nil {
+		return err
+	}
+	// JSON is valid TypeScript; maps marshal with sorted keys, so the
+	// output is stable
+	lit, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if doc && tok.Description != "" {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(tok.Description, "*/", "* /"))
+	}
+	fmt.Fprintf(b, "%s%s: %s,\n", indent, identifier(name), lit)
+	return nil
+}
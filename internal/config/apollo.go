@@ -65,11 +65,19 @@ func applyApolloOverrides(client agollo.Client, namespace string, cfg *Config) {
 	setStringFromCache(cache, "es.addrs", &cfg.ES.Addrs)
 	setStringFromCache(cache, "es.username", &cfg.ES.Username)
 	setStringFromCache(cache, "es.password", &cfg.ES.Password)
+	setStringFromCache(cache, "figma.api_base", &cfg.Figma.BaseURL)
+	setStringFromCache(cache, "figma.token", &cfg.Figma.Token)
+	setStringFromCache(cache, "figma.webhook_passcode", &cfg.Figma.WebhookPasscode)
+	setStringFromCache(cache, "blob.dir", &cfg.Blob.Dir)
 
 	// Integer assignments
 	setIntFromCache(cache, "pg.max_open", &cfg.PG.MaxOpenConns)
 	setIntFromCache(cache, "pg.max_idle", &cfg.PG.MaxIdleConns)
 	setIntFromCache(cache, "redis.db", &cfg.Redis.DB)
+	setIntFromCache(cache, "figma.timeout_sec", &cfg.Figma.TimeoutSec)
+	setIntFromCache(cache, "figma.webhook_debounce_sec", &cfg.Figma.WebhookDebounceSec)
+	setIntFromCache(cache, "artifact.retention_days", &cfg.Blob.RetentionDays)
+	setIntFromCache(cache, "blob.gc_grace_min", &cfg.Blob.GCGraceMin)
 }
 
 func setStringFromCache(cache agcache.CacheInterface, key string, target *string) {
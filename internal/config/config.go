@@ -39,6 +39,18 @@ type Config struct {
 		Username string
 		Password string
 	}
+	Figma struct {
+		BaseURL            string // REST API base, overridable for local stubs
+		Token              string // personal access token
+		TimeoutSec         int
+		WebhookPasscode    string // passcode of Figma webhooks; empty disables the receiver
+		WebhookDebounceSec int    // events of a project within this window trigger one export
+	}
+	Blob struct {
+		Dir           string // local blob store root
+		RetentionDays int    // artifacts of older finished exports are deleted; 0 keeps them
+		GCGraceMin    int    // unreferenced blobs younger than this are kept
+	}
 	Apollo struct {
 		Enable    bool
 		AppID     string
@@ -102,6 +114,18 @@ func Load() (*Config, *Store, func(), error) {
 	cfg.ES.Username = getEnv("ES_USERNAME", "")
 	cfg.ES.Password = getEnv("ES_PASSWORD", "")
 
+	// Figma
+	cfg.Figma.BaseURL = getEnv("FIGMA_API_BASE", "https://api.figma.com")
+	cfg.Figma.Token = getEnv("FIGMA_TOKEN", "")
+	cfg.Figma.TimeoutSec = getInt("FIGMA_TIMEOUT_SEC", 15)
+	cfg.Figma.WebhookPasscode = getEnv("FIGMA_WEBHOOK_PASSCODE", "")
+	cfg.Figma.WebhookDebounceSec = getInt("FIGMA_WEBHOOK_DEBOUNCE_SEC", 60)
+
+	// Artifact blobs
+	cfg.Blob.Dir = getEnv("BLOB_DIR", "./data/blobs")
+	cfg.Blob.RetentionDays = getInt("ARTIFACT_RETENTION_DAYS", 30)
+	cfg.Blob.GCGraceMin = getInt("BLOB_GC_GRACE_MIN", 60)
+
 	cfg.Apollo.Enable = getBool("APOLLO_ENABLE", false)
 	cfg.Apollo.AppID = getEnv("APOLLO_APP_ID", "")
 	cfg.Apollo.Cluster = getEnv("APOLLO_CLUSTER", "default")
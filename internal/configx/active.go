@@ -0,0 +1,35 @@
+package configx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/ent/projectconfig"
+)
+
+// ErrNoActiveConfig is returned when a project has no active config.
+var ErrNoActiveConfig = errors.New("project has no active config")
+
+// ResolveActive loads the active config of a project and resolves it over the
+// parents visible to uid. The returned association has its config item loaded.
+func ResolveActive(ctx context.Context, client *ent.Client, projID, uid uuid.UUID) (*ent.ProjectConfig, *Resolved, error) {
+	pc, err := client.ProjectConfig.Query().
+		Where(projectconfig.HasProjectWith(project.IDEQ(projID)), projectconfig.ActiveEQ(true)).
+		WithConfigItem().
+		First(ctx)
+	if ent.IsNotFound(err) || (err == nil && pc.Edges.ConfigItem == nil) {
+		return nil, nil, ErrNoActiveConfig
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := Resolve(ctx, pc.Edges.ConfigItem, VisibleLoader(client, uid))
+	if err != nil {
+		return nil, nil, err
+	}
+	return pc, res, nil
+}
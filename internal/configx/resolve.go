@@ -0,0 +1,139 @@
+// Package configx holds config logic shared by HTTP handlers: resolving layered
+// configs, where a config may declare ordered parent configs whose resolved data
+// it is deep-merged on top of, and config visibility rules.
+package configx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/jsonx"
+)
+
+// MaxLayers bounds the number of distinct configs in one resolution.
+const MaxLayers = 32
+
+// ErrTooManyLayers is returned when a parent chain exceeds MaxLayers configs.
+var ErrTooManyLayers = fmt.Errorf("config inherits from more than %d layers", MaxLayers)
+
+// Loader fetches a parent config by id.
+type Loader func(ctx context.Context, id uuid.UUID) (*ent.ConfigItem, error)
+
+// Layer is one config taking part in a resolution.
+type Layer struct {
+	ConfigID uuid.UUID `json:"config_id"`
+	Name     string    `json:"name"`
+	Revision int       `json:"revision"`
+}
+
+// Resolved is the result of merging a config's parent chain.
+type Resolved struct {
+	Data map[string]any `json:"data"`
+	// Layers in merge order: every parent precedes the configs layered on it
+	// and the resolved config itself comes last.
+	Layers []Layer `json:"layers"`
+	// Sources maps each leaf pointer of Data to the config that supplied it.
+	Sources map[string]uuid.UUID `json:"sources"`
+}
+
+// CycleError reports a parent chain that loops back on itself.
+type CycleError struct {
+	Path []uuid.UUID
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, len(e.Path))
+	for i, id := range e.Path {
+		ids[i] = id.String()
+	}
+	return "config parents form a cycle: " + strings.Join(ids, " -> ")
+}
+
+// ParentError reports a parent that could not be loaded.
+type ParentError struct {
+	ID  uuid.UUID
+	Err error
+}
+
+func (e *ParentError) Error() string {
+	return fmt.Sprintf("load parent config %s: %v", e.ID, e.Err)
+}
+
+func (e *ParentError) Unwrap() error { return e.Err }
+
+// Resolve deep-merges the parent chain of cfg (see jsonx.MergeLayers for the
+// object, array and null semantics). Parents are linearized depth first so
+// that each config is merged once, after all of its own parents; later entries
+// in ParentIds take precedence over earlier ones and cfg's own data wins
+// over every parent. cfg itself is not reloaded, so unsaved changes to its
+// data or parents can be resolved before they are committed.
+func Resolve(ctx context.Context, cfg *ent.ConfigItem, load Loader) (*Resolved, error) {
+	r := &resolver{ctx: ctx, load: load, done: map[uuid.UUID]bool{}, onStack: map[uuid.UUID]bool{}}
+	if err := r.visit(cfg); err != nil {
+		return nil, err
+	}
+
+	docs := make([]map[string]any, len(r.order))
+	layers := make([]Layer, len(r.order))
+	for i, c := range r.order {
+		docs[i] = c.Data
+		layers[i] = Layer{ConfigID: c.ID, Name: c.Name, Revision: c.Revision}
+	}
+	data, idx := jsonx.MergeLayers(docs...)
+	sources := make(map[string]uuid.UUID, len(idx))
+	for p, i := range idx {
+		sources[p] = layers[i].ConfigID
+	}
+	return &Resolved{Data: data, Layers: layers, Sources: sources}, nil
+}
+
+type resolver struct {
+	ctx     context.Context
+	load    Loader
+	done    map[uuid.UUID]bool
+	onStack map[uuid.UUID]bool
+	stack   []uuid.UUID
+	order   []*ent.ConfigItem
+}
+
+func (r *resolver) visit(c *ent.ConfigItem) error {
+	r.onStack[c.ID] = true
+	r.stack = append(r.stack, c.ID)
+	for _, pid := range c.ParentIds {
+		if r.onStack[pid] {
+			path := append([]uuid.UUID{}, r.stack[indexOf(r.stack, pid):]...)
+			return &CycleError{Path: append(path, pid)}
+		}
+		if r.done[pid] {
+			continue
+		}
+		if len(r.done)+len(r.stack) >= MaxLayers {
+			return ErrTooManyLayers
+		}
+		p, err := r.load(r.ctx, pid)
+		if err != nil {
+			return &ParentError{ID: pid, Err: err}
+		}
+		if err := r.visit(p); err != nil {
+			return err
+		}
+	}
+	r.stack = r.stack[:len(r.stack)-1]
+	delete(r.onStack, c.ID)
+	r.done[c.ID] = true
+	r.order = append(r.order, c)
+	return nil
+}
+
+func indexOf(ids []uuid.UUID, id uuid.UUID) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package configx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+)
+
+type store map[uuid.UUID]*ent.ConfigItem
+
+func (s store) add(name string, data map[string]any, parents ...*ent.ConfigItem) *ent.ConfigItem {
+	c := &ent.ConfigItem{ID: uuid.New(), Name: name, Data: data, Revision: 1}
+	for _, p := range parents {
+		c.ParentIds = append(c.ParentIds, p.ID)
+	}
+	s[c.ID] = c
+	return c
+}
+
+func (s store) load(_ context.Context, id uuid.UUID) (*ent.ConfigItem, error) {
+	if c, ok := s[id]; ok {
+		return c, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestResolve_DiamondMergesEachLayerOnce(t *testing.T) {
+	s := store{}
+	base := s.add("base", map[string]any{"format": "png", "scale": 1.0, "prefix": "ic"})
+	web := s.add("web", map[string]any{"format": "svg"}, base)
+	retina := s.add("retina", map[string]any{"scale": 2.0}, base)
+	leaf := s.add("leaf", map[string]any{"prefix": nil}, web, retina)
+
+	res, err := Resolve(context.Background(), leaf, s.load)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(res.Layers) != 4 || res.Layers[0].ConfigID != base.ID || res.Layers[3].ConfigID != leaf.ID {
+		t.Fatalf("unexpected layers: %+v", res.Layers)
+	}
+	if res.Data["format"] != "svg" || res.Data["scale"] != 2.0 || len(res.Data) != 2 {
+		t.Fatalf("unexpected data: %v", res.Data)
+	}
+	if res.Sources["/format"] != web.ID || res.Sources["/scale"] != retina.ID {
+		t.Fatalf("unexpected sources: %v", res.Sources)
+	}
+}
+
+func TestResolve_DetectsCycles(t *testing.T) {
+	s := store{}
+	a := s.add("a", nil)
+	b := s.add("b", nil, a)
+	a.ParentIds = []uuid.UUID{b.ID}
+
+	_, err := Resolve(context.Background(), a, s.load)
+	var ce *CycleError
+	if !errors.As(err, &ce) || len(ce.Path) != 3 || ce.Path[0] != a.ID || ce.Path[2] != a.ID {
+		t.Fatalf("expected cycle a->b->a, got %v", err)
+	}
+
+	missing := &ent.ConfigItem{ID: uuid.New(), ParentIds: []uuid.UUID{uuid.New()}}
+	var pe *ParentError
+	if _, err := Resolve(context.Background(), missing, s.load); !errors.As(err, &pe) {
+		t.Fatalf("expected ParentError, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package configx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/predicate"
+	"fiber-ent-apollo-pg/ent/user"
+)
+
+// VisibleTo matches configs the user owns, that are shared to one of their
+// groups or that are shared directly with them.
+func VisibleTo(uid uuid.UUID) predicate.ConfigItem {
+	return configitem.Or(
+		configitem.HasOwnerWith(user.IDEQ(uid)),
+		configitem.HasSharedGroupsWith(group.HasMembersWith(user.IDEQ(uid))),
+		configitem.HasSharesWith(configshare.UserIDEQ(uid)),
+	)
+}
+
+// VisibleLoader loads parents through client, treating configs the user cannot see as missing.
+func VisibleLoader(client *ent.Client, uid uuid.UUID) Loader {
+	return func(ctx context.Context, id uuid.UUID) (*ent.ConfigItem, error) {
+		return client.ConfigItem.Query().Where(configitem.IDEQ(id), VisibleTo(uid)).Only(ctx)
+	}
+}
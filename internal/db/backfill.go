@@ -0,0 +1,303 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ariga.io/atlas/sql/migrate"
+	atlas "ariga.io/atlas/sql/schema"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+// activeConfigIndex is the partial unique index allowing one active config per project.
+const activeConfigIndex = "projectconfig_project_config_project"
+
+// dedupeActiveConfigs keeps the most recently updated active config of each
+// project active and deactivates the others.
+const dedupeActiveConfigs = `UPDATE project_configs SET active = false
+WHERE active AND id NOT IN (
+	SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY project_config_project ORDER BY updated_at DESC, id DESC) AS n
+		FROM project_configs WHERE active
+	) ranked WHERE n = 1
+)`
+
+// Migrate runs the automatic schema migration. Rows that would violate a
+// constraint added by the migration are fixed right before it is created.
+func Migrate(ctx context.Context, client *ent.Client) error {
+	return client.Schema.Create(ctx, schema.WithApplyHook(func(next schema.Applier) schema.Applier {
+		return schema.ApplyFunc(func(ctx context.Context, conn dialect.ExecQuerier, plan *migrate.Plan) error {
+			changes := make([]*migrate.Change, 0, len(plan.Changes)+1)
+			for _, c := range plan.Changes {
+				if add, ok := c.Source.(*atlas.AddIndex); ok && add.I.Name == activeConfigIndex {
+					changes = append(changes, &migrate.Change{
+						Cmd:     dedupeActiveConfigs,
+						Comment: "keep one active config per project",
+					})
+				}
+				changes = append(changes, c)
+			}
+			plan.Changes = changes
+			return next.Apply(ctx, conn, plan)
+		})
+	}))
+}
+
+// Backfill migrates existing rows to the current schema after the automatic
+// schema migration. It is idempotent and runs on every start.
+func Backfill(ctx context.Context, client *ent.Client) error {
+	if err := backfillConfigRevisions(ctx, client); err != nil {
+		return fmt.Errorf("backfill config revisions: %w", err)
+	}
+	if err := migrateDMGroups(ctx, client); err != nil {
+		return fmt.Errorf("migrate dm groups: %w", err)
+	}
+	if err := backfillGroupRoles(ctx, client); err != nil {
+		return fmt.Errorf("backfill group roles: %w", err)
+	}
+	if err := backfillProjectFileKeys(ctx, client); err != nil {
+		return fmt.Errorf("backfill project file keys: %w", err)
+	}
+	return nil
+}
+
+// backfillConfigRevisions records the data of configs saved before revision
+// history existed as their first revision, authored by the owner, so the
+// original data can still be restored after they are next updated.
+func backfillConfigRevisions(ctx context.Context, client *ent.Client) error {
+	legacy, err := client.ConfigItem.Query().
+		Where(configitem.RevisionEQ(0)).
+		WithOwner().
+		All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cfg := range legacy {
+		if cfg.Edges.Owner == nil {
+			continue
+		}
+		if err := backfillConfigRevision(ctx, client, cfg); err != nil {
+			return fmt.Errorf("config %s: %w", cfg.ID, err)
+		}
+	}
+	return nil
+}
+
+func backfillConfigRevision(ctx context.Context, client *ent.Client, cfg *ent.ConfigItem) error {
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// Guarded like a save, so a config updated meanwhile is left alone
+	n, err := tx.ConfigItem.Update().
+		Where(configitem.IDEQ(cfg.ID), configitem.RevisionEQ(0)).
+		SetRevision(1).
+		SetUpdatedAt(cfg.UpdatedAt).
+		Save(ctx)
+	if err != nil || n == 0 {
+		return err
+	}
+	err = tx.ConfigRevision.Create().
+		SetConfigID(cfg.ID).
+		SetAuthorID(cfg.Edges.Owner.ID).
+		SetRevision(1).
+		SetData(cfg.Data).
+		SetMessage("baseline").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrateDMGroups turns the "dm:<sharer>:<target>" groups that sharing with a
+// single user used to create into direct view shares, and drops the groups.
+// Groups that gained other members since are real groups now and are kept.
+func migrateDMGroups(ctx context.Context, client *ent.Client) error {
+	dms, err := client.Group.Query().
+		Where(group.NameHasPrefix("dm:")).
+		WithMembers().
+		WithConfigs(func(q *ent.ConfigItemQuery) { q.WithOwner() }).
+		All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, g := range dms {
+		if !isDMGroup(g) {
+			continue
+		}
+		if err := migrateDMGroup(ctx, client, g); err != nil {
+			return fmt.Errorf("group %s: %w", g.ID, err)
+		}
+	}
+	return nil
+}
+
+// isDMGroup reports whether g still has exactly the two members its name lists.
+func isDMGroup(g *ent.Group) bool {
+	parts := strings.Split(g.Name, ":")
+	if len(parts) != 3 || len(g.Edges.Members) != 2 {
+		return false
+	}
+	for _, m := range g.Edges.Members {
+		if m.ID.String() != parts[1] && m.ID.String() != parts[2] {
+			return false
+		}
+	}
+	return true
+}
+
+func migrateDMGroup(ctx context.Context, client *ent.Client, g *ent.Group) error {
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, cfg := range g.Edges.Configs {
+		for _, m := range g.Edges.Members {
+			if cfg.Edges.Owner != nil && cfg.Edges.Owner.ID == m.ID {
+				continue
+			}
+			exists, err := tx.ConfigShare.Query().
+				Where(configshare.ConfigIDEQ(cfg.ID), configshare.UserIDEQ(m.ID)).
+				Exist(ctx)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			if err := tx.ConfigShare.Create().SetConfigID(cfg.ID).SetUserID(m.ID).Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Group.DeleteOneID(g.ID).Exec(ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// backfillGroupRoles assigns roles in groups created before memberships had
+// them, where every member became a plain member. Every group gets exactly one
+// owner: the sharer of a "dm:<sharer>:<target>" group, or else the earliest
+// member (by join time, then user id, as rows of the former edge all carry the
+// time the column was added). The other members of groups that are not dm
+// groups could all manage them before roles existed, so they become admins
+// rather than losing that; the other members of dm groups stay plain members.
+func backfillGroupRoles(ctx context.Context, client *ent.Client) error {
+	legacy, err := client.Group.Query().
+		Where(
+			group.HasMemberships(),
+			group.Not(group.HasMembershipsWith(groupmembership.RoleIn(groupmembership.RoleOwner, groupmembership.RoleAdmin))),
+		).
+		All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, g := range legacy {
+		members, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(g.ID)).
+			Order(ent.Asc(groupmembership.FieldCreatedAt), ent.Asc(groupmembership.FieldUserID)).
+			All(ctx)
+		if err != nil {
+			return err
+		}
+		owner, others := members[0].UserID, groupmembership.RoleAdmin
+		if sharer, ok := dmSharer(g.Name); ok {
+			others = groupmembership.RoleMember
+			for _, m := range members {
+				if m.UserID == sharer {
+					owner = sharer
+				}
+			}
+		}
+		if err := backfillGroupOwner(ctx, client, g.ID, owner, others); err != nil {
+			return fmt.Errorf("group %s: %w", g.ID, err)
+		}
+	}
+	return nil
+}
+
+// backfillGroupOwner makes owner the owner of a group and gives its other
+// members role, in one transaction so a group is never left without an owner.
+func backfillGroupOwner(ctx context.Context, client *ent.Client, groupID, owner uuid.UUID, role groupmembership.Role) error {
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := tx.GroupMembership.Update().
+		Where(groupmembership.GroupIDEQ(groupID), groupmembership.UserIDNEQ(owner)).
+		SetRole(role).
+		Exec(ctx); err != nil {
+		return err
+	}
+	if err := tx.GroupMembership.Update().
+		Where(groupmembership.GroupIDEQ(groupID), groupmembership.UserIDEQ(owner)).
+		SetRole(groupmembership.RoleOwner).
+		Exec(ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// backfillProjectFileKeys parses the Figma file and branch keys of projects
+// created before they were stored. The url itself is left as entered. When an
+// owner has several projects for the same file, the oldest gets the keys and
+// the others keep none until their url is changed.
+func backfillProjectFileKeys(ctx context.Context, client *ent.Client) error {
+	legacy, err := client.Project.Query().
+		Where(project.Or(project.FileKeyIsNil(), project.FileKeyEQ(""))).
+		Order(ent.Asc(project.FieldCreatedAt), ent.Asc(project.FieldID)).
+		All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range legacy {
+		ref, err := figmax.ParseURL(p.URL)
+		if err != nil {
+			continue
+		}
+		upd := client.Project.UpdateOneID(p.ID).
+			Where(project.Or(project.FileKeyIsNil(), project.FileKeyEQ(""))).
+			SetFileKey(ref.FileKey).
+			SetBranchKey(ref.BranchKey).
+			AddVersion(1).
+			SetUpdatedAt(p.UpdatedAt)
+		if p.NodeID == "" {
+			upd = upd.SetNodeID(ref.NodeID)
+		}
+		err = upd.Exec(ctx)
+		if ent.IsNotFound(err) || ent.IsConstraintError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("project %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// dmSharer parses the sharer from the name of a group created by sharing a
+// config with a single user.
+func dmSharer(name string) (uuid.UUID, bool) {
+	parts := strings.Split(name, ":")
+	if len(parts) != 3 || parts[0] != "dm" {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(parts[1])
+	return id, err == nil
+}
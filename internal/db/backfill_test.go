@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configrevision"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/ent/projectconfig"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	// One database per test, so repeated runs do not see each other's rows
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	t.Cleanup(func() { _ = client.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+func TestBackfillGroupRoles(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	a := client.User.Create().SetDisplayName("A").SaveX(ctx)
+	b := client.User.Create().SetDisplayName("B").SaveX(ctx)
+	c := client.User.Create().SetDisplayName("C").SaveX(ctx)
+
+	// Rows as the former edge left them: everyone a plain member, all with
+	// the time the column was added. The dm group gained a third member, so
+	// it is kept as a group; the sharer of the other dm group left it.
+	added := time.Now().Add(-time.Hour)
+	dm := client.Group.Create().SetName("dm:" + b.ID.String() + ":" + a.ID.String()).SaveX(ctx)
+	left := client.Group.Create().SetName("dm:" + uuid.NewString() + ":" + a.ID.String()).SaveX(ctx)
+	team := client.Group.Create().SetName("Team").SaveX(ctx)
+	owned := client.Group.Create().SetName("Owned").SaveX(ctx)
+	for _, g := range []*ent.Group{dm, left, team, owned} {
+		client.GroupMembership.Create().SetGroupID(g.ID).SetUserID(a.ID).SetCreatedAt(added).SaveX(ctx)
+		client.GroupMembership.Create().SetGroupID(g.ID).SetUserID(b.ID).SetCreatedAt(added).SaveX(ctx)
+	}
+	client.GroupMembership.Create().SetGroupID(dm.ID).SetUserID(c.ID).SetCreatedAt(added).SaveX(ctx)
+	client.GroupMembership.Create().SetGroupID(team.ID).SetUserID(c.ID).SetCreatedAt(added.Add(-time.Minute)).SaveX(ctx)
+	first := a
+	if b.ID.String() < a.ID.String() {
+		first = b
+	}
+	second := map[*ent.User]*ent.User{a: b, b: a}[first]
+	client.GroupMembership.Update().
+		Where(groupmembership.GroupIDEQ(owned.ID), groupmembership.UserIDEQ(b.ID)).
+		SetRole(groupmembership.RoleOwner).
+		ExecX(ctx)
+
+	for i := 0; i < 2; i++ {
+		if err := Backfill(ctx, client); err != nil {
+			t.Fatalf("backfill: %v", err)
+		}
+	}
+	role := func(g *ent.Group, u *ent.User) groupmembership.Role {
+		return client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(g.ID), groupmembership.UserIDEQ(u.ID)).
+			OnlyX(ctx).Role
+	}
+	for _, tc := range []struct {
+		g    *ent.Group
+		u    *ent.User
+		want groupmembership.Role
+	}{
+		{dm, b, groupmembership.RoleOwner}, {dm, a, groupmembership.RoleMember}, {dm, c, groupmembership.RoleMember},
+		{left, first, groupmembership.RoleOwner}, {left, second, groupmembership.RoleMember},
+		{team, c, groupmembership.RoleOwner}, {team, a, groupmembership.RoleAdmin}, {team, b, groupmembership.RoleAdmin},
+		{owned, a, groupmembership.RoleMember}, {owned, b, groupmembership.RoleOwner},
+	} {
+		if got := role(tc.g, tc.u); got != tc.want {
+			t.Errorf("%s/%s: %s, want %s", tc.g.Name, tc.u.DisplayName, got, tc.want)
+		}
+	}
+}
+
+func TestMigrateDMGroups(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	a := client.User.Create().SetDisplayName("DM-A").SaveX(ctx)
+	b := client.User.Create().SetDisplayName("DM-B").SaveX(ctx)
+
+	dm := client.Group.Create().SetName("dm:" + a.ID.String() + ":" + b.ID.String()).SaveX(ctx)
+	client.GroupMembership.Create().SetGroupID(dm.ID).SetUserID(a.ID).SetRole(groupmembership.RoleOwner).SaveX(ctx)
+	client.GroupMembership.Create().SetGroupID(dm.ID).SetUserID(b.ID).SaveX(ctx)
+	// Both directions reused the same group
+	fromA := client.ConfigItem.Create().SetName("FromA").SetOwnerID(a.ID).SetData(map[string]any{}).AddSharedGroupIDs(dm.ID).SaveX(ctx)
+	fromB := client.ConfigItem.Create().SetName("FromB").SetOwnerID(b.ID).SetData(map[string]any{}).AddSharedGroupIDs(dm.ID).SaveX(ctx)
+	// An existing direct share is kept as is
+	client.ConfigShare.Create().SetConfigID(fromA.ID).SetUserID(b.ID).SetPermission(configshare.PermissionEdit).ExecX(ctx)
+
+	for i := 0; i < 2; i++ {
+		if err := Backfill(ctx, client); err != nil {
+			t.Fatalf("backfill: %v", err)
+		}
+	}
+	if client.Group.Query().Where(group.IDEQ(dm.ID)).ExistX(ctx) {
+		t.Fatal("dm group not removed")
+	}
+	for _, tc := range []struct {
+		cfg  *ent.ConfigItem
+		u    *ent.User
+		want configshare.Permission
+	}{
+		{fromA, b, configshare.PermissionEdit},
+		{fromB, a, configshare.PermissionView},
+	} {
+		share, err := client.ConfigShare.Query().
+			Where(configshare.ConfigIDEQ(tc.cfg.ID), configshare.UserIDEQ(tc.u.ID)).
+			Only(ctx)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tc.cfg.Name, tc.u.DisplayName, err)
+		}
+		if share.Permission != tc.want {
+			t.Errorf("%s/%s: %s, want %s", tc.cfg.Name, tc.u.DisplayName, share.Permission, tc.want)
+		}
+	}
+	if n := client.ConfigShare.Query().Where(configshare.UserIDIn(a.ID, b.ID)).CountX(ctx); n != 2 {
+		t.Fatalf("shares: %d", n)
+	}
+}
+
+func TestBackfillConfigRevisions(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner := client.User.Create().SetDisplayName("RevOwner").SaveX(ctx)
+	// Saved before revision history: head revision 0 and no revision rows
+	legacy := client.ConfigItem.Create().SetName("Legacy").SetOwnerID(owner.ID).SetData(map[string]any{"v": "original"}).SaveX(ctx)
+	current := client.ConfigItem.Create().SetName("Current").SetOwnerID(owner.ID).SetData(map[string]any{}).SetRevision(1).SaveX(ctx)
+	client.ConfigRevision.Create().SetConfigID(current.ID).SetAuthorID(owner.ID).SetRevision(1).SetData(map[string]any{}).ExecX(ctx)
+
+	for i := 0; i < 2; i++ {
+		if err := Backfill(ctx, client); err != nil {
+			t.Fatalf("backfill: %v", err)
+		}
+	}
+	got := client.ConfigItem.GetX(ctx, legacy.ID)
+	if got.Revision != 1 || !got.UpdatedAt.Equal(legacy.UpdatedAt) {
+		t.Fatalf("legacy config: revision=%d updated_at=%v, want 1 and %v", got.Revision, got.UpdatedAt, legacy.UpdatedAt)
+	}
+	revs := client.ConfigRevision.Query().Where(configrevision.ConfigIDEQ(legacy.ID)).AllX(ctx)
+	if len(revs) != 1 || revs[0].Revision != 1 || revs[0].AuthorID != owner.ID || revs[0].Data["v"] != "original" {
+		t.Fatalf("legacy revisions: %+v", revs)
+	}
+	if n := client.ConfigRevision.Query().Where(configrevision.ConfigIDEQ(current.ID)).CountX(ctx); n != 1 {
+		t.Fatalf("current revisions: %d", n)
+	}
+}
+
+func TestMigrate_DedupesActiveConfigs(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// A database from before the index, where racing requests left several
+	// active configs on one project
+	if _, err := db.Exec("DROP INDEX " + activeConfigIndex); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	owner := client.User.Create().SetDisplayName("Owner").SaveX(ctx)
+	proj := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/dedupe").SetOwnerID(owner.ID).SaveX(ctx)
+	now := time.Now()
+	var pcs []*ent.ProjectConfig
+	for i, age := range []time.Duration{2 * time.Hour, time.Minute, time.Hour} {
+		cfg := client.ConfigItem.Create().SetName("c").SetOwnerID(owner.ID).SetData(map[string]any{"i": i}).SaveX(ctx)
+		pcs = append(pcs, client.ProjectConfig.Create().SetProjectID(proj.ID).SetConfigItemID(cfg.ID).
+			SetActive(true).SetUpdatedAt(now.Add(-age)).SaveX(ctx))
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := Migrate(ctx, client); err != nil {
+			t.Fatalf("migrate %d: %v", i, err)
+		}
+	}
+	active := client.ProjectConfig.Query().
+		Where(projectconfig.HasProjectWith(project.IDEQ(proj.ID)), projectconfig.ActiveEQ(true)).
+		IDsX(ctx)
+	if len(active) != 1 || active[0] != pcs[1].ID {
+		t.Fatalf("active configs: %v, want only %s", active, pcs[1].ID)
+	}
+	// The index is in place again
+	if err := client.ProjectConfig.UpdateOneID(pcs[0].ID).SetActive(true).Exec(ctx); !ent.IsConstraintError(err) {
+		t.Fatalf("second active config: %v", err)
+	}
+}
+
+func TestBackfillProjectFileKeys(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner := client.User.Create().SetDisplayName("Owner").SaveX(ctx)
+	// Projects from before the keys were stored: only the url was saved
+	legacy := func(name, url string, age time.Duration) *ent.Project {
+		return client.Project.Create().SetName(name).SetURL(url).SetOwnerID(owner.ID).
+			SetCreatedAt(time.Now().Add(-age)).SaveX(ctx)
+	}
+	file := legacy("file", "https://www.figma.com/file/LegacyKey1/Design?node-id=1-2", 3*time.Hour)
+	branch := legacy("branch", "https://www.figma.com/design/LegacyKey1/branch/LegacyBr1/Design", 2*time.Hour)
+	dup := legacy("dup", "https://figma.com/design/LegacyKey1", time.Hour)
+	other := legacy("other", "https://example.com/not-figma", time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := Backfill(ctx, client); err != nil {
+			t.Fatalf("backfill: %v", err)
+		}
+	}
+	got := client.Project.GetX(ctx, file.ID)
+	if got.FileKey != "LegacyKey1" || got.BranchKey != "" || got.NodeID != "1:2" || got.URL != file.URL || got.Version != file.Version+1 {
+		t.Fatalf("file project: %+v", got)
+	}
+	got = client.Project.GetX(ctx, branch.ID)
+	if got.FileKey != "LegacyKey1" || got.BranchKey != "LegacyBr1" {
+		t.Fatalf("branch project: %+v", got)
+	}
+	// The newer duplicate of the file and the non-Figma project keep no keys
+	for _, p := range []*ent.Project{dup, other} {
+		if got := client.Project.GetX(ctx, p.ID); got.FileKey != "" || got.Version != p.Version {
+			t.Fatalf("project %s: %+v", p.Name, got)
+		}
+	}
+}
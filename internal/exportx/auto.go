@@ -0,0 +1,156 @@
+package exportx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/configx"
+)
+
+// maxDebounce bounds how many windows a steady stream of notifications can
+// postpone an export.
+const maxDebounce = 5
+
+// AutoExporter queues exports of projects that opted in to automatic exports.
+// Notifications of a project are debounced: the export is queued once no
+// further notification arrived for window, or maxDebounce windows after the
+// first one, whichever comes first. Pending exports are lost on restart.
+type AutoExporter struct {
+	svc    *Service
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*pendingExport
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type pendingExport struct {
+	timer *time.Timer
+	first time.Time
+}
+
+// NewAutoExporter creates an exporter queueing jobs through svc.
+func NewAutoExporter(svc *Service, window time.Duration) *AutoExporter {
+	return &AutoExporter{svc: svc, window: window, pending: map[uuid.UUID]*pendingExport{}}
+}
+
+// Notify reports that the Figma file of a project changed.
+func (a *AutoExporter) Notify(projID uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	now := time.Now()
+	if p, ok := a.pending[projID]; ok && p.timer.Stop() {
+		delay := a.window
+		if deadline := p.first.Add(maxDebounce * a.window); now.Add(delay).After(deadline) {
+			delay = deadline.Sub(now)
+		}
+		p.timer.Reset(delay)
+		return
+	}
+	p := &pendingExport{first: now}
+	a.wg.Add(1)
+	p.timer = time.AfterFunc(a.window, func() {
+		defer a.wg.Done()
+		a.mu.Lock()
+		if a.pending[projID] == p {
+			delete(a.pending, projID)
+		}
+		a.mu.Unlock()
+		a.fire(projID)
+	})
+	a.pending[projID] = p
+}
+
+// Close drops pending exports and waits for exports being queued.
+func (a *AutoExporter) Close() {
+	a.mu.Lock()
+	a.closed = true
+	for id, p := range a.pending {
+		if p.timer.Stop() {
+			a.wg.Done()
+		}
+		delete(a.pending, id)
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+}
+
+func (a *AutoExporter) fire(projID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	job, err := a.export(ctx, projID)
+	switch {
+	case err != nil:
+		exportLogger.Warn("auto export failed", zap.String("project", projID.String()), zap.Error(err))
+	case job != nil:
+		exportLogger.Info("auto export queued", zap.String("project", projID.String()), zap.String("job", job.ID.String()))
+	}
+}
+
+// export queues a job for the project with its active config, on behalf of
+// its owner. Projects that opted out, have no active config or still have a
+// queued job, which will read the latest file anyway, are skipped with a nil job.
+func (a *AutoExporter) export(ctx context.Context, projID uuid.UUID) (*ent.ExportJob, error) {
+	client := a.svc.client
+	proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+	if ent.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load project: %w", err)
+	}
+	if !proj.AutoExport || proj.Edges.Owner == nil {
+		return nil, nil
+	}
+	queued, err := client.ExportJob.Query().
+		Where(exportjob.ProjectIDEQ(projID), exportjob.StatusEQ(exportjob.StatusQueued)).
+		Exist(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query export jobs: %w", err)
+	}
+	if queued {
+		return nil, nil
+	}
+	ownerID := proj.Edges.Owner.ID
+	pc, res, err := configx.ResolveActive(ctx, client, projID, ownerID)
+	if errors.Is(err, configx.ErrNoActiveConfig) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve active config: %w", err)
+	}
+
+	job, err := client.ExportJob.Create().
+		SetProjectID(projID).
+		SetRequestedByID(ownerID).
+		SetConfigID(pc.Edges.ConfigItem.ID).
+		SetConfigRevision(pc.Edges.ConfigItem.Revision).
+		SetConfig(res.Data).
+		SetTrigger(exportjob.TriggerWebhook).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create export job: %w", err)
+	}
+	if err := a.svc.Enqueue(ctx, job.ID); err != nil {
+		_, _ = client.ExportJob.UpdateOneID(job.ID).
+			SetStatus(exportjob.StatusFailed).
+			SetError("enqueue failed: " + err.Error()).
+			SetFinishedAt(time.Now()).
+			Save(ctx)
+		return nil, fmt.Errorf("enqueue export job: %w", err)
+	}
+	return job, nil
+}
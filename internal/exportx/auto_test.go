@@ -0,0 +1,113 @@
+package exportx
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+)
+
+// recorder is a publisher remembering published bodies.
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *recorder) Publish(_ context.Context, _ string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	return nil
+}
+
+func (r *recorder) Close() error { return nil }
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+// newAutoProject creates a project with an active config.
+func newAutoProject(t *testing.T, client *ent.Client, auto bool) *ent.Project {
+	t.Helper()
+	ctx := context.Background()
+	u := client.User.Create().SetDisplayName("Owner").SaveX(ctx)
+	p := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/" + uuid.NewString()[:8]).
+		SetOwnerID(u.ID).SetAutoExport(auto).SaveX(ctx)
+	cfg := client.ConfigItem.Create().SetName("c").SetData(map[string]any{"format": "svg"}).SetOwnerID(u.ID).SaveX(ctx)
+	client.ProjectConfig.Create().SetProjectID(p.ID).SetConfigItemID(cfg.ID).SetActive(true).SaveX(ctx)
+	return p
+}
+
+func TestAutoExporter_Debounces(t *testing.T) {
+	client := newTestClient(t)
+	pub := &recorder{}
+	a := NewAutoExporter(NewService(client, pub, nil), 50*time.Millisecond)
+	p := newAutoProject(t, client, true)
+
+	for i := 0; i < 5; i++ {
+		a.Notify(p.ID)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pub.count() != 0 {
+		t.Fatal("export queued before the window passed")
+	}
+	time.Sleep(150 * time.Millisecond)
+	a.Close()
+
+	jobs := client.ExportJob.Query().Where(exportjob.ProjectIDEQ(p.ID)).AllX(context.Background())
+	if len(jobs) != 1 || pub.count() != 1 {
+		t.Fatalf("jobs %d, published %d", len(jobs), pub.count())
+	}
+	if jobs[0].Trigger != exportjob.TriggerWebhook || jobs[0].Status != exportjob.StatusQueued || jobs[0].Config["format"] != "svg" {
+		t.Fatalf("unexpected job: %+v", jobs[0])
+	}
+}
+
+func TestAutoExporter_Skips(t *testing.T) {
+	client := newTestClient(t)
+	a := NewAutoExporter(NewService(client, &recorder{}, nil), time.Hour)
+	defer a.Close()
+	ctx := context.Background()
+
+	// Opted out
+	off := newAutoProject(t, client, false)
+	if job, err := a.export(ctx, off.ID); err != nil || job != nil {
+		t.Fatalf("opted out project: %v %v", job, err)
+	}
+
+	// A queued job covers the change
+	on := newAutoProject(t, client, true)
+	job, err := a.export(ctx, on.ID)
+	if err != nil || job == nil {
+		t.Fatalf("export: %v %v", job, err)
+	}
+	if job, err := a.export(ctx, on.ID); err != nil || job != nil {
+		t.Fatalf("project with queued job: %v %v", job, err)
+	}
+
+	// Unknown projects are ignored
+	if job, err := a.export(ctx, uuid.New()); err != nil || job != nil {
+		t.Fatalf("unknown project: %v %v", job, err)
+	}
+}
+
+func TestAutoExporter_CloseDropsPending(t *testing.T) {
+	client := newTestClient(t)
+	pub := &recorder{}
+	a := NewAutoExporter(NewService(client, pub, nil), 20*time.Millisecond)
+	p := newAutoProject(t, client, true)
+	a.Notify(p.ID)
+	a.Close()
+	a.Notify(p.ID)
+	time.Sleep(60 * time.Millisecond)
+	if pub.count() != 0 {
+		t.Fatalf("published %d after close", pub.count())
+	}
+}
@@ -0,0 +1,59 @@
+package exportx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/blobx"
+)
+
+// ErrNoStore is returned when a step writes output but no blob store is configured.
+var ErrNoStore = errors.New("exportx: no blob store configured")
+
+// Output records the files a job produces.
+type Output struct {
+	client *ent.Client
+	store  blobx.Store
+	jobID  uuid.UUID
+}
+
+// Put stores the content of r as the artifact name of the job. Names are
+// slash separated relative paths and must be unique within a job.
+func (o *Output) Put(ctx context.Context, name, contentType string, r io.Reader) (*ent.Artifact, error) {
+	if o.store == nil {
+		return nil, ErrNoStore
+	}
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	info, err := o.store.Put(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("store %s: %w", name, err)
+	}
+	create := o.client.Artifact.Create().
+		SetJobID(o.jobID).
+		SetName(name).
+		SetDigest(info.Digest).
+		SetSize(info.Size)
+	if contentType != "" {
+		create = create.SetContentType(contentType)
+	}
+	return create.Save(ctx)
+}
+
+// checkName rejects artifact names that are not clean relative paths, so
+// names are safe to use as zip entries.
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "../") ||
+		strings.Contains(name, "\\") || path.Clean(name) != name {
+		return fmt.Errorf("exportx: invalid artifact name %q", name)
+	}
+	return nil
+}
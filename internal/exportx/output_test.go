@@ -0,0 +1,68 @@
+package exportx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/internal/blobx"
+)
+
+func TestOutput_PutAndSweep(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	store, err := blobx.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	svc := NewService(client, nil, store, Step{Name: "write", Run: func(ctx context.Context, job *ent.ExportJob, out *Output) error {
+		if _, err := out.Put(ctx, "icons/a.svg", "image/svg+xml", strings.NewReader("<svg/>")); err != nil {
+			return err
+		}
+		_, err := out.Put(ctx, "icons/copy.svg", "image/svg+xml", strings.NewReader("<svg/>"))
+		return err
+	}})
+
+	old, recent := newJob(t, client), newJob(t, client)
+	for _, j := range []*ent.ExportJob{old, recent} {
+		if err := svc.Run(ctx, j.ID); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	arts := client.Artifact.Query().Where(artifact.JobIDEQ(old.ID)).AllX(ctx)
+	if len(arts) != 2 || arts[0].Digest != arts[1].Digest || arts[0].ContentType != "image/svg+xml" {
+		t.Fatalf("unexpected artifacts: %+v", arts)
+	}
+
+	// Expire the first job; its blob is still used by the second one
+	client.ExportJob.UpdateOneID(old.ID).SetFinishedAt(time.Now().Add(-48 * time.Hour)).ExecX(ctx)
+	expired, stats, err := Sweep(ctx, client, store, 24*time.Hour, 0)
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if expired != 2 || stats.Deleted != 0 {
+		t.Fatalf("expired=%d stats=%+v", expired, stats)
+	}
+
+	// Once nothing references the blob it is collected
+	client.Artifact.Delete().Where(artifact.JobIDEQ(recent.ID)).ExecX(ctx)
+	if _, stats, err = Sweep(ctx, client, store, 24*time.Hour, 0); err != nil || stats.Deleted != 1 {
+		t.Fatalf("second sweep: %+v, %v", stats, err)
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	for _, ok := range []string{"a.svg", "icons/a.svg", "x/y/z.json"} {
+		if err := checkName(ok); err != nil {
+			t.Fatalf("checkName(%q): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", ".", "..", "../a", "/etc/passwd", "a/../b", "a//b", `a\b`, "a/"} {
+		if err := checkName(bad); err == nil {
+			t.Fatalf("checkName(%q) accepted", bad)
+		}
+	}
+}
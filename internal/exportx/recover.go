@@ -0,0 +1,122 @@
+package exportx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+)
+
+// StaleAfter is how long after starting a running job is considered abandoned
+// by its worker. Run gives up after RunTimeout, so no live worker holds a job
+// that long.
+const StaleAfter = RunTimeout + time.Minute
+
+// Recover enqueues the jobs a restart may have lost: every queued job, and
+// running jobs abandoned by their worker, which are reset to queued first.
+// Publishing a job that is still queued elsewhere is harmless since Run only
+// claims queued jobs. It returns the number of jobs enqueued.
+func (s *Service) Recover(ctx context.Context) (int, error) {
+	if _, err := s.resetStale(ctx); err != nil {
+		return 0, err
+	}
+	ids, err := s.client.ExportJob.Query().
+		Where(exportjob.StatusEQ(exportjob.StatusQueued)).
+		Order(ent.Asc(exportjob.FieldCreatedAt)).
+		IDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("query queued export jobs: %w", err)
+	}
+	return s.enqueueAll(ctx, ids)
+}
+
+// RequeueStale resets running jobs abandoned by their worker to queued and
+// enqueues them again. It returns the number of jobs enqueued.
+func (s *Service) RequeueStale(ctx context.Context) (int, error) {
+	ids, err := s.resetStale(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.enqueueAll(ctx, ids)
+}
+
+// RunRecoverer calls RequeueStale every interval until ctx is done.
+func (s *Service) RunRecoverer(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		n, err := s.RequeueStale(ctx)
+		if err != nil {
+			exportLogger.Warn("export job recovery failed", zap.Error(err))
+			continue
+		}
+		if n > 0 {
+			exportLogger.Info("requeued stale export jobs", zap.Int("jobs", n))
+		}
+	}
+}
+
+// resetStale puts running jobs that started more than StaleAfter ago back in
+// the queue and drops the artifacts their interrupted run produced.
+func (s *Service) resetStale(ctx context.Context) ([]uuid.UUID, error) {
+	cutoff := time.Now().Add(-StaleAfter)
+	stale, err := s.client.ExportJob.Query().
+		Where(exportjob.StatusEQ(exportjob.StatusRunning), exportjob.StartedAtLT(cutoff)).
+		IDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query stale export jobs: %w", err)
+	}
+	var reset []uuid.UUID
+	for _, id := range stale {
+		ok, err := s.resetJob(ctx, id, cutoff)
+		if err != nil {
+			return reset, fmt.Errorf("reset export job %s: %w", id, err)
+		}
+		if ok {
+			reset = append(reset, id)
+		}
+	}
+	return reset, nil
+}
+
+func (s *Service) resetJob(ctx context.Context, id uuid.UUID, cutoff time.Time) (bool, error) {
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// Guarded like a claim, so a job that finished or was canceled meanwhile is left alone
+	n, err := tx.ExportJob.Update().
+		Where(exportjob.IDEQ(id), exportjob.StatusEQ(exportjob.StatusRunning), exportjob.StartedAtLT(cutoff)).
+		SetStatus(exportjob.StatusQueued).
+		SetProgress(0).
+		ClearStartedAt().
+		Save(ctx)
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if _, err := tx.Artifact.Delete().Where(artifact.HasJobWith(exportjob.IDEQ(id))).Exec(ctx); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *Service) enqueueAll(ctx context.Context, ids []uuid.UUID) (int, error) {
+	for i, id := range ids {
+		if err := s.Enqueue(ctx, id); err != nil {
+			return i, fmt.Errorf("enqueue export job %s: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,77 @@
+package exportx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+)
+
+func TestService_RecoversLostJobs(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	pub := &recorder{}
+	svc := NewService(client, pub, nil)
+
+	// What a restart leaves behind: a job whose message was lost, a job its
+	// worker died on halfway, a job still within its run time, and a finished one
+	queued := newJob(t, client)
+	stale := newJob(t, client)
+	client.ExportJob.UpdateOne(stale).SetStatus(exportjob.StatusRunning).SetProgress(50).
+		SetStartedAt(time.Now().Add(-StaleAfter - time.Minute)).ExecX(ctx)
+	client.Artifact.Create().SetJobID(stale.ID).SetName("partial.svg").SetDigest(strings.Repeat("a", 64)).SetSize(1).ExecX(ctx)
+	fresh := newJob(t, client)
+	client.ExportJob.UpdateOne(fresh).SetStatus(exportjob.StatusRunning).SetStartedAt(time.Now().Add(-time.Minute)).ExecX(ctx)
+	done := newJob(t, client)
+	client.ExportJob.UpdateOne(done).SetStatus(exportjob.StatusSucceeded).
+		SetStartedAt(time.Now().Add(-StaleAfter - time.Hour)).SetFinishedAt(time.Now()).ExecX(ctx)
+
+	n, err := svc.Recover(ctx)
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	published := strings.Join(pub.bodies, " ")
+	for _, j := range []*ent.ExportJob{queued, stale} {
+		if !strings.Contains(published, j.ID.String()) {
+			t.Fatalf("job %s not enqueued: %s", j.ID, published)
+		}
+	}
+	for _, j := range []*ent.ExportJob{fresh, done} {
+		if strings.Contains(published, j.ID.String()) {
+			t.Fatalf("job %s enqueued: %s", j.ID, published)
+		}
+	}
+	if n != pub.count() {
+		t.Fatalf("recovered %d, published %d", n, pub.count())
+	}
+
+	got := client.ExportJob.GetX(ctx, stale.ID)
+	if got.Status != exportjob.StatusQueued || got.Progress != 0 || got.StartedAt != nil {
+		t.Fatalf("stale job not reset: %+v", got)
+	}
+	if client.Artifact.Query().Where(artifact.JobIDEQ(stale.ID)).ExistX(ctx) {
+		t.Fatal("partial artifacts kept")
+	}
+	if got := client.ExportJob.GetX(ctx, fresh.ID); got.Status != exportjob.StatusRunning {
+		t.Fatalf("fresh job touched: %s", got.Status)
+	}
+
+	// The periodic pass only picks up newly abandoned jobs
+	pub.bodies = nil
+	client.ExportJob.UpdateOne(fresh).SetStartedAt(time.Now().Add(-StaleAfter - time.Second)).ExecX(ctx)
+	if n, err := svc.RequeueStale(ctx); err != nil || n != 1 || !strings.Contains(pub.bodies[0], fresh.ID.String()) {
+		t.Fatalf("requeue stale: n=%d err=%v bodies=%v", n, err, pub.bodies)
+	}
+
+	// Recovered jobs run like any queued job
+	if err := svc.Run(ctx, stale.ID); err != nil {
+		t.Fatalf("run recovered job: %v", err)
+	}
+	if got := client.ExportJob.GetX(ctx, stale.ID); got.Status != exportjob.StatusSucceeded {
+		t.Fatalf("recovered job status: %s", got.Status)
+	}
+}
@@ -0,0 +1,63 @@
+package exportx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/blobx"
+)
+
+// Sweep applies the artifact retention policy: artifacts of jobs that finished
+// more than retention ago are deleted, then blobs older than grace that no
+// artifact references are garbage collected. A zero retention keeps artifacts
+// forever and only collects orphaned blobs.
+func Sweep(ctx context.Context, client *ent.Client, store blobx.Store, retention, grace time.Duration) (int, blobx.GCStats, error) {
+	expired := 0
+	if retention > 0 {
+		n, err := client.Artifact.Delete().
+			Where(artifact.HasJobWith(exportjob.FinishedAtLT(time.Now().Add(-retention)))).
+			Exec(ctx)
+		if err != nil {
+			return 0, blobx.GCStats{}, fmt.Errorf("delete expired artifacts: %w", err)
+		}
+		expired = n
+	}
+	referenced := func(ctx context.Context, digest string) (bool, error) {
+		return client.Artifact.Query().Where(artifact.DigestEQ(digest)).Exist(ctx)
+	}
+	stats, err := blobx.GC(ctx, store, referenced, grace)
+	if err != nil {
+		return expired, stats, fmt.Errorf("collect blobs: %w", err)
+	}
+	return expired, stats, nil
+}
+
+// RunSweeper calls Sweep every interval until ctx is done.
+func RunSweeper(ctx context.Context, client *ent.Client, store blobx.Store, interval, retention, grace time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		expired, stats, err := Sweep(ctx, client, store, retention, grace)
+		if err != nil {
+			exportLogger.Warn("artifact sweep failed", zap.Error(err))
+			continue
+		}
+		if expired > 0 || stats.Deleted > 0 {
+			exportLogger.Info("artifact sweep",
+				zap.Int("expired_artifacts", expired),
+				zap.Int("deleted_blobs", stats.Deleted),
+				zap.Int64("freed_bytes", stats.Freed))
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package exportx runs export jobs. Jobs are created by HTTP handlers, queued
+// through an mqx.Publisher and executed by whichever process consumes the
+// queue, as an ordered list of steps.
+package exportx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/logx"
+	"fiber-ent-apollo-pg/internal/mqx"
+)
+
+var exportLogger = logx.GetScope("exportx")
+
+// RoutingKey is the message routing key of queued export jobs.
+const RoutingKey = "export.requested"
+
+// RunTimeout bounds the execution of a single job.
+const RunTimeout = 10 * time.Minute
+
+// ErrNotCancelable is returned when canceling a job that already finished.
+var ErrNotCancelable = errors.New("export job already finished")
+
+// Step is one stage of an export. Steps run in order and a failing step fails the job.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, job *ent.ExportJob, out *Output) error
+}
+
+// Message is the queued payload of an export job.
+type Message struct {
+	JobID uuid.UUID `json:"job_id"`
+}
+
+// Service enqueues and executes export jobs.
+type Service struct {
+	client *ent.Client
+	pub    mqx.Publisher
+	store  blobx.Store
+	steps  []Step
+}
+
+// NewService creates a service publishing jobs to pub and running steps for
+// each. Step output is written to store.
+func NewService(client *ent.Client, pub mqx.Publisher, store blobx.Store, steps ...Step) *Service {
+	return &Service{client: client, pub: pub, store: store, steps: steps}
+}
+
+// Store returns the blob store holding job artifacts.
+func (s *Service) Store() blobx.Store { return s.store }
+
+// Enqueue publishes a queued job for execution.
+func (s *Service) Enqueue(ctx context.Context, jobID uuid.UUID) error {
+	body, _ := json.Marshal(Message{JobID: jobID})
+	return s.pub.Publish(ctx, RoutingKey, body)
+}
+
+// Handle is the mqx.Handler consuming queued jobs.
+func (s *Service) Handle(ctx context.Context, body []byte) error {
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("decode export message: %w", err)
+	}
+	return s.Run(ctx, msg.JobID)
+}
+
+// Run executes a queued job. Jobs that are no longer queued, because they were
+// canceled or another consumer claimed them, are skipped, so redelivered
+// messages are harmless. Cancellation is checked between steps.
+func (s *Service) Run(ctx context.Context, jobID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, RunTimeout)
+	defer cancel()
+
+	// Claim the job
+	n, err := s.client.ExportJob.Update().
+		Where(exportjob.IDEQ(jobID), exportjob.StatusEQ(exportjob.StatusQueued)).
+		SetStatus(exportjob.StatusRunning).
+		SetStartedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("claim export job: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	job, err := s.client.ExportJob.Get(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("load export job: %w", err)
+	}
+	out := &Output{client: s.client, store: s.store, jobID: jobID}
+
+	for i, step := range s.steps {
+		if canceled, err := s.canceled(ctx, jobID); err != nil || canceled {
+			return err
+		}
+		if err := step.Run(ctx, job, out); err != nil {
+			exportLogger.Warn("export step failed", zap.String("job", jobID.String()), zap.String("step", step.Name), zap.Error(err))
+			return s.finish(ctx, jobID, exportjob.StatusFailed, fmt.Sprintf("%s: %v", step.Name, err))
+		}
+		if _, err := s.client.ExportJob.Update().
+			Where(exportjob.IDEQ(jobID), exportjob.StatusEQ(exportjob.StatusRunning)).
+			SetProgress((i + 1) * 100 / len(s.steps)).
+			Save(ctx); err != nil {
+			return fmt.Errorf("update export progress: %w", err)
+		}
+	}
+	return s.finish(ctx, jobID, exportjob.StatusSucceeded, "")
+}
+
+// Cancel stops a queued or running job. A running job stops before its next step.
+func Cancel(ctx context.Context, client *ent.Client, jobID uuid.UUID) (*ent.ExportJob, error) {
+	n, err := client.ExportJob.Update().
+		Where(exportjob.IDEQ(jobID), exportjob.StatusIn(exportjob.StatusQueued, exportjob.StatusRunning)).
+		SetStatus(exportjob.StatusCanceled).
+		SetFinishedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job, err := client.ExportJob.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return job, ErrNotCancelable
+	}
+	return job, nil
+}
+
+func (s *Service) canceled(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	job, err := s.client.ExportJob.Get(ctx, jobID)
+	if err != nil {
+		return false, fmt.Errorf("load export job: %w", err)
+	}
+	return job.Status == exportjob.StatusCanceled, nil
+}
+
+// finish records the outcome of a running job; a cancel that won the race is kept.
+// It runs even when ctx expired, which is how timed out jobs get marked failed.
+func (s *Service) finish(ctx context.Context, jobID uuid.UUID, status exportjob.Status, msg string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	upd := s.client.ExportJob.Update().
+		Where(exportjob.IDEQ(jobID), exportjob.StatusEQ(exportjob.StatusRunning)).
+		SetStatus(status).
+		SetFinishedAt(time.Now())
+	if status == exportjob.StatusSucceeded {
+		upd = upd.SetProgress(100)
+	}
+	if msg != "" {
+		upd = upd.SetError(truncate(msg, 2000))
+	}
+	if _, err := upd.Save(ctx); err != nil {
+		return fmt.Errorf("finish export job: %w", err)
+	}
+	return nil
+}
+
+// truncate cuts s to at most n bytes without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,143 @@
+package exportx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/mqx"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:exportx?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+func newJob(t *testing.T, client *ent.Client) *ent.ExportJob {
+	t.Helper()
+	ctx := context.Background()
+	u := client.User.Create().SetDisplayName("Exporter").SaveX(ctx)
+	p := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/" + uuid.NewString()[:8]).SetOwnerID(u.ID).SaveX(ctx)
+	return client.ExportJob.Create().
+		SetProjectID(p.ID).
+		SetRequestedByID(u.ID).
+		SetConfigID(uuid.New()).
+		SetConfigRevision(1).
+		SetConfig(map[string]any{"format": "svg"}).
+		SaveX(ctx)
+}
+
+func TestService_RunsQueuedJobThroughBus(t *testing.T) {
+	client := newTestClient(t)
+	bus := mqx.NewLocalBus(1)
+	var seen []string
+	svc := NewService(client, bus, nil,
+		Step{Name: "one", Run: func(_ context.Context, job *ent.ExportJob, _ *Output) error {
+			seen = append(seen, job.Config["format"].(string))
+			return nil
+		}},
+		Step{Name: "two", Run: func(context.Context, *ent.ExportJob, *Output) error { return nil }},
+	)
+	if err := bus.Subscribe(RoutingKey, svc.Handle); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	job := newJob(t, client)
+
+	if err := svc.Enqueue(context.Background(), job.ID); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	_ = bus.Close()
+
+	got := client.ExportJob.GetX(context.Background(), job.ID)
+	if got.Status != exportjob.StatusSucceeded || got.Progress != 100 || got.StartedAt == nil || got.FinishedAt == nil {
+		t.Fatalf("unexpected job: %+v", got)
+	}
+	if len(seen) != 1 || seen[0] != "svg" {
+		t.Fatalf("step saw %v", seen)
+	}
+
+	// Redelivery of a finished job is a no-op
+	if err := svc.Run(context.Background(), job.ID); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("finished job ran again")
+	}
+}
+
+func TestService_FailingStepFailsJob(t *testing.T) {
+	client := newTestClient(t)
+	svc := NewService(client, nil, nil,
+		Step{Name: "ok", Run: func(context.Context, *ent.ExportJob, *Output) error { return nil }},
+		Step{Name: "render", Run: func(context.Context, *ent.ExportJob, *Output) error { return errors.New("boom") }},
+	)
+	job := newJob(t, client)
+
+	if err := svc.Run(context.Background(), job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	got := client.ExportJob.GetX(context.Background(), job.ID)
+	if got.Status != exportjob.StatusFailed || got.Error != "render: boom" || got.Progress != 50 {
+		t.Fatalf("unexpected job: %+v", got)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	ran := 0
+	svc := NewService(client, nil, nil,
+		Step{Name: "first", Run: func(ctx context.Context, job *ent.ExportJob, _ *Output) error {
+			ran++
+			_, err := Cancel(ctx, client, job.ID)
+			return err
+		}},
+		Step{Name: "second", Run: func(context.Context, *ent.ExportJob, *Output) error {
+			ran++
+			return nil
+		}},
+	)
+
+	// Canceled while running: stops before the next step and stays canceled
+	job := newJob(t, client)
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := client.ExportJob.GetX(ctx, job.ID); got.Status != exportjob.StatusCanceled || ran != 1 {
+		t.Fatalf("status %s after %d steps", got.Status, ran)
+	}
+
+	// Canceled while queued: never runs
+	queued := newJob(t, client)
+	if _, err := Cancel(ctx, client, queued.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := svc.Run(ctx, queued.ID); err != nil || ran != 1 {
+		t.Fatalf("canceled job ran: err=%v steps=%d", err, ran)
+	}
+
+	// Finished jobs cannot be canceled
+	if _, err := Cancel(ctx, client, queued.ID); !errors.Is(err, ErrNotCancelable) {
+		t.Fatalf("second cancel: err = %v", err)
+	}
+}
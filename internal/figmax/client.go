@@ -0,0 +1,282 @@
+package figmax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fiber-ent-apollo-pg/internal/config"
+)
+
+// DefaultBaseURL is the public Figma REST API.
+const DefaultBaseURL = "https://api.figma.com"
+
+// Errors matched by errors.Is on an *Error returned from the client.
+var (
+	ErrUnauthorized = errors.New("figma: token rejected")
+	ErrNotFound     = errors.New("figma: not found")
+	ErrRateLimited  = errors.New("figma: rate limited")
+)
+
+// Error is a non-2xx response from the Figma API.
+type Error struct {
+	Status  int
+	Message string
+	// RetryAfter is set from the Retry-After header of 429 responses.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("figma: %d %s", e.Status, e.Message)
+}
+
+// Is maps the response status onto the package sentinels.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Client calls the Figma REST API with a personal access token.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient creates a client for baseURL, which defaults to DefaultBaseURL and
+// can point at a local stub in tests.
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), Token: token, HTTP: httpClient}
+}
+
+// Open creates a client from configuration. It returns nil when no token is configured.
+func Open(cfg *config.Config) *Client {
+	if strings.TrimSpace(cfg.Figma.Token) == "" {
+		return nil
+	}
+	return NewClient(cfg.Figma.BaseURL, cfg.Figma.Token, &http.Client{Timeout: time.Duration(cfg.Figma.TimeoutSec) * time.Second})
+}
+
+// File is the response of GET /v1/files/:key.
+type File struct {
+	Name         string                     `json:"name"`
+	Role         string                     `json:"role,omitempty"`
+	LastModified time.Time                  `json:"lastModified"`
+	EditorType   string                     `json:"editorType,omitempty"`
+	ThumbnailURL string                     `json:"thumbnailUrl,omitempty"`
+	Version      string                     `json:"version"`
+	Document     json.RawMessage            `json:"document,omitempty"`
+	Components   map[string]json.RawMessage `json:"components,omitempty"`
+	Styles       map[string]json.RawMessage `json:"styles,omitempty"`
+}
+
+// FileOptions narrows a file request.
+type FileOptions struct {
+	// Depth limits how deep the document tree is returned; 0 returns all of it.
+	Depth int
+	// Version fetches a specific version instead of the current one.
+	Version string
+}
+
+// GetFile fetches a file. key is a file key, or a branch key for branches.
+func (c *Client) GetFile(ctx context.Context, key string, opt FileOptions) (*File, error) {
+	q := url.Values{}
+	if opt.Depth > 0 {
+		q.Set("depth", strconv.Itoa(opt.Depth))
+	}
+	if opt.Version != "" {
+		q.Set("version", opt.Version)
+	}
+	var f File
+	if err := c.get(ctx, "/v1/files/"+url.PathEscape(key), q, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Node is one entry of a nodes response.
+type Node struct {
+	Document   json.RawMessage            `json:"document"`
+	Components map[string]json.RawMessage `json:"components,omitempty"`
+	Styles     map[string]json.RawMessage `json:"styles,omitempty"`
+}
+
+// Nodes is the response of GET /v1/files/:key/nodes.
+type Nodes struct {
+	Name         string    `json:"name"`
+	LastModified time.Time `json:"lastModified"`
+	Version      string    `json:"version"`
+	// Nodes is keyed by node id; ids that do not exist map to nil.
+	Nodes map[string]*Node `json:"nodes"`
+}
+
+// GetNodes fetches the subtrees rooted at ids.
+func (c *Client) GetNodes(ctx context.Context, key string, ids []string) (*Nodes, error) {
+	q := url.Values{"ids": {strings.Join(ids, ",")}}
+	var n Nodes
+	if err := c.get(ctx, "/v1/files/"+url.PathEscape(key)+"/nodes", q, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ImageOptions controls image rendering.
+type ImageOptions struct {
+	// Format is one of jpg, png, svg or pdf; the API defaults to png.
+	Format string
+	// Scale is between 0.01 and 4; 0 leaves the API default of 1.
+	Scale float64
+}
+
+// GetImages renders nodes and returns temporary download URLs keyed by node id.
+// Nodes that could not be rendered map to an empty URL.
+func (c *Client) GetImages(ctx context.Context, key string, ids []string, opt ImageOptions) (map[string]string, error) {
+	q := url.Values{"ids": {strings.Join(ids, ",")}}
+	if opt.Format != "" {
+		q.Set("format", opt.Format)
+	}
+	if opt.Scale > 0 {
+		q.Set("scale", strconv.FormatFloat(opt.Scale, 'f', -1, 64))
+	}
+	var res struct {
+		Err    *string            `json:"err"`
+		Images map[string]*string `json:"images"`
+	}
+	if err := c.get(ctx, "/v1/images/"+url.PathEscape(key), q, &res); err != nil {
+		return nil, err
+	}
+	if res.Err != nil && *res.Err != "" {
+		return nil, &Error{Status: http.StatusBadRequest, Message: *res.Err}
+	}
+	out := make(map[string]string, len(res.Images))
+	for id, u := range res.Images {
+		if u != nil {
+			out[id] = *u
+		} else {
+			out[id] = ""
+		}
+	}
+	return out, nil
+}
+
+// MaxDownload bounds the size of a rendered image fetched with Download.
+const MaxDownload = 32 << 20
+
+// Download fetches a rendered image from a URL returned by GetImages. The
+// URLs are pre-signed, so the token is not sent.
+func (c *Client) Download(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &Error{Status: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+	}
+	b, err := io.ReadAll(io.LimitReader(res.Body, MaxDownload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxDownload {
+		return nil, fmt.Errorf("figma: image larger than %d bytes", MaxDownload)
+	}
+	return b, nil
+}
+
+// Version is one entry of a file's version history.
+type Version struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Label       string    `json:"label,omitempty"`
+	Description string    `json:"description,omitempty"`
+	User        struct {
+		ID     string `json:"id"`
+		Handle string `json:"handle"`
+	} `json:"user"`
+}
+
+// GetVersions lists the most recent versions of a file, newest first.
+func (c *Client) GetVersions(ctx context.Context, key string) ([]Version, error) {
+	var res struct {
+		Versions []Version `json:"versions"`
+	}
+	if err := c.get(ctx, "/v1/files/"+url.PathEscape(key)+"/versions", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Versions, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values, out any) error {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Figma-Token", c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return responseError(res)
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("figma: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// responseError builds an *Error from a failed response. Figma reports errors
+// as {"status": 403, "err": "Invalid token"}.
+func responseError(res *http.Response) error {
+	e := &Error{Status: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	var payload struct {
+		Err     string `json:"err"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		if payload.Err != "" {
+			e.Message = payload.Err
+		} else if payload.Message != "" {
+			e.Message = payload.Message
+		}
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs > 0 {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return e
+}
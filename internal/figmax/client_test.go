@@ -0,0 +1,122 @@
+package figmax_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/figmax/figmatest"
+)
+
+func newStub(t *testing.T) *figmatest.Server {
+	t.Helper()
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	srv.SetFile("KEY", figmatest.File{
+		File: figmax.File{
+			Name:         "Design System",
+			LastModified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			ThumbnailURL: "https://thumb.example/1.png",
+			Version:      "42",
+			Document:     json.RawMessage(`{"id":"0:0","type":"DOCUMENT"}`),
+		},
+		Nodes:    map[string]figmax.Node{"1:2": {Document: json.RawMessage(`{"id":"1:2","type":"FRAME"}`)}},
+		Images:   map[string]string{"1:2": "https://img.example/1-2.svg"},
+		Versions: []figmax.Version{{ID: "42", Label: "release"}},
+		Variables: &figmax.Variables{
+			Variables: map[string]figmax.Variable{"VariableID:1:1": {
+				ID: "VariableID:1:1", Name: "space/md", VariableCollectionID: "VariableCollectionId:1:0", ResolvedType: "FLOAT",
+				ValuesByMode: map[string]json.RawMessage{"1:0": json.RawMessage(`8`)},
+			}},
+			Collections: map[string]figmax.VariableCollection{"VariableCollectionId:1:0": {
+				ID: "VariableCollectionId:1:0", Name: "Spacing", Modes: []figmax.VariableMode{{ModeID: "1:0", Name: "Default"}}, DefaultModeID: "1:0",
+			}},
+		},
+	})
+	srv.SetFile("STARTER", figmatest.File{File: figmax.File{Name: "No variables"}})
+	return srv
+}
+
+func TestClient_Endpoints(t *testing.T) {
+	srv := newStub(t)
+	fc := srv.FigmaClient()
+	ctx := context.Background()
+
+	f, err := fc.GetFile(ctx, "KEY", figmax.FileOptions{Depth: 1})
+	if err != nil {
+		t.Fatalf("get file: %v", err)
+	}
+	if f.Name != "Design System" || f.Version != "42" || !f.LastModified.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+
+	n, err := fc.GetNodes(ctx, "KEY", []string{"1:2", "9:9"})
+	if err != nil {
+		t.Fatalf("get nodes: %v", err)
+	}
+	if n.Nodes["1:2"] == nil || n.Nodes["9:9"] != nil {
+		t.Fatalf("unexpected nodes: %+v", n.Nodes)
+	}
+
+	imgs, err := fc.GetImages(ctx, "KEY", []string{"1:2", "9:9"}, figmax.ImageOptions{Format: "svg"})
+	if err != nil {
+		t.Fatalf("get images: %v", err)
+	}
+	if imgs["1:2"] != "https://img.example/1-2.svg" || imgs["9:9"] != "" {
+		t.Fatalf("unexpected images: %v", imgs)
+	}
+
+	u := srv.SetAsset("1-2.svg", []byte("<svg/>"))
+	if b, err := fc.Download(ctx, u); err != nil || string(b) != "<svg/>" {
+		t.Fatalf("download: %q, %v", b, err)
+	}
+	if _, err := fc.Download(ctx, srv.URL+"/assets/missing.svg"); !errors.Is(err, figmax.ErrNotFound) {
+		t.Fatalf("download missing: err = %v", err)
+	}
+
+	vs, err := fc.GetVersions(ctx, "KEY")
+	if err != nil {
+		t.Fatalf("get versions: %v", err)
+	}
+	if len(vs) != 1 || vs[0].Label != "release" {
+		t.Fatalf("unexpected versions: %+v", vs)
+	}
+
+	vars, err := fc.GetLocalVariables(ctx, "KEY")
+	if err != nil {
+		t.Fatalf("get variables: %v", err)
+	}
+	v := vars.Variables["VariableID:1:1"]
+	if v.Name != "space/md" || string(v.ValuesByMode["1:0"]) != "8" || vars.Collections[v.VariableCollectionID].Name != "Spacing" {
+		t.Fatalf("unexpected variables: %+v", vars)
+	}
+	if _, err := fc.GetLocalVariables(ctx, "STARTER"); !errors.Is(err, figmax.ErrUnauthorized) {
+		t.Fatalf("variables without plan access: err = %v", err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := newStub(t)
+	ctx := context.Background()
+
+	bad := figmax.NewClient(srv.URL, "wrong", srv.Client())
+	_, err := bad.GetFile(ctx, "KEY", figmax.FileOptions{})
+	var fe *figmax.Error
+	if !errors.Is(err, figmax.ErrUnauthorized) || !errors.As(err, &fe) || fe.Message != "Invalid token" {
+		t.Fatalf("bad token: err = %v", err)
+	}
+
+	if _, err := srv.FigmaClient().GetFile(ctx, "MISSING", figmax.FileOptions{}); !errors.Is(err, figmax.ErrNotFound) {
+		t.Fatalf("missing file: err = %v", err)
+	}
+
+	srv.FailNext(http.StatusTooManyRequests, 30)
+	_, err = srv.FigmaClient().GetVersions(ctx, "KEY")
+	if !errors.Is(err, figmax.ErrRateLimited) || !errors.As(err, &fe) || fe.RetryAfter != 30*time.Second {
+		t.Fatalf("rate limit: err = %v", err)
+	}
+}
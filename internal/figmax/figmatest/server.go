@@ -0,0 +1,220 @@
+// Package figmatest provides an in-memory stand-in for the Figma REST API.
+package figmatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+// File is a file served by the stub.
+type File struct {
+	figmax.File
+	Nodes    map[string]figmax.Node
+	Images   map[string]string
+	Versions []figmax.Version
+	// History holds earlier versions of the file, served for ?version=.
+	History map[string]figmax.File
+	// Variables is served by the local variables endpoint; when nil the
+	// endpoint answers 403 like files on plans without variables access.
+	Variables *figmax.Variables
+}
+
+// Server serves the file, nodes, images, versions and variables endpoints for the files
+// added to it, and the rendered images added with SetAsset. API requests must
+// carry Token in X-Figma-Token.
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu       sync.Mutex
+	files    map[string]*File
+	assets   map[string][]byte
+	fail     []failure
+	requests []string
+}
+
+type failure struct {
+	status     int
+	retryAfter int
+}
+
+// NewServer starts a stub accepting token. Callers must Close it.
+func NewServer(token string) *Server {
+	s := &Server{Token: token, files: map[string]*File{}, assets: map[string][]byte{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/files/{key}", s.handleFile)
+	mux.HandleFunc("GET /v1/files/{key}/nodes", s.handleNodes)
+	mux.HandleFunc("GET /v1/files/{key}/versions", s.handleVersions)
+	mux.HandleFunc("GET /v1/files/{key}/variables/local", s.handleVariables)
+	mux.HandleFunc("GET /v1/images/{key}", s.handleImages)
+	mux.HandleFunc("GET /assets/{name}", s.handleAsset)
+	s.Server = httptest.NewServer(s.auth(mux))
+	return s
+}
+
+// FigmaClient returns a client pointed at the stub.
+func (s *Server) FigmaClient() *figmax.Client {
+	return figmax.NewClient(s.URL, s.Token, s.Client())
+}
+
+// SetFile adds or replaces the file served under key.
+func (s *Server) SetFile(key string, f File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = &f
+}
+
+// SetAsset serves data as a rendered image and returns its URL, for use in
+// File.Images. Like Figma's pre-signed image URLs it needs no token.
+func (s *Server) SetAsset(name string, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assets[name] = data
+	return s.URL + "/assets/" + name
+}
+
+// FailNext makes the next request fail with status. retryAfter, in seconds, is
+// sent as Retry-After when positive.
+func (s *Server) FailNext(status, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = append(s.fail, failure{status: status, retryAfter: retryAfter})
+}
+
+// Requests returns the paths requested so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		var f *failure
+		if len(s.fail) > 0 {
+			f, s.fail = &s.fail[0], s.fail[1:]
+		}
+		s.mu.Unlock()
+
+		if f != nil {
+			if f.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(f.retryAfter))
+			}
+			writeError(w, f.status, http.StatusText(f.status))
+			return
+		}
+		if r.Header.Get("X-Figma-Token") != s.Token && !strings.HasPrefix(r.URL.Path, "/assets/") {
+			writeError(w, http.StatusForbidden, "Invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) file(w http.ResponseWriter, r *http.Request) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[r.PathValue("key")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found")
+		return nil
+	}
+	return f
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	f := s.file(w, r)
+	if f == nil {
+		return
+	}
+	if v := r.URL.Query().Get("version"); v != "" && v != f.Version {
+		old, ok := f.History[v]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Version not found")
+			return
+		}
+		writeJSON(w, old)
+		return
+	}
+	writeJSON(w, f.File)
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	f := s.file(w, r)
+	if f == nil {
+		return
+	}
+	nodes := map[string]*figmax.Node{}
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if n, ok := f.Nodes[id]; ok {
+			nodes[id] = &n
+		} else {
+			nodes[id] = nil
+		}
+	}
+	writeJSON(w, figmax.Nodes{Name: f.Name, LastModified: f.LastModified, Version: f.Version, Nodes: nodes})
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	if f := s.file(w, r); f != nil {
+		writeJSON(w, map[string]any{"versions": f.Versions})
+	}
+}
+
+func (s *Server) handleVariables(w http.ResponseWriter, r *http.Request) {
+	f := s.file(w, r)
+	if f == nil {
+		return
+	}
+	if f.Variables == nil {
+		writeError(w, http.StatusForbidden, "Limited by Figma plan")
+		return
+	}
+	writeJSON(w, map[string]any{"status": http.StatusOK, "error": false, "meta": f.Variables})
+}
+
+func (s *Server) handleImages(w http.ResponseWriter, r *http.Request) {
+	f := s.file(w, r)
+	if f == nil {
+		return
+	}
+	images := map[string]*string{}
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if u, ok := f.Images[id]; ok {
+			images[id] = &u
+		} else {
+			images[id] = nil
+		}
+	}
+	writeJSON(w, map[string]any{"err": nil, "images": images})
+}
+
+func (s *Server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.assets[r.PathValue("name")]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "err": msg})
+}
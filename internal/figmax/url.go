@@ -0,0 +1,79 @@
+// Package figmax holds Figma specific helpers: parsing file URLs and a client
+// for the Figma REST API.
+package figmax
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FileRef identifies a Figma file, optionally a branch of it and a node within it.
+type FileRef struct {
+	FileKey   string `json:"file_key"`
+	BranchKey string `json:"branch_key,omitempty"`
+	// NodeID uses the API form "1:2" even when the URL spelled it "1-2".
+	NodeID string `json:"node_id,omitempty"`
+}
+
+// ErrNotFigmaURL is returned for URLs that do not point at figma.com.
+var ErrNotFigmaURL = errors.New("not a Figma URL")
+
+var (
+	keyPattern  = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+	nodePattern = regexp.MustCompile(`^[0-9]+[:-][0-9]+$`)
+)
+
+// fileKinds are the path prefixes that address a design file.
+var fileKinds = map[string]bool{"file": true, "design": true, "proto": true}
+
+// ParseURL extracts the file reference from a Figma file, design, proto or branch
+// URL, such as https://www.figma.com/design/KEY/Name?node-id=1-2 or
+// https://figma.com/file/KEY/branch/BRANCH/Name.
+func ParseURL(raw string) (*FileRef, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("%w: %q is not an http(s) URL", ErrNotFigmaURL, raw)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host != "figma.com" && host != "www.figma.com" {
+		return nil, fmt.Errorf("%w: host %q is not figma.com", ErrNotFigmaURL, u.Hostname())
+	}
+
+	segs := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segs) == 0 || !fileKinds[segs[0]] {
+		return nil, fmt.Errorf("%w: expected a /file/, /design/ or /proto/ link", ErrNotFigmaURL)
+	}
+	if len(segs) < 2 || !keyPattern.MatchString(segs[1]) {
+		return nil, fmt.Errorf("%w: missing or malformed file key", ErrNotFigmaURL)
+	}
+	ref := &FileRef{FileKey: segs[1]}
+	if len(segs) >= 3 && segs[2] == "branch" {
+		if len(segs) < 4 || !keyPattern.MatchString(segs[3]) {
+			return nil, fmt.Errorf("%w: missing or malformed branch key", ErrNotFigmaURL)
+		}
+		ref.BranchKey = segs[3]
+	}
+
+	if node := u.Query().Get("node-id"); node != "" {
+		if !nodePattern.MatchString(node) {
+			return nil, fmt.Errorf("%w: malformed node-id %q", ErrNotFigmaURL, node)
+		}
+		ref.NodeID = strings.Replace(node, "-", ":", 1)
+	}
+	return ref, nil
+}
+
+// URL returns the canonical design URL of the reference.
+func (r *FileRef) URL() string {
+	s := "https://www.figma.com/design/" + r.FileKey
+	if r.BranchKey != "" {
+		s += "/branch/" + r.BranchKey
+	}
+	if r.NodeID != "" {
+		s += "?node-id=" + strings.Replace(r.NodeID, ":", "-", 1)
+	}
+	return s
+}
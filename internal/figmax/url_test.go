@@ -0,0 +1,59 @@
+package figmax
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		in   string
+		want FileRef
+	}{
+		{"https://www.figma.com/file/AbC123/Name?node-id=1-2", FileRef{FileKey: "AbC123", NodeID: "1:2"}},
+		{"https://figma.com/design/AbC123/Other", FileRef{FileKey: "AbC123"}},
+		{"http://www.figma.com/proto/AbC123/Name?node-id=10%3A20&scaling=min-zoom", FileRef{FileKey: "AbC123", NodeID: "10:20"}},
+		{"https://www.figma.com/design/AbC123/branch/Br4nch/Name", FileRef{FileKey: "AbC123", BranchKey: "Br4nch"}},
+		{" https://WWW.FIGMA.COM/file/AbC123 ", FileRef{FileKey: "AbC123"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseURL(tc.in)
+		if err != nil {
+			t.Fatalf("ParseURL(%q): %v", tc.in, err)
+		}
+		if *got != tc.want {
+			t.Fatalf("ParseURL(%q) = %+v, want %+v", tc.in, *got, tc.want)
+		}
+	}
+}
+
+func TestParseURL_Rejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"figma.com/file/AbC123",
+		"ftp://figma.com/file/AbC123",
+		"https://example.com/file/AbC123",
+		"https://figma.com.evil.io/file/AbC123",
+		"https://www.figma.com/community/file/123",
+		"https://www.figma.com/file/",
+		"https://www.figma.com/file/Ab_C/Name",
+		"https://www.figma.com/design/AbC123/branch/",
+		"https://www.figma.com/design/AbC123/Name?node-id=abc",
+	} {
+		if _, err := ParseURL(in); !errors.Is(err, ErrNotFigmaURL) {
+			t.Fatalf("ParseURL(%q) error = %v, want ErrNotFigmaURL", in, err)
+		}
+	}
+}
+
+func TestFileRefURL(t *testing.T) {
+	a, _ := ParseURL("https://www.figma.com/file/AbC123/Name?node-id=1-2")
+	b, _ := ParseURL("https://figma.com/design/AbC123/Other?node-id=1%3A2")
+	if a.URL() != b.URL() || a.URL() != "https://www.figma.com/design/AbC123?node-id=1-2" {
+		t.Fatalf("canonical URLs differ: %q vs %q", a.URL(), b.URL())
+	}
+	br := FileRef{FileKey: "K", BranchKey: "B"}
+	if got := br.URL(); got != "https://www.figma.com/design/K/branch/B" {
+		t.Fatalf("branch URL = %q", got)
+	}
+}
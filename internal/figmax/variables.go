@@ -0,0 +1,58 @@
+package figmax
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// Variables is the meta payload of GET /v1/files/:key/variables/local: the
+// local variables of a file and the remote ones it uses.
+type Variables struct {
+	Variables   map[string]Variable           `json:"variables"`
+	Collections map[string]VariableCollection `json:"variableCollections"`
+}
+
+// Variable is a single variable with one value per mode of its collection.
+type Variable struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	Key                  string `json:"key,omitempty"`
+	VariableCollectionID string `json:"variableCollectionId"`
+	// ResolvedType is one of BOOLEAN, FLOAT, STRING or COLOR.
+	ResolvedType string `json:"resolvedType"`
+	Description  string `json:"description,omitempty"`
+	// ValuesByMode holds a raw value or a {"type":"VARIABLE_ALIAS","id":...}
+	// reference per mode id.
+	ValuesByMode         map[string]json.RawMessage `json:"valuesByMode"`
+	Scopes               []string                   `json:"scopes,omitempty"`
+	Remote               bool                       `json:"remote,omitempty"`
+	HiddenFromPublishing bool                       `json:"hiddenFromPublishing,omitempty"`
+}
+
+// VariableCollection groups variables sharing a set of modes.
+type VariableCollection struct {
+	ID            string         `json:"id"`
+	Name          string         `json:"name"`
+	Modes         []VariableMode `json:"modes"`
+	DefaultModeID string         `json:"defaultModeId"`
+	Remote        bool           `json:"remote,omitempty"`
+}
+
+// VariableMode is one mode (e.g. a theme) of a collection.
+type VariableMode struct {
+	ModeID string `json:"modeId"`
+	Name   string `json:"name"`
+}
+
+// GetLocalVariables fetches the variables of a file. The endpoint is limited to
+// some Figma plans and answers 403 elsewhere, which matches ErrUnauthorized.
+func (c *Client) GetLocalVariables(ctx context.Context, key string) (*Variables, error) {
+	var res struct {
+		Meta Variables `json:"meta"`
+	}
+	if err := c.get(ctx, "/v1/files/"+url.PathEscape(key)+"/variables/local", nil, &res); err != nil {
+		return nil, err
+	}
+	return &res.Meta, nil
+}
@@ -0,0 +1,21 @@
+package figmax
+
+import "time"
+
+// Webhook event types.
+const (
+	EventPing           = "PING"
+	EventFileUpdate     = "FILE_UPDATE"
+	EventLibraryPublish = "LIBRARY_PUBLISH"
+)
+
+// WebhookEvent is the body Figma posts to a webhook endpoint. Passcode echoes
+// the passcode the webhook was created with.
+type WebhookEvent struct {
+	EventType string    `json:"event_type"`
+	Passcode  string    `json:"passcode"`
+	WebhookID string    `json:"webhook_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	FileKey   string    `json:"file_key,omitempty"`
+	FileName  string    `json:"file_name,omitempty"`
+}
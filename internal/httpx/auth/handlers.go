@@ -386,7 +386,7 @@ func LoginHandler(cfg *config.Config, client *ent.Client) fiber.Handler {
 // RegisterHandler creates a new user and a password identity, then returns JWTs.
 //
 //	@Summary      Register (password)
-//	@Description  Create user + password identity, then issue tokens
+//	@Description  Create user + password identity, then issue tokens. Configs shared with the identifier before it registered stay pending until their sharer confirms them, as the identifier is not verified
 //	@Tags         auth
 //	@Accept       json
 //	@Produce      json
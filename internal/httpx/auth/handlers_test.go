@@ -16,7 +16,9 @@ import (
 	_ "modernc.org/sqlite"
 
 	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configshare"
 	"fiber-ent-apollo-pg/ent/identity"
+	"fiber-ent-apollo-pg/ent/pendingshare"
 	"fiber-ent-apollo-pg/internal/config"
 	// kit imported by testutil
 	testutil "fiber-ent-apollo-pg/internal/httpx/kit/testutil"
@@ -27,13 +29,15 @@ func newTestApp(t *testing.T, client *ent.Client, cfg *config.Config) *fiber.App
 	return testutil.NewApp(
 		func(app *fiber.App) { app.Post("/auth/anonymous/init", AnonymousInitHandler(cfg, client)) },
 		func(app *fiber.App) { app.Post("/auth/login", LoginHandler(cfg, client)) },
+		func(app *fiber.App) { app.Post("/auth/register", RegisterHandler(cfg, client)) },
 		func(app *fiber.App) { app.Post("/auth/fp/sync", FpSyncHandler(client)) },
 	)
 }
 
 func newTestClient(t *testing.T) *ent.Client {
 	t.Helper()
-	dsn := "file:ent?mode=memory&cache=shared&_fk=1"
+	// One database per test, so repeated runs do not see each other's rows
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared&_fk=1"
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		t.Fatalf("open db: %v", err)
@@ -42,6 +46,7 @@ func newTestClient(t *testing.T) *ent.Client {
 	_, _ = db.Exec("PRAGMA foreign_keys = ON")
 	drv := entsql.OpenDB(dialect.SQLite, db)
 	client := ent.NewClient(ent.Driver(drv))
+	t.Cleanup(func() { _ = client.Close() })
 	ctx, cancel := contextWithT(t)
 	defer cancel()
 	if err := client.Schema.Create(ctx); err != nil {
@@ -195,3 +200,52 @@ func contextWithT(t *testing.T) (context.Context, context.CancelFunc) {
 	t.Helper()
 	return context.WithTimeout(context.Background(), 5*time.Second)
 }
+
+func TestRegister_LeavesPendingShares(t *testing.T) {
+	client := newTestClient(t)
+	cfg := newTestConfig()
+	app := newTestApp(t, client, cfg)
+
+	ctx, cancel := contextWithT(t)
+	defer cancel()
+	owner, err := client.User.Create().SetDisplayName("Sharer").Save(ctx)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	item, err := client.ConfigItem.Create().SetName("Shared").SetOwner(owner).SetData(map[string]any{}).Save(ctx)
+	if err != nil {
+		t.Fatalf("create config: %v", err)
+	}
+	_, err = client.PendingShare.Create().
+		SetConfigID(item.ID).
+		SetSharerID(owner.ID).
+		SetIdentifier("Carol@Example.com").
+		SetPermission(pendingshare.PermissionEdit).
+		Save(ctx)
+	if err != nil {
+		t.Fatalf("create pending share: %v", err)
+	}
+
+	b, _ := json.Marshal(RegisterRequest{Identifier: "carol@example.com", Password: "P@ssw0rd", DisplayName: "Carol"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d", res.StatusCode)
+	}
+
+	idn, err := client.Identity.Query().Where(identity.IdentifierEQ("carol@example.com")).WithUser().Only(ctx)
+	if err != nil {
+		t.Fatalf("query identity: %v", err)
+	}
+	// Registration does not prove the identifier, so the sharer confirms it
+	if client.ConfigShare.Query().Where(configshare.ConfigIDEQ(item.ID), configshare.UserIDEQ(idn.Edges.User.ID)).ExistX(ctx) {
+		t.Fatal("pending share claimed on registration")
+	}
+	if n := client.PendingShare.Query().Where(pendingshare.ConfigIDEQ(item.ID)).CountX(ctx); n != 1 {
+		t.Fatalf("pending shares: %d", n)
+	}
+}
@@ -0,0 +1,137 @@
+package configs
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupshare"
+	"fiber-ent-apollo-pg/ent/user"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+)
+
+// permission is the caller's effective access level on a config.
+type permission int
+
+const (
+	permNone permission = iota
+	permView
+	permEdit
+	permManage
+	permOwner
+)
+
+// String returns the name of the permission as listed in responses.
+func (p permission) String() string {
+	switch p {
+	case permView:
+		return "view"
+	case permEdit:
+		return "edit"
+	case permManage:
+		return "manage"
+	case permOwner:
+		return "owner"
+	}
+	return "none"
+}
+
+// sharePermission maps a share's permission value to a permission level.
+func sharePermission(v string) permission {
+	switch v {
+	case "view":
+		return permView
+	case "edit":
+		return permEdit
+	case "manage":
+		return permManage
+	}
+	return permNone
+}
+
+// loadConfig fetches a config with its owner and resolves the caller's permission on it.
+func loadConfig(ctx context.Context, client *ent.Client, cfgID, uid uuid.UUID) (*ent.ConfigItem, permission, error) {
+	cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
+	if err != nil || cfg.Edges.Owner == nil {
+		return nil, permNone, kit.NotFound("config not found")
+	}
+	if cfg.Edges.Owner.ID == uid {
+		return cfg, permOwner, nil
+	}
+	perms, err := permissions(ctx, client, uid, []*ent.ConfigItem{cfg})
+	if err != nil {
+		return nil, permNone, kit.InternalError("query config access failed", err.Error())
+	}
+	return cfg, perms[cfgID], nil
+}
+
+// permissions resolves the caller's permission on each config: owners have
+// every permission, others the highest one granted by a direct share or by a
+// share to one of their groups.
+func permissions(ctx context.Context, client *ent.Client, uid uuid.UUID, cfgs []*ent.ConfigItem) (map[uuid.UUID]permission, error) {
+	out := make(map[uuid.UUID]permission, len(cfgs))
+	if len(cfgs) == 0 {
+		return out, nil
+	}
+	ids := make([]uuid.UUID, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		ids = append(ids, cfg.ID)
+	}
+	grant := func(id uuid.UUID, p permission) {
+		if p > out[id] {
+			out[id] = p
+		}
+	}
+	owned, err := client.ConfigItem.Query().
+		Where(configitem.IDIn(ids...), configitem.HasOwnerWith(user.IDEQ(uid))).
+		IDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range owned {
+		grant(id, permOwner)
+	}
+	direct, err := client.ConfigShare.Query().
+		Where(configshare.ConfigIDIn(ids...), configshare.UserIDEQ(uid)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range direct {
+		grant(s.ConfigID, sharePermission(s.Permission.String()))
+	}
+	viaGroups, err := client.GroupShare.Query().
+		Where(groupshare.ConfigItemIDIn(ids...), groupshare.HasGroupWith(group.HasMembersWith(user.IDEQ(uid)))).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range viaGroups {
+		grant(s.ConfigItemID, sharePermission(s.Permission.String()))
+	}
+	return out, nil
+}
+
+// ConfigListItem is a config in a list response, with the caller's effective
+// permission on it (view, edit, manage or owner).
+type ConfigListItem struct {
+	*ent.ConfigItem
+	Permission string `json:"permission"`
+}
+
+// withPermissions pairs listed configs with the caller's permission on them.
+func withPermissions(ctx context.Context, client *ent.Client, uid uuid.UUID, items []*ent.ConfigItem) ([]ConfigListItem, error) {
+	perms, err := permissions(ctx, client, uid, items)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ConfigListItem, 0, len(items))
+	for _, it := range items {
+		out = append(out, ConfigListItem{ConfigItem: it, Permission: perms[it.ID].String()})
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configrevision"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/jsonx"
+)
+
+// DiffSide identifies one side of a config diff.
+type DiffSide struct {
+	ConfigID uuid.UUID `json:"config_id"`
+	Revision int       `json:"revision"`
+}
+
+// String labels the side for text output.
+func (s DiffSide) String() string {
+	return fmt.Sprintf("config %s@%d", s.ConfigID, s.Revision)
+}
+
+// DiffResponse is the structured diff of two config data maps
+// swagger:model DiffResponse
+type DiffResponse struct {
+	From DiffSide `json:"from"`
+	To   DiffSide `json:"to"`
+	jsonx.Diff
+}
+
+// DiffConfigHandler diffs a config's head data against another config or one of its revisions.
+//
+//	@Summary      Diff config
+//	@Description  Structured diff of data from `against` (a config UUID or a revision number of this config) to this config's head. format=text renders a unified-style view.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Produce      plain
+//	@Param        id       path   string  true   "Config UUID"
+//	@Param        against  query  string  true   "config UUID or revision number"
+//	@Param        format   query  string  false  "json or text"  default(json)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/diff [get]
+func DiffConfigHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		against := strings.TrimSpace(c.Query("against"))
+		if against == "" {
+			return kit.BadRequest("against required", nil)
+		}
+		format := strings.ToLower(c.Query("format", "json"))
+		if format != "json" && format != "text" {
+			return kit.BadRequest("format must be json or text", format)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		var (
+			fromSide DiffSide
+			fromData map[string]any
+		)
+		if otherID, err := uuid.Parse(against); err == nil {
+			other, perm, err := loadConfig(ctx, client, otherID, uid)
+			if err != nil {
+				return err
+			}
+			if perm < permView {
+				return fiber.ErrForbidden
+			}
+			fromSide, fromData = DiffSide{ConfigID: other.ID, Revision: other.Revision}, other.Data
+		} else {
+			rev, err := strconv.Atoi(against)
+			if err != nil || rev <= 0 {
+				return kit.BadRequest("against must be a config id or a revision number", against)
+			}
+			old, err := client.ConfigRevision.Query().
+				Where(configrevision.ConfigIDEQ(cfgID), configrevision.RevisionEQ(rev)).
+				Only(ctx)
+			if err != nil {
+				return kit.NotFound("revision not found")
+			}
+			fromSide, fromData = DiffSide{ConfigID: cfgID, Revision: old.Revision}, old.Data
+		}
+		toSide := DiffSide{ConfigID: cfg.ID, Revision: cfg.Revision}
+
+		diff := jsonx.Compare(asDocument(fromData), asDocument(cfg.Data))
+		if format == "text" {
+			c.Type("txt", "utf-8")
+			return c.SendString(jsonx.RenderText(diff, fromSide.String(), toSide.String()))
+		}
+		return kit.OK(c, DiffResponse{From: fromSide, To: toSide, Diff: diff})
+	}
+}
+
+// asDocument returns data as a JSON document, treating a nil map as an empty object.
+func asDocument(data map[string]any) any {
+	if data == nil {
+		return map[string]any{}
+	}
+	return data
+}
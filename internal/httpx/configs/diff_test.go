@@ -0,0 +1,79 @@
+package configs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_Diff(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("DiffOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Get("/configs/:id/diff", mw.RequireUser(), DiffConfigHandler(client))
+	})
+
+	var a, b struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "DiffA", "data": map[string]any{"scale": 1, "format": "png"}}, &a)
+	doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "DiffB", "data": map[string]any{"scale": 2}}, &b)
+	base := "/configs/" + a.Data.ID.String()
+	doJSON(t, app, http.MethodPut, base, map[string]any{"data": map[string]any{"scale": 2, "prefix": "ic-"}}, nil)
+
+	var diff struct {
+		Data struct {
+			From    DiffSide `json:"from"`
+			To      DiffSide `json:"to"`
+			Added   []map[string]any
+			Removed []map[string]any
+			Changed []map[string]any
+		}
+	}
+	res := doJSON(t, app, http.MethodGet, base+"/diff?against=1", nil, &diff)
+	if res.StatusCode != http.StatusOK || diff.Data.From.Revision != 1 || diff.Data.To.Revision != 2 {
+		t.Fatalf("revision diff: status=%d body=%+v", res.StatusCode, diff.Data)
+	}
+	if len(diff.Data.Added) != 1 || diff.Data.Added[0]["path"] != "/prefix" ||
+		len(diff.Data.Removed) != 1 || diff.Data.Removed[0]["path"] != "/format" ||
+		len(diff.Data.Changed) != 1 || diff.Data.Changed[0]["old"] != float64(1) || diff.Data.Changed[0]["new"] != float64(2) {
+		t.Fatalf("unexpected revision diff: %+v", diff.Data)
+	}
+
+	res = doJSON(t, app, http.MethodGet, base+"/diff?against="+b.Data.ID.String()+"&format=text", nil, nil)
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("text diff: status=%d type=%q", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "+ /prefix: \"ic-\"\n") || strings.Contains(string(body), "/scale") {
+		t.Fatalf("unexpected text diff:\n%s", body)
+	}
+
+	for _, q := range []string{"", "?against=x", "?against=1&format=yaml"} {
+		if res := doJSON(t, app, http.MethodGet, base+"/diff"+q, nil, nil); res.StatusCode != http.StatusBadRequest {
+			t.Fatalf("diff%s: status=%d", q, res.StatusCode)
+		}
+	}
+	if res := doJSON(t, app, http.MethodGet, base+"/diff?against=9", nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing revision: status=%d", res.StatusCode)
+	}
+}
@@ -0,0 +1,106 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// putIfMatch sends a JSON PUT guarded by the given If-Match value.
+func putIfMatch(t *testing.T, app *fiber.App, target, ifMatch string, body any) *http.Response {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPut, target, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", ifMatch)
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("PUT %s: %v", target, err)
+	}
+	return res
+}
+
+func TestConfig_ETag_IfMatch(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("ETagOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Get("/configs", mw.RequireUser(), ListConfigsHandler(client))
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Get("/configs/:id", mw.RequireUser(), GetConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Delete("/configs/:id", mw.RequireUser(), DeleteConfigHandler(client))
+	})
+
+	var created struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	res := doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "ETagCfg", "data": map[string]any{"v": 1}}, &created)
+	if res.StatusCode != http.StatusCreated || res.Header.Get("ETag") != `"1"` {
+		t.Fatalf("create: status=%d etag=%q", res.StatusCode, res.Header.Get("ETag"))
+	}
+	base := "/configs/" + created.Data.ID.String()
+
+	res = doJSON(t, app, http.MethodGet, base, nil, nil)
+	etag := res.Header.Get("ETag")
+	if res.StatusCode != http.StatusOK || etag != `"1"` {
+		t.Fatalf("get: status=%d etag=%q", res.StatusCode, etag)
+	}
+	listTag := doJSON(t, app, http.MethodGet, "/configs", nil, nil).Header.Get("ETag")
+	if listTag == "" {
+		t.Fatalf("list: missing etag")
+	}
+
+	// First writer wins and receives the new tag
+	res = putIfMatch(t, app, base, etag, map[string]any{"data": map[string]any{"v": 2}})
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") != `"2"` {
+		t.Fatalf("guarded put: status=%d etag=%q", res.StatusCode, res.Header.Get("ETag"))
+	}
+	if got := doJSON(t, app, http.MethodGet, "/configs", nil, nil).Header.Get("ETag"); got == listTag {
+		t.Fatalf("list etag did not change after update")
+	}
+
+	// Second writer still holds the stale tag
+	var body struct {
+		Code    string         `json:"code"`
+		Details map[string]any `json:"details"`
+	}
+	res = putIfMatch(t, app, base, etag, map[string]any{"data": map[string]any{"v": 3}})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale put: status=%d", res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Code != "E_PRECONDITION_FAILED" || body.Details["etag"] != `"2"` {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, base, nil)
+	req.Header.Set("If-Match", etag)
+	if res, _ := app.Test(req); res.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale delete: status=%d", res.StatusCode)
+	}
+	req = httptest.NewRequest(http.MethodDelete, base, nil)
+	req.Header.Set("If-Match", `"2"`)
+	if res, _ := app.Test(req); res.StatusCode != http.StatusOK {
+		t.Fatalf("guarded delete: status=%d", res.StatusCode)
+	}
+}
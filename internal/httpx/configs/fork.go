@@ -0,0 +1,210 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/internal/configx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/jsonx"
+)
+
+// ForkConfigRequest is the optional request body for forking a config
+// swagger:model ForkConfigRequest
+type ForkConfigRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ForkConfigHandler copies a visible config into a new config owned by the caller.
+//
+//	@Summary      Fork config
+//	@Description  Create a copy of a config (owner or shared) owned by the current user. The fork records the upstream config and revision it was taken from. Parents the caller cannot see are not copied. If-Match is not evaluated; the fork records the revision it copied.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path  string                     true   "Config UUID"
+//	@Param        body  body  configs.ForkConfigRequest  false  "fork payload"
+//	@Success      201   {object}  map[string]interface{}
+//	@Failure      400   {object}  map[string]interface{}
+//	@Failure      401   {object}  map[string]interface{}
+//	@Failure      403   {object}  map[string]interface{}
+//	@Failure      404   {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/fork [post]
+func ForkConfigHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		var req ForkConfigRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return kit.BadRequest("invalid request body", nil)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		src, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = forkName(src.Name)
+		}
+		data, _ := jsonx.Clone(src.Data).(map[string]any)
+		// Parents the forking user cannot see are dropped rather than leaked
+		parents := src.ParentIds
+		if len(parents) > 0 {
+			visible, err := client.ConfigItem.Query().
+				Where(configitem.IDIn(parents...), configx.VisibleTo(uid)).
+				IDs(ctx)
+			if err != nil {
+				return kit.InternalError("query parents failed", err.Error())
+			}
+			ok := make(map[uuid.UUID]bool, len(visible))
+			for _, id := range visible {
+				ok[id] = true
+			}
+			parents = make([]uuid.UUID, 0, len(src.ParentIds))
+			for _, id := range src.ParentIds {
+				if ok[id] {
+					parents = append(parents, id)
+				}
+			}
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		created, err := tx.ConfigItem.Create().
+			SetName(name).
+			SetKind(src.Kind).
+			SetParentIds(parents).
+			SetData(data).
+			SetRevision(1).
+			SetOwnerID(uid).
+			SetForkedFromID(src.ID).
+			SetForkedFromRevision(src.Revision).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("create fork failed", err.Error())
+		}
+		if _, err := tx.ConfigRevision.Create().
+			SetConfigID(created.ID).
+			SetAuthorID(uid).
+			SetRevision(1).
+			SetData(created.Data).
+			SetMessage(fmt.Sprintf("fork of %s at revision %d", src.ID, src.Revision)).
+			Save(ctx); err != nil {
+			return kit.InternalError("create revision failed", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
+		kit.SetETag(c, kit.VersionETag(created.Revision))
+		return kit.Created(c, created)
+	}
+}
+
+// ListForksHandler lists the forks of a config that the current user can see.
+//
+//	@Summary      List config forks
+//	@Description  Forks of a config that are owned by or shared to the current user, each with the caller's permission
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Config UUID"
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/forks [get]
+func ListForksHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		_, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		items, err := client.ConfigItem.Query().
+			Where(configitem.ForkedFromIDEQ(cfgID), configx.VisibleTo(uid)).
+			Order(ent.Desc(configitem.FieldCreatedAt)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query forks failed", err.Error())
+		}
+		list, err := withPermissions(ctx, client, uid, items)
+		if err != nil {
+			return kit.InternalError("query config access failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		kit.SetETag(c, listETag(list))
+		return kit.List(c, list, meta)
+	}
+}
+
+// forkName derives a default name for a fork, keeping within the 255 byte name limit.
+func forkName(name string) string {
+	const suffix = " (fork)"
+	for len(name)+len(suffix) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name + suffix
+}
@@ -0,0 +1,150 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_Fork(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("ForkOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	member, err := client.User.Create().SetDisplayName("ForkMember").Save(ctx)
+	if err != nil {
+		t.Fatalf("create member: %v", err)
+	}
+	stranger, err := client.User.Create().SetDisplayName("ForkStranger").Save(ctx)
+	if err != nil {
+		t.Fatalf("create stranger: %v", err)
+	}
+
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Get("/configs", mw.RequireUser(), ListConfigsHandler(client))
+		app.Delete("/configs/:id", mw.RequireUser(), DeleteConfigHandler(client))
+		app.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), ShareToUserHandler(client))
+		app.Post("/configs/:id/fork", mw.RequireUser(), ForkConfigHandler(client))
+		app.Get("/configs/:id/forks", mw.RequireUser(), ListForksHandler(client))
+	}
+	appOwner := testutil.NewApp(asUser(owner.ID), routes)
+	appMember := testutil.NewApp(asUser(member.ID), routes)
+	appStranger := testutil.NewApp(asUser(stranger.ID), routes)
+
+	var src struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	doJSON(t, appOwner, http.MethodPost, "/configs", map[string]any{"name": "Upstream", "data": map[string]any{"scale": 2}}, &src)
+	base := "/configs/" + src.Data.ID.String()
+
+	if res := doJSON(t, appStranger, http.MethodPost, base+"/fork", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("stranger fork: status=%d", res.StatusCode)
+	}
+	doJSON(t, appOwner, http.MethodPost, base+"/share/user/"+member.ID.String(), nil, nil)
+
+	var fork struct {
+		Data struct {
+			ID                 uuid.UUID      `json:"id"`
+			Name               string         `json:"name"`
+			Revision           int            `json:"revision"`
+			ForkedFromID       *uuid.UUID     `json:"forked_from_id"`
+			ForkedFromRevision int            `json:"forked_from_revision"`
+			Data               map[string]any `json:"data"`
+		}
+	}
+	res := doJSON(t, appMember, http.MethodPost, base+"/fork", nil, &fork)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("member fork: status=%d", res.StatusCode)
+	}
+	if fork.Data.Name != "Upstream (fork)" || fork.Data.Revision != 1 || fork.Data.ForkedFromID == nil ||
+		*fork.Data.ForkedFromID != src.Data.ID || fork.Data.ForkedFromRevision != 1 || fork.Data.Data["scale"] != float64(2) {
+		t.Fatalf("unexpected fork: %+v", fork.Data)
+	}
+
+	// Lineage is exposed on list endpoints
+	var mine struct {
+		Data []struct {
+			ForkedFromID *uuid.UUID `json:"forked_from_id"`
+		}
+	}
+	doJSON(t, appMember, http.MethodGet, "/configs", nil, &mine)
+	if len(mine.Data) != 1 || mine.Data[0].ForkedFromID == nil || *mine.Data[0].ForkedFromID != src.Data.ID {
+		t.Fatalf("unexpected member configs: %+v", mine.Data)
+	}
+	var forks struct{ Data []map[string]any }
+	doJSON(t, appMember, http.MethodGet, base+"/forks", nil, &forks)
+	if len(forks.Data) != 1 {
+		t.Fatalf("member forks: %d", len(forks.Data))
+	}
+	// The upstream owner cannot see a fork that was not shared back
+	doJSON(t, appOwner, http.MethodGet, base+"/forks", nil, &forks)
+	if len(forks.Data) != 0 {
+		t.Fatalf("owner forks: %d", len(forks.Data))
+	}
+
+	// Deleting the upstream detaches the fork
+	if res := doJSON(t, appOwner, http.MethodDelete, base, nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("delete upstream: status=%d", res.StatusCode)
+	}
+	got, err := client.ConfigItem.Get(ctx, fork.Data.ID)
+	if err != nil || got.ForkedFromID != nil {
+		t.Fatalf("fork after upstream delete: %+v err=%v", got, err)
+	}
+}
+
+func TestConfig_ForkDropsInvisibleParents(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner := client.User.Create().SetDisplayName("ForkParentOwner").SaveX(ctx)
+	member := client.User.Create().SetDisplayName("ForkParentMember").SaveX(ctx)
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), ShareToUserHandler(client))
+		app.Post("/configs/:id/fork", mw.RequireUser(), ForkConfigHandler(client))
+	}
+	appOwner := testutil.NewApp(asUser(owner.ID), routes)
+	appMember := testutil.NewApp(asUser(member.ID), routes)
+	create := func(body map[string]any) uuid.UUID {
+		var env struct {
+			Data struct {
+				ID uuid.UUID `json:"id"`
+			}
+		}
+		doJSON(t, appOwner, http.MethodPost, "/configs", body, &env)
+		return env.Data.ID
+	}
+	private := create(map[string]any{"name": "PrivateParent", "data": map[string]any{"secret": 1}})
+	shared := create(map[string]any{"name": "SharedParent", "data": map[string]any{"scale": 1}})
+	src := create(map[string]any{"name": "Layered", "parent_ids": []uuid.UUID{private, shared}, "data": map[string]any{}})
+	for _, id := range []uuid.UUID{shared, src} {
+		doJSON(t, appOwner, http.MethodPost, "/configs/"+id.String()+"/share/user/"+member.ID.String(), nil, nil)
+	}
+
+	var fork struct {
+		Data struct {
+			ParentIDs []uuid.UUID `json:"parent_ids"`
+		}
+	}
+	if res := doJSON(t, appMember, http.MethodPost, "/configs/"+src.String()+"/fork", nil, &fork); res.StatusCode != http.StatusCreated {
+		t.Fatalf("fork: status=%d", res.StatusCode)
+	}
+	if len(fork.Data.ParentIDs) != 1 || fork.Data.ParentIDs[0] != shared {
+		t.Fatalf("fork parents: %v", fork.Data.ParentIDs)
+	}
+}
@@ -3,16 +3,25 @@ package configs
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
 	"fiber-ent-apollo-pg/ent"
 	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/configrevision"
+	"fiber-ent-apollo-pg/ent/configshare"
 	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupshare"
+	"fiber-ent-apollo-pg/ent/schema"
 	"fiber-ent-apollo-pg/ent/user"
+	"fiber-ent-apollo-pg/internal/configx"
 	"fiber-ent-apollo-pg/internal/httpx/kit"
 	"fiber-ent-apollo-pg/internal/httpx/mw"
 )
@@ -20,27 +29,40 @@ import (
 // CreateConfigRequest is the request body for creating a config item
 // swagger:model CreateConfigRequest
 type CreateConfigRequest struct {
-	Name string         `json:"name"`
-	Data map[string]any `json:"data"`
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind,omitempty"`
+	ParentIDs []uuid.UUID    `json:"parent_ids,omitempty"`
+	Data      map[string]any `json:"data"`
+	Message   string         `json:"message,omitempty"`
 }
 
 // UpdateConfigRequest is the request body for updating a config item
 // swagger:model UpdateConfigRequest
 type UpdateConfigRequest struct {
-	Name *string         `json:"name,omitempty"`
-	Data *map[string]any `json:"data,omitempty"`
+	Name      *string         `json:"name,omitempty"`
+	Kind      *string         `json:"kind,omitempty"`
+	ParentIDs *[]uuid.UUID    `json:"parent_ids,omitempty"`
+	Data      *map[string]any `json:"data,omitempty"`
+	Message   string          `json:"message,omitempty"`
 }
 
 // ShareToGroupsRequest is the request body for sharing a config to groups
 // swagger:model ShareToGroupsRequest
 type ShareToGroupsRequest struct {
-	GroupIDs []uuid.UUID `json:"group_ids"`
+	GroupIDs   []uuid.UUID `json:"group_ids"`
+	Permission string      `json:"permission,omitempty" example:"view"`
+}
+
+// ShareToUserRequest is the request body for sharing a config with a user
+// swagger:model ShareToUserRequest
+type ShareToUserRequest struct {
+	Permission string `json:"permission,omitempty" example:"view"`
 }
 
 // ListConfigsHandler lists configs owned by the current user.
 //
 //	@Summary      List my configs
-//	@Description  Returns configs owned by the current user
+//	@Description  Returns configs owned by the current user, each with the caller's permission (owner)
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
@@ -74,16 +96,21 @@ func ListConfigsHandler(client *ent.Client) fiber.Handler {
 			return kit.InternalError("query configs failed", err.Error())
 		}
 
+		list, err := withPermissions(ctx, client, uid, items)
+		if err != nil {
+			return kit.InternalError("query config access failed", err.Error())
+		}
 		nextOff := pg.Offset + len(items)
 		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
-		return kit.List(c, items, meta)
+		kit.SetETag(c, listETag(list))
+		return kit.List(c, list, meta)
 	}
 }
 
 // CreateConfigHandler creates a new config owned by the current user.
 //
 //	@Summary      Create config
-//	@Description  Create a config owned by the current user
+//	@Description  Create a config owned by the current user. parent_ids layers it on other visible configs (see the resolved endpoint). When kind is set, the resolved data is validated against the schema registered for it.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
@@ -107,31 +134,117 @@ func CreateConfigHandler(client *ent.Client) fiber.Handler {
 		if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Name) == "" {
 			return kit.BadRequest("name required", nil)
 		}
+		req.Kind = strings.TrimSpace(req.Kind)
+		if req.Kind != "" && !schema.KindPattern.MatchString(req.Kind) {
+			return kit.BadRequest("kind must look like name/v1", req.Kind)
+		}
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 
-		created, err := client.ConfigItem.Create().SetName(req.Name).SetData(req.Data).SetOwnerID(uid).Save(ctx)
+		if err := checkParents(ctx, client, uid, uuid.Nil, req.ParentIDs); err != nil {
+			return err
+		}
+		id := uuid.New()
+		if err := checkLayers(ctx, client, &ent.ConfigItem{ID: id, Kind: req.Kind, ParentIds: req.ParentIDs, Data: req.Data}); err != nil {
+			return err
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		created, err := tx.ConfigItem.Create().
+			SetID(id).
+			SetName(req.Name).
+			SetKind(req.Kind).
+			SetParentIds(req.ParentIDs).
+			SetData(req.Data).
+			SetRevision(1).
+			SetOwnerID(uid).
+			Save(ctx)
 		if err != nil {
 			return kit.InternalError("create config failed", err.Error())
 		}
+		if _, err := tx.ConfigRevision.Create().
+			SetConfigID(created.ID).
+			SetAuthorID(uid).
+			SetRevision(1).
+			SetData(created.Data).
+			SetMessage(req.Message).
+			Save(ctx); err != nil {
+			return kit.InternalError("create revision failed", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
+		kit.SetETag(c, kit.VersionETag(created.Revision))
 		return kit.Created(c, created)
 	}
 }
 
-// UpdateConfigHandler updates a config owned by the current user.
+// GetConfigHandler returns a single config visible to the current user.
+//
+//	@Summary      Get config
+//	@Description  Get a config (owner or shared). The ETag header carries the head revision.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Config UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id} [get]
+func GetConfigHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+		kit.SetETag(c, kit.VersionETag(cfg.Revision))
+		return kit.OK(c, cfg)
+	}
+}
+
+// UpdateConfigHandler updates a config owned by or shared for editing with the current user.
 //
 //	@Summary      Update config
-//	@Description  Update a config (owner only)
+//	@Description  Update a config (owner or users it is shared with for editing). Data is validated against the schema of the config's kind; an empty kind removes validation.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
-//	@Param        id    path  string                    true  "Config UUID"
-//	@Param        body  body  configs.UpdateConfigRequest  true  "config payload"
+//	@Param        id        path    string                       true   "Config UUID"
+//	@Param        If-Match  header  string                       false  "expected ETag"
+//	@Param        body      body    configs.UpdateConfigRequest  true   "config payload"
 //	@Success      200   {object}  map[string]interface{}
 //	@Failure      400   {object}  map[string]interface{}
 //	@Failure      401   {object}  map[string]interface{}
 //	@Failure      403   {object}  map[string]interface{}
 //	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
+//	@Failure      412   {object}  map[string]interface{}
 //	@Router       /api/v1/configs/{id} [put]
 func UpdateConfigHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -139,7 +252,7 @@ func UpdateConfigHandler(client *ent.Client) fiber.Handler {
 		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
 			return fiber.ErrUnauthorized
 		}
-		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
 		if err != nil {
 			return fiber.ErrUnauthorized
 		}
@@ -152,30 +265,51 @@ func UpdateConfigHandler(client *ent.Client) fiber.Handler {
 		if err := c.BodyParser(&req); err != nil {
 			return kit.BadRequest("invalid request body", nil)
 		}
+		if req.Kind != nil {
+			k := strings.TrimSpace(*req.Kind)
+			if k != "" && !schema.KindPattern.MatchString(k) {
+				return kit.BadRequest("kind must look like name/v1", k)
+			}
+			req.Kind = &k
+		}
 
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != ownerID {
+		if perm < permEdit {
 			return fiber.ErrForbidden
 		}
+		if err := kit.CheckIfMatch(c, kit.VersionETag(cfg.Revision)); err != nil {
+			return err
+		}
 
-		upd := client.ConfigItem.UpdateOneID(cfgID)
+		ch := configChange{Message: req.Message, Guarded: kit.HasIfMatch(c)}
 		if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
-			upd = upd.SetName(*req.Name)
+			ch.Name = req.Name
+		}
+		ch.Kind = req.Kind
+		if req.ParentIDs != nil {
+			if err := checkParents(ctx, client, uid, cfgID, *req.ParentIDs); err != nil {
+				return err
+			}
+			ch.Parents = req.ParentIDs
 		}
 		if req.Data != nil {
-			upd = upd.SetData(*req.Data)
+			ch.Data = *req.Data
+			if ch.Data == nil {
+				ch.Data = map[string]any{}
+			}
 		}
 
-		updated, err := upd.Save(ctx)
+		updated, err := commitChange(ctx, client, cfg, uid, ch)
 		if err != nil {
-			return kit.InternalError("update config failed", err.Error())
+			return err
 		}
+		kit.SetETag(c, kit.VersionETag(updated.Revision))
 		return kit.OK(c, updated)
 	}
 }
@@ -183,15 +317,17 @@ func UpdateConfigHandler(client *ent.Client) fiber.Handler {
 // DeleteConfigHandler deletes a config owned by the current user.
 //
 //	@Summary      Delete config
-//	@Description  Delete a config (owner only)
+//	@Description  Delete a config (owner only). Forks and configs layered on it keep their data and are detached from it.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
-//	@Param        id   path  string  true  "Config UUID"
+//	@Param        id        path    string  true   "Config UUID"
+//	@Param        If-Match  header  string  false  "expected ETag"
 //	@Success      200  {object}  map[string]string
 //	@Failure      401  {object}  map[string]interface{}
 //	@Failure      403  {object}  map[string]interface{}
 //	@Failure      404  {object}  map[string]interface{}
+//	@Failure      412  {object}  map[string]interface{}
 //	@Router       /api/v1/configs/{id} [delete]
 func DeleteConfigHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -199,7 +335,7 @@ func DeleteConfigHandler(client *ent.Client) fiber.Handler {
 		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
 			return fiber.ErrUnauthorized
 		}
-		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
 		if err != nil {
 			return fiber.ErrUnauthorized
 		}
@@ -209,16 +345,90 @@ func DeleteConfigHandler(client *ent.Client) fiber.Handler {
 		}
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		// Shares never allow deleting, not even manage
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != ownerID {
+		if perm < permOwner {
 			return fiber.ErrForbidden
 		}
-		if err := client.ConfigItem.DeleteOneID(cfgID).Exec(ctx); err != nil {
+		if err := kit.CheckIfMatch(c, kit.VersionETag(cfg.Revision)); err != nil {
+			return err
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		// Delete revision history first (cascade delete)
+		if _, err := tx.ConfigRevision.Delete().Where(configrevision.ConfigIDEQ(cfgID)).Exec(ctx); err != nil {
+			return kit.InternalError("delete revisions failed", err.Error())
+		}
+		// Forks keep their data but lose the upstream link
+		if _, err := tx.ConfigItem.Update().
+			Where(configitem.ForkedFromIDEQ(cfgID)).
+			ClearForkedFromID().
+			ClearForkedFromRevision().
+			Save(ctx); err != nil {
+			return kit.InternalError("detach forks failed", err.Error())
+		}
+		// Layered configs keep their own data but no longer resolve through it
+		children, err := tx.ConfigItem.Query().
+			Where(func(s *sql.Selector) {
+				s.Where(sqljson.ValueContains(configitem.FieldParentIds, cfgID.String()))
+			}).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query layered configs failed", err.Error())
+		}
+		for _, child := range children {
+			parents := make([]uuid.UUID, 0, len(child.ParentIds))
+			for _, id := range child.ParentIds {
+				if id != cfgID {
+					parents = append(parents, id)
+				}
+			}
+			n, err := tx.ConfigItem.Update().
+				Where(configitem.IDEQ(child.ID), configitem.RevisionEQ(child.Revision)).
+				SetParentIds(parents).
+				SetRevision(child.Revision + 1).
+				Save(ctx)
+			if err != nil {
+				return kit.InternalError("detach layered configs failed", err.Error())
+			}
+			if n == 0 {
+				return kit.Conflict("layered config was modified concurrently", fiber.Map{"config_id": child.ID})
+			}
+			if err := tx.ConfigRevision.Create().
+				SetConfigID(child.ID).
+				SetAuthorID(uid).
+				SetRevision(child.Revision + 1).
+				SetData(child.Data).
+				SetMessage(fmt.Sprintf("parent %s deleted", cfgID)).
+				Exec(ctx); err != nil {
+				return kit.InternalError("create revision failed", err.Error())
+			}
+		}
+		del := tx.ConfigItem.Delete().Where(configitem.IDEQ(cfgID))
+		if kit.HasIfMatch(c) {
+			del = del.Where(configitem.RevisionEQ(cfg.Revision))
+		}
+		n, err := del.Exec(ctx)
+		if err != nil {
 			return kit.InternalError("delete failed", err.Error())
 		}
+		if n == 0 {
+			if kit.HasIfMatch(c) {
+				return kit.PreconditionFailed("resource has been modified", fiber.Map{"etag": kit.VersionETag(cfg.Revision)})
+			}
+			return kit.NotFound("config not found")
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
 		return kit.OK(c, fiber.Map{"status": "ok"})
 	}
 }
@@ -226,15 +436,16 @@ func DeleteConfigHandler(client *ent.Client) fiber.Handler {
 // ShareToGroupsHandler shares a config to given groups.
 //
 //	@Summary      Share to groups
-//	@Description  Share a config to specified groups (owner only)
+//	@Description  Share a config to specified groups (owner or managers). Sharing again updates the permission, view by default. If-Match is not evaluated, as shares are not versioned.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
 //	@Param        id     path  string                    true  "Config UUID"
-//	@Param        body   body  configs.ShareToGroupsRequest  true  "group ids"
+//	@Param        body   body  configs.ShareToGroupsRequest  true  "group ids and permission"
 //	@Success      200    {object}  map[string]string
 //	@Failure      400    {object}  map[string]interface{}
 //	@Failure      401    {object}  map[string]interface{}
+//	@Failure      403    {object}  map[string]interface{}
 //	@Failure      404    {object}  map[string]interface{}
 //	@Router       /api/v1/configs/{id}/share/groups [post]
 func ShareToGroupsHandler(client *ent.Client) fiber.Handler {
@@ -257,21 +468,80 @@ func ShareToGroupsHandler(client *ent.Client) fiber.Handler {
 		if err := c.BodyParser(&req); err != nil || len(req.GroupIDs) == 0 {
 			return kit.BadRequest("group_ids required", nil)
 		}
+		perm := groupshare.PermissionView
+		if req.Permission != "" {
+			perm = groupshare.Permission(req.Permission)
+			if err := groupshare.PermissionValidator(perm); err != nil {
+				return kit.BadRequest("invalid permission", req.Permission)
+			}
+		}
+		var gids []uuid.UUID
+		seen := map[uuid.UUID]bool{}
+		for _, id := range req.GroupIDs {
+			if !seen[id] {
+				seen[id] = true
+				gids = append(gids, id)
+			}
+		}
 
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		_, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != uid {
+		if access < permManage {
 			return fiber.ErrForbidden
 		}
+		n, err := client.Group.Query().Where(group.IDIn(gids...)).Count(ctx)
+		if err != nil {
+			return kit.InternalError("query groups failed", err.Error())
+		}
+		if n != len(gids) {
+			return kit.NotFound("group not found")
+		}
 
-		upd := client.ConfigItem.UpdateOneID(cfgID).AddSharedGroupIDs(req.GroupIDs...)
-		if err := upd.Exec(ctx); err != nil {
-			return kit.InternalError("share failed", err.Error())
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+		existing, err := tx.GroupShare.Query().
+			Where(groupshare.ConfigItemIDEQ(cfgID), groupshare.GroupIDIn(gids...)).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query shares failed", err.Error())
+		}
+		shared := make(map[uuid.UUID]bool, len(existing))
+		for _, gs := range existing {
+			shared[gs.GroupID] = true
+		}
+		var creates []*ent.GroupShareCreate
+		for _, gid := range gids {
+			if !shared[gid] {
+				creates = append(creates, tx.GroupShare.Create().SetConfigItemID(cfgID).SetGroupID(gid).SetPermission(perm))
+			}
+		}
+		if len(existing) > 0 {
+			err = tx.GroupShare.Update().
+				Where(groupshare.ConfigItemIDEQ(cfgID), groupshare.GroupIDIn(gids...)).
+				SetPermission(perm).
+				Exec(ctx)
+			if err != nil {
+				return kit.InternalError("share failed", err.Error())
+			}
+		}
+		if len(creates) > 0 {
+			if err := tx.GroupShare.CreateBulk(creates...).Exec(ctx); err != nil {
+				if ent.IsConstraintError(err) {
+					return kit.Conflict("config is being shared concurrently", nil)
+				}
+				return kit.InternalError("share failed", err.Error())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
 		}
 		return kit.OK(c, fiber.Map{"status": "ok"})
 	}
@@ -280,7 +550,7 @@ func ShareToGroupsHandler(client *ent.Client) fiber.Handler {
 // UnshareFromGroupsHandler removes sharing of a config from specified groups.
 //
 //	@Summary      Unshare from groups
-//	@Description  Remove group sharing (owner only)
+//	@Description  Remove group sharing (owner or managers). If-Match is not evaluated, as shares are not versioned.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
@@ -312,29 +582,33 @@ func UnshareFromGroupsHandler(client *ent.Client) fiber.Handler {
 		}
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		_, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != uid {
+		if access < permManage {
 			return fiber.ErrForbidden
 		}
-		if err := client.ConfigItem.UpdateOneID(cfgID).RemoveSharedGroupIDs(req.GroupIDs...).Exec(ctx); err != nil {
+		_, err = client.GroupShare.Delete().
+			Where(groupshare.ConfigItemIDEQ(cfgID), groupshare.GroupIDIn(req.GroupIDs...)).
+			Exec(ctx)
+		if err != nil {
 			return kit.InternalError("unshare failed", err.Error())
 		}
 		return kit.OK(c, fiber.Map{"status": "ok"})
 	}
 }
 
-// ShareToUserHandler shares a config to a single user by creating/finding a 2-person group.
+// ShareToUserHandler shares a config directly with a single user.
 //
 //	@Summary      Share to user
-//	@Description  Share a config to a user (creates a 2-person group if needed)
+//	@Description  Share a config with a user (owner or managers). Sharing again updates the permission. If-Match is not evaluated, as shares are not versioned.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
-//	@Param        id        path  string  true  "Config UUID"
-//	@Param        user_id   path  string  true  "Target User UUID"
+//	@Param        id        path  string                   true   "Config UUID"
+//	@Param        user_id   path  string                   true   "Target User UUID"
+//	@Param        payload   body  ShareToUserRequest       false  "Permission, view by default"
 //	@Success      200       {object}  map[string]interface{}
 //	@Failure      400       {object}  map[string]interface{}
 //	@Failure      401       {object}  map[string]interface{}
@@ -347,7 +621,7 @@ func ShareToUserHandler(client *ent.Client) fiber.Handler {
 		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
 			return fiber.ErrUnauthorized
 		}
-		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
 		if err != nil {
 			return fiber.ErrUnauthorized
 		}
@@ -359,70 +633,55 @@ func ShareToUserHandler(client *ent.Client) fiber.Handler {
 		if err != nil {
 			return kit.BadRequest("invalid user id", c.Params("user_id"))
 		}
+		var req ShareToUserRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return kit.BadRequest("invalid request body", nil)
+			}
+		}
+		perm := configshare.PermissionView
+		if req.Permission != "" {
+			perm = configshare.Permission(req.Permission)
+			if err := configshare.PermissionValidator(perm); err != nil {
+				return kit.BadRequest("invalid permission", req.Permission)
+			}
+		}
 
-		if targetID == ownerID {
+		if targetID == uid {
 			return kit.BadRequest("cannot share to self", nil)
 		}
 
 		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 		defer cancel()
 
-		// Ensure owner has this config
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		cfg, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != ownerID {
+		if access < permManage {
 			return fiber.ErrForbidden
 		}
-
-		// Try find an existing group that has exactly two members: owner and target.
-		cand, err := client.Group.Query().
-			Where(group.HasMembersWith(user.IDIn(ownerID, targetID))).
-			All(ctx)
-		if err != nil {
-			return kit.InternalError("query groups failed", err.Error())
-		}
-		var dm *ent.Group
-		for _, g := range cand {
-			// fetch members count and ids
-			members, err := g.QueryMembers().All(ctx)
-			if err != nil {
-				continue
-			}
-			if len(members) == 2 {
-				ids := map[uuid.UUID]bool{members[0].ID: true, members[1].ID: true}
-				if ids[ownerID] && ids[targetID] {
-					dm = g
-					break
-				}
-			}
+		if targetID == cfg.Edges.Owner.ID {
+			return kit.BadRequest("cannot share to the config owner", nil)
 		}
-		if dm == nil {
-			// create a new 2-person group
-			name := "dm:" + ownerID.String() + ":" + targetID.String()
-			var err error
-			dm, err = client.Group.Create().SetName(name).Save(ctx)
-			if err != nil {
-				return kit.InternalError("create group failed", err.Error())
-			}
-			if err := client.Group.UpdateOne(dm).AddMemberIDs(ownerID, targetID).Exec(ctx); err != nil {
-				return kit.InternalError("add members failed", err.Error())
-			}
+		if ok, err := client.User.Query().Where(user.IDEQ(targetID)).Exist(ctx); err != nil {
+			return kit.InternalError("query user failed", err.Error())
+		} else if !ok {
+			return kit.NotFound("user not found")
 		}
 
-		// Share config to the group
-		if err := client.ConfigItem.UpdateOneID(cfgID).AddSharedGroupIDs(dm.ID).Exec(ctx); err != nil {
+		share, err := shareWithUser(ctx, client, cfgID, targetID, perm)
+		if err != nil {
 			return kit.InternalError("share failed", err.Error())
 		}
-		return kit.OK(c, fiber.Map{"status": "ok", "group_id": dm.ID})
+		return kit.OK(c, share)
 	}
 }
 
-// UnshareFromUserHandler removes sharing of a config from a 2-person group with the target user (if exists).
+// UnshareFromUserHandler removes the direct share of a config with the target user.
 //
 //	@Summary      Unshare from user
-//	@Description  Remove sharing from the DM group (owner only)
+//	@Description  Remove a direct share (owner or managers). If-Match is not evaluated, as shares are not versioned.
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
@@ -440,7 +699,7 @@ func UnshareFromUserHandler(client *ent.Client) fiber.Handler {
 		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
 			return fiber.ErrUnauthorized
 		}
-		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
 		if err != nil {
 			return fiber.ErrUnauthorized
 		}
@@ -453,53 +712,34 @@ func UnshareFromUserHandler(client *ent.Client) fiber.Handler {
 			return kit.BadRequest("invalid user id", c.Params("user_id"))
 		}
 
-		if targetID == ownerID {
-			return kit.BadRequest("cannot unshare from self", nil)
-		}
-
 		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
 		defer cancel()
 
-		cfg, err := client.ConfigItem.Query().Where(configitem.IDEQ(cfgID)).WithOwner().Only(ctx)
-		if err != nil || cfg.Edges.Owner == nil {
-			return kit.NotFound("config not found")
+		_, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
 		}
-		if cfg.Edges.Owner.ID != ownerID {
+		if access < permManage {
 			return fiber.ErrForbidden
 		}
 
-		cand, err := client.Group.Query().Where(group.HasMembersWith(user.IDIn(ownerID, targetID))).All(ctx)
+		n, err := client.ConfigShare.Delete().
+			Where(configshare.ConfigIDEQ(cfgID), configshare.UserIDEQ(targetID)).
+			Exec(ctx)
 		if err != nil {
-			return kit.InternalError("query groups failed", err.Error())
-		}
-		var dm *ent.Group
-		for _, g := range cand {
-			members, err := g.QueryMembers().All(ctx)
-			if err != nil {
-				continue
-			}
-			if len(members) == 2 {
-				ids := map[uuid.UUID]bool{members[0].ID: true, members[1].ID: true}
-				if ids[ownerID] && ids[targetID] {
-					dm = g
-					break
-				}
-			}
-		}
-		if dm == nil {
-			return kit.NotFound("dm group not found")
-		}
-		if err := client.ConfigItem.UpdateOneID(cfgID).RemoveSharedGroupIDs(dm.ID).Exec(ctx); err != nil {
 			return kit.InternalError("unshare failed", err.Error())
 		}
-		return kit.OK(c, fiber.Map{"status": "ok", "group_id": dm.ID})
+		if n == 0 {
+			return kit.NotFound("share not found")
+		}
+		return kit.OK(c, fiber.Map{"status": "ok"})
 	}
 }
 
-// VisibleConfigsHandler lists configs the current user can see (owned or shared via groups).
+// VisibleConfigsHandler lists configs the current user can see (owned, shared via groups or shared directly).
 //
 //	@Summary      List visible configs
-//	@Description  Configs owned by me or shared to my groups
+//	@Description  Configs owned by me, shared to my groups or shared with me, each with my effective permission (view, edit, manage or owner)
 //	@Tags         configs
 //	@Accept       json
 //	@Produce      json
@@ -524,25 +764,29 @@ func VisibleConfigsHandler(client *ent.Client) fiber.Handler {
 		if err != nil {
 			return err
 		}
-		// gather group IDs where current user is a member
-		gids, err := client.Group.Query().Where(group.HasMembersWith(user.IDEQ(uid))).IDs(ctx)
-		if err != nil {
-			return kit.InternalError("query groups failed", err.Error())
-		}
 		q := client.ConfigItem.Query().
-			Where(
-				configitem.Or(
-					configitem.HasOwnerWith(user.IDEQ(uid)),
-					configitem.HasSharedGroupsWith(group.IDIn(gids...)),
-				),
-			).
+			Where(configx.VisibleTo(uid)).
 			Order(ent.Desc(configitem.FieldUpdatedAt))
 		items, err := q.Limit(pg.Limit).Offset(pg.Offset).All(ctx)
 		if err != nil {
 			return kit.InternalError("query configs failed", err.Error())
 		}
+		list, err := withPermissions(ctx, client, uid, items)
+		if err != nil {
+			return kit.InternalError("query config access failed", err.Error())
+		}
 		nextOff := pg.Offset + len(items)
 		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
-		return kit.List(c, items, meta)
+		kit.SetETag(c, listETag(list))
+		return kit.List(c, list, meta)
+	}
+}
+
+// listETag derives a list ETag from the id, head revision and caller permission of every item on the page.
+func listETag(items []ConfigListItem) string {
+	parts := make([]string, 0, len(items))
+	for _, it := range items {
+		parts = append(parts, it.ID.String()+":"+strconv.Itoa(it.Revision)+":"+it.Permission)
 	}
+	return kit.HashETag(parts...)
 }
@@ -17,13 +17,16 @@ import (
 	_ "modernc.org/sqlite"
 
 	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/groupshare"
 	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
 	"fiber-ent-apollo-pg/internal/httpx/mw"
 )
 
 func newTestClient(t *testing.T) *ent.Client {
 	t.Helper()
-	dsn := "file:ent?mode=memory&cache=shared&_fk=1"
+	// One database per test, so repeated runs do not see each other's rows
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared&_fk=1"
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		t.Fatalf("open db: %v", err)
@@ -31,6 +34,7 @@ func newTestClient(t *testing.T) *ent.Client {
 	_, _ = db.Exec("PRAGMA foreign_keys = ON")
 	drv := entsql.OpenDB(dialect.SQLite, db)
 	client := ent.NewClient(ent.Driver(drv))
+	t.Cleanup(func() { _ = client.Close() })
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := client.Schema.Create(ctx); err != nil {
@@ -168,3 +172,182 @@ func TestConfig_Create_List_Share_Unshare(t *testing.T) {
 		t.Fatalf("target visible post-unshare: %d", len(envVis.Data))
 	}
 }
+
+func TestConfig_ShareToUser_Permission(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("PermOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	target, err := client.User.Create().SetDisplayName("PermTarget").Save(ctx)
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), ShareToUserHandler(client))
+		app.Post("/configs/:id/unshare/user/:user_id", mw.RequireUser(), UnshareFromUserHandler(client))
+	}
+	appOwner := testutil.NewApp(asUser(owner.ID), routes)
+	appTarget := testutil.NewApp(asUser(target.ID), routes)
+
+	var created struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	doJSON(t, appOwner, http.MethodPost, "/configs", map[string]any{"name": "PermCfg", "data": map[string]any{"v": 1}}, &created)
+	base := "/configs/" + created.Data.ID.String()
+	share := base + "/share/user/" + target.ID.String()
+	update := map[string]any{"data": map[string]any{"v": 2}}
+
+	if res := doJSON(t, appOwner, http.MethodPost, share, map[string]any{"permission": "admin"}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid permission: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appOwner, http.MethodPost, base+"/share/user/"+uuid.NewString(), nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown user: status=%d", res.StatusCode)
+	}
+
+	// View by default: the target cannot edit
+	var got struct {
+		Data struct {
+			Permission string `json:"permission"`
+		}
+	}
+	if res := doJSON(t, appOwner, http.MethodPost, share, nil, &got); res.StatusCode != http.StatusOK || got.Data.Permission != "view" {
+		t.Fatalf("share view: status=%d permission=%q", res.StatusCode, got.Data.Permission)
+	}
+	if res := doJSON(t, appTarget, http.MethodPut, base, update, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("viewer update: status=%d", res.StatusCode)
+	}
+
+	// Sharing again upgrades the existing share
+	if res := doJSON(t, appOwner, http.MethodPost, share, map[string]any{"permission": "edit"}, &got); res.StatusCode != http.StatusOK || got.Data.Permission != "edit" {
+		t.Fatalf("share edit: status=%d permission=%q", res.StatusCode, got.Data.Permission)
+	}
+	if n := client.ConfigShare.Query().Where(configshare.ConfigIDEQ(created.Data.ID)).CountX(ctx); n != 1 {
+		t.Fatalf("shares: %d", n)
+	}
+	if res := doJSON(t, appTarget, http.MethodPut, base, update, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("editor update: status=%d", res.StatusCode)
+	}
+
+	unshare := base + "/unshare/user/" + target.ID.String()
+	if res := doJSON(t, appOwner, http.MethodPost, unshare, nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("unshare: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appOwner, http.MethodPost, unshare, nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unshare again: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appTarget, http.MethodPut, base, update, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("update after unshare: status=%d", res.StatusCode)
+	}
+}
+
+func TestConfig_SharePermissionLevels(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner := client.User.Create().SetDisplayName("LvlOwner").SaveX(ctx)
+	manager := client.User.Create().SetDisplayName("LvlManager").SaveX(ctx)
+	member := client.User.Create().SetDisplayName("LvlMember").SaveX(ctx)
+	other := client.User.Create().SetDisplayName("LvlOther").SaveX(ctx)
+	team := client.Group.Create().SetName("LvlTeam").SaveX(ctx)
+	client.GroupMembership.Create().SetGroupID(team.ID).SetUserID(member.ID).ExecX(ctx)
+
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Get("/configs/visible", mw.RequireUser(), VisibleConfigsHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Delete("/configs/:id", mw.RequireUser(), DeleteConfigHandler(client))
+		app.Post("/configs/:id/share/groups", mw.RequireUser(), ShareToGroupsHandler(client))
+		app.Post("/configs/:id/unshare/groups", mw.RequireUser(), UnshareFromGroupsHandler(client))
+		app.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), ShareToUserHandler(client))
+	}
+	appOwner := testutil.NewApp(asUser(owner.ID), routes)
+	appManager := testutil.NewApp(asUser(manager.ID), routes)
+	appMember := testutil.NewApp(asUser(member.ID), routes)
+
+	var created struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	doJSON(t, appOwner, http.MethodPost, "/configs", map[string]any{"name": "LvlCfg", "data": map[string]any{"v": 1}}, &created)
+	base := "/configs/" + created.Data.ID.String()
+	update := map[string]any{"data": map[string]any{"v": 2}}
+	visible := func(app *fiber.App) string {
+		t.Helper()
+		var out struct {
+			Data []struct {
+				ID         uuid.UUID `json:"id"`
+				Permission string    `json:"permission"`
+			}
+		}
+		doJSON(t, app, http.MethodGet, "/configs/visible", nil, &out)
+		for _, it := range out.Data {
+			if it.ID == created.Data.ID {
+				return it.Permission
+			}
+		}
+		return ""
+	}
+
+	// Managers can share further but not delete
+	doJSON(t, appOwner, http.MethodPost, base+"/share/user/"+manager.ID.String(), map[string]any{"permission": "manage"}, nil)
+	if got := visible(appManager); got != "manage" {
+		t.Fatalf("manager permission: %q", got)
+	}
+	if got := visible(appOwner); got != "owner" {
+		t.Fatalf("owner permission: %q", got)
+	}
+	if res := doJSON(t, appManager, http.MethodPost, base+"/share/groups", map[string]any{"group_ids": []uuid.UUID{uuid.New()}}, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("share to unknown group: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appManager, http.MethodPost, base+"/share/groups", map[string]any{"group_ids": []uuid.UUID{team.ID}}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("manager share to group: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appManager, http.MethodPost, base+"/share/user/"+owner.ID.String(), nil, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("share to owner: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appManager, http.MethodDelete, base, nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("manager delete: status=%d", res.StatusCode)
+	}
+
+	// Group members get the group share's permission
+	if got := visible(appMember); got != "view" {
+		t.Fatalf("member permission: %q", got)
+	}
+	if res := doJSON(t, appMember, http.MethodPut, base, update, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("group viewer update: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appMember, http.MethodPost, base+"/share/user/"+other.ID.String(), nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("group viewer share: status=%d", res.StatusCode)
+	}
+	doJSON(t, appOwner, http.MethodPost, base+"/share/groups", map[string]any{"group_ids": []uuid.UUID{team.ID}, "permission": "edit"}, nil)
+	if got := visible(appMember); got != "edit" {
+		t.Fatalf("member permission after upgrade: %q", got)
+	}
+	if res := doJSON(t, appMember, http.MethodPut, base, update, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("group editor update: status=%d", res.StatusCode)
+	}
+
+	// The highest of direct and group shares applies
+	doJSON(t, appOwner, http.MethodPost, base+"/share/user/"+member.ID.String(), map[string]any{"permission": "manage"}, nil)
+	if got := visible(appMember); got != "manage" {
+		t.Fatalf("member permission with direct share: %q", got)
+	}
+
+	if res := doJSON(t, appManager, http.MethodPost, base+"/unshare/groups", map[string]any{"group_ids": []uuid.UUID{team.ID}}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("manager unshare group: status=%d", res.StatusCode)
+	}
+	if n := client.GroupShare.Query().Where(groupshare.ConfigItemIDEQ(created.Data.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("group shares: %d", n)
+	}
+}
@@ -0,0 +1,109 @@
+package configs
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/configx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// checkParents verifies that every parent exists, is visible to the caller and
+// is listed once. cfgID is the config being edited (uuid.Nil on create).
+func checkParents(ctx context.Context, client *ent.Client, uid, cfgID uuid.UUID, parents []uuid.UUID) error {
+	seen := make(map[uuid.UUID]bool, len(parents))
+	for _, pid := range parents {
+		if pid == cfgID {
+			return kit.BadRequest("config cannot be its own parent", pid)
+		}
+		if seen[pid] {
+			return kit.BadRequest("duplicate parent config", pid)
+		}
+		seen[pid] = true
+		_, perm, err := loadConfig(ctx, client, pid, uid)
+		if err != nil || perm < permView {
+			return kit.BadRequest("parent config not found or not visible", pid)
+		}
+	}
+	return nil
+}
+
+// checkLayers resolves cfg's parent chain, which rejects cycles, and validates the
+// resolved data against cfg's kind. cfg may hold unsaved changes.
+func checkLayers(ctx context.Context, client *ent.Client, cfg *ent.ConfigItem) error {
+	data := cfg.Data
+	if len(cfg.ParentIds) > 0 {
+		res, err := configx.Resolve(ctx, cfg, func(ctx context.Context, id uuid.UUID) (*ent.ConfigItem, error) {
+			return client.ConfigItem.Get(ctx, id)
+		})
+		if err != nil {
+			return kit.ResolveError(err)
+		}
+		data = res.Data
+	}
+	return validateData(ctx, client, cfg.Kind, data)
+}
+
+// ResolvedConfigHandler returns a config's data deep-merged over its parent chain.
+//
+//	@Summary      Resolve config
+//	@Description  Deep-merge the parent chain of a config (owner or shared). Later parents override earlier ones and the config's own data wins; objects merge, arrays replace and null deletes a key. sources maps each leaf pointer to the config that supplied it.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Config UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "parents form a cycle"
+//	@Failure      422  {object}  map[string]interface{}  "parent missing or not visible"
+//	@Router       /api/v1/configs/{id}/resolved [get]
+func ResolvedConfigHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		// Every layer must be visible to the caller, not just the config itself
+		res, err := configx.Resolve(ctx, cfg, configx.VisibleLoader(client, uid))
+		if err != nil {
+			return kit.ResolveError(err)
+		}
+
+		parts := make([]string, 0, len(res.Layers))
+		for _, l := range res.Layers {
+			parts = append(parts, l.ConfigID.String()+":"+strconv.Itoa(l.Revision))
+		}
+		kit.SetETag(c, kit.HashETag(parts...))
+		return kit.OK(c, res)
+	}
+}
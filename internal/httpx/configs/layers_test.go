@@ -0,0 +1,128 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_Resolved(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("LayerOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	stranger, err := client.User.Create().SetDisplayName("LayerStranger").Save(ctx)
+	if err != nil {
+		t.Fatalf("create stranger: %v", err)
+	}
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Get("/configs/:id/resolved", mw.RequireUser(), ResolvedConfigHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	appStranger := testutil.NewApp(asUser(stranger.ID), routes)
+
+	create := func(a *fiber.App, body map[string]any) (uuid.UUID, int) {
+		var env struct {
+			Data struct {
+				ID uuid.UUID `json:"id"`
+			}
+		}
+		res := doJSON(t, a, http.MethodPost, "/configs", body, &env)
+		return env.Data.ID, res.StatusCode
+	}
+	base, _ := create(app, map[string]any{"name": "Base", "data": map[string]any{"format": "png", "scale": 1, "naming": map[string]any{"case": "kebab", "prefix": "ic"}}})
+	team, _ := create(app, map[string]any{"name": "Team", "parent_ids": []uuid.UUID{base}, "data": map[string]any{"scale": 2}})
+	leaf, status := create(app, map[string]any{"name": "Leaf", "parent_ids": []uuid.UUID{team}, "data": map[string]any{"naming": map[string]any{"prefix": nil}}})
+	if status != http.StatusCreated {
+		t.Fatalf("create leaf: status=%d", status)
+	}
+
+	var resolved struct {
+		Data struct {
+			Data   map[string]any `json:"data"`
+			Layers []struct {
+				ConfigID uuid.UUID `json:"config_id"`
+			} `json:"layers"`
+			Sources map[string]uuid.UUID `json:"sources"`
+		}
+	}
+	res := doJSON(t, app, http.MethodGet, "/configs/"+leaf.String()+"/resolved", nil, &resolved)
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == "" {
+		t.Fatalf("resolved: status=%d", res.StatusCode)
+	}
+	d := resolved.Data
+	if len(d.Layers) != 3 || d.Layers[0].ConfigID != base || d.Layers[2].ConfigID != leaf {
+		t.Fatalf("unexpected layers: %+v", d.Layers)
+	}
+	naming, _ := d.Data["naming"].(map[string]any)
+	if d.Data["scale"] != float64(2) || d.Data["format"] != "png" || naming["case"] != "kebab" || naming["prefix"] != nil {
+		t.Fatalf("unexpected data: %v", d.Data)
+	}
+	if d.Sources["/scale"] != team || d.Sources["/format"] != base || d.Sources["/naming/case"] != base {
+		t.Fatalf("unexpected sources: %v", d.Sources)
+	}
+
+	// Closing the loop base -> leaf is rejected
+	if res := doJSON(t, app, http.MethodPut, "/configs/"+base.String(), map[string]any{"parent_ids": []uuid.UUID{leaf}}, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("cycle: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPut, "/configs/"+base.String(), map[string]any{"parent_ids": []uuid.UUID{base}}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("self parent: status=%d", res.StatusCode)
+	}
+	// Parents must be visible to the caller
+	if _, status := create(appStranger, map[string]any{"name": "Sneaky", "parent_ids": []uuid.UUID{base}, "data": map[string]any{}}); status != http.StatusBadRequest {
+		t.Fatalf("invisible parent: status=%d", status)
+	}
+}
+
+func TestConfig_DeleteParentDetachesLayers(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner := client.User.Create().SetDisplayName("DetachOwner").SaveX(ctx)
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Delete("/configs/:id", mw.RequireUser(), DeleteConfigHandler(client))
+		app.Get("/configs/:id/resolved", mw.RequireUser(), ResolvedConfigHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	create := func(body map[string]any) uuid.UUID {
+		var env struct {
+			Data struct {
+				ID uuid.UUID `json:"id"`
+			}
+		}
+		if res := doJSON(t, app, http.MethodPost, "/configs", body, &env); res.StatusCode != http.StatusCreated {
+			t.Fatalf("create %v: status=%d", body["name"], res.StatusCode)
+		}
+		return env.Data.ID
+	}
+	base := create(map[string]any{"name": "DetachBase", "data": map[string]any{"format": "png"}})
+	keep := create(map[string]any{"name": "DetachKeep", "data": map[string]any{"scale": 1}})
+	child := create(map[string]any{"name": "DetachChild", "parent_ids": []uuid.UUID{base, keep}, "data": map[string]any{"scale": 2}})
+
+	if res := doJSON(t, app, http.MethodDelete, "/configs/"+base.String(), nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("delete parent: status=%d", res.StatusCode)
+	}
+	got := client.ConfigItem.GetX(ctx, child)
+	if len(got.ParentIds) != 1 || got.ParentIds[0] != keep || got.Revision != 2 || got.Data["scale"] != float64(2) {
+		t.Fatalf("child after parent delete: parents=%v revision=%d data=%v", got.ParentIds, got.Revision, got.Data)
+	}
+	if res := doJSON(t, app, http.MethodGet, "/configs/"+child.String()+"/resolved", nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("resolve child: status=%d", res.StatusCode)
+	}
+}
@@ -0,0 +1,117 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/configx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/naming"
+)
+
+// maxPreviewNodes bounds the sample of a naming preview.
+const maxPreviewNodes = 1000
+
+// NamingPreviewRequest is the request body for previewing export file names
+// swagger:model NamingPreviewRequest
+type NamingPreviewRequest struct {
+	Nodes []naming.Node `json:"nodes"`
+	// Naming, when set, replaces the config's naming section to try out
+	// rules before saving them.
+	Naming map[string]any `json:"naming,omitempty"`
+}
+
+// NamingPreview is the response of a naming preview.
+type NamingPreview struct {
+	Options naming.Options `json:"options"`
+	// Format and Scale are the export defaults nodes fall back to.
+	Format string          `json:"format"`
+	Scale  float64         `json:"scale"`
+	Items  []naming.Result `json:"items"`
+}
+
+// NamingPreviewHandler names a sample of nodes with a config's export naming rules.
+//
+//	@Summary      Preview export naming
+//	@Description  Apply the naming rules of a config (owner or shared), resolved over its parents, to a sample node list and return the file path of each node in order. Templates use {page}, {frame}, {component}, {name}, {id}, {variant}, {variant.Prop}, {scale}, {scale_suffix} and {format}, with |kebab, |snake, |camel, |pascal, |constant, |lower, |upper and |flat transforms.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path  string                        true  "Config UUID"
+//	@Param        body  body  configs.NamingPreviewRequest  true  "sample nodes"
+//	@Success      200   {object}  map[string]interface{}
+//	@Failure      400   {object}  map[string]interface{}
+//	@Failure      401   {object}  map[string]interface{}
+//	@Failure      403   {object}  map[string]interface{}
+//	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}  "paths collide with collision set to error"
+//	@Failure      422   {object}  map[string]interface{}  "invalid naming rules or config parents"
+//	@Router       /api/v1/configs/{id}/naming/preview [post]
+func NamingPreviewHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		var req NamingPreviewRequest
+		if err := c.BodyParser(&req); err != nil {
+			return kit.BadRequest("invalid request body", nil)
+		}
+		if len(req.Nodes) == 0 || len(req.Nodes) > maxPreviewNodes {
+			return kit.BadRequest("nodes must hold 1 to 1000 entries", len(req.Nodes))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+		res, err := configx.Resolve(ctx, cfg, configx.VisibleLoader(client, uid))
+		if err != nil {
+			return kit.ResolveError(err)
+		}
+		data := res.Data
+		if req.Naming != nil {
+			data = make(map[string]any, len(res.Data))
+			for k, v := range res.Data {
+				data[k] = v
+			}
+			data[naming.ConfigKey] = req.Naming
+		}
+
+		opts, err := naming.OptionsFromConfig(data)
+		if err != nil {
+			return kit.NewAPIError(http.StatusUnprocessableEntity, "E_INVALID_NAMING", err.Error(), nil)
+		}
+		items, err := naming.Paths(opts, req.Nodes)
+		var ce *naming.ConflictError
+		switch {
+		case errors.As(err, &ce):
+			return kit.Conflict("export paths collide", ce)
+		case err != nil:
+			return kit.BadRequest(err.Error(), nil)
+		}
+		return kit.OK(c, NamingPreview{Options: opts, Format: opts.Format, Scale: opts.Scale, Items: items})
+	}
+}
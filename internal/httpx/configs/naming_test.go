@@ -0,0 +1,99 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_NamingPreview(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("NamingOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	stranger, err := client.User.Create().SetDisplayName("NamingStranger").Save(ctx)
+	if err != nil {
+		t.Fatalf("create stranger: %v", err)
+	}
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Post("/configs/:id/naming/preview", mw.RequireUser(), NamingPreviewHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	appStranger := testutil.NewApp(asUser(stranger.ID), routes)
+
+	create := func(body map[string]any) uuid.UUID {
+		var env struct {
+			Data struct {
+				ID uuid.UUID `json:"id"`
+			}
+		}
+		if res := doJSON(t, app, http.MethodPost, "/configs", body, &env); res.StatusCode != http.StatusCreated {
+			t.Fatalf("create config: status=%d", res.StatusCode)
+		}
+		return env.Data.ID
+	}
+	base := create(map[string]any{"name": "NamingBase", "data": map[string]any{"format": "svg", "naming": map[string]any{"case": "kebab", "prefix": "ic-"}}})
+	leaf := create(map[string]any{"name": "NamingLeaf", "parent_ids": []uuid.UUID{base}, "data": map[string]any{"scale": 2, "naming": map[string]any{"template": "{page}/{component|flat}{scale_suffix}"}}})
+
+	nodes := []map[string]any{
+		{"id": "1:1", "name": "Arrow", "page": "Icons", "component": "Nav/Arrow"},
+		{"id": "1:2", "name": "Arrow", "page": "Icons", "component": "Nav Arrow", "format": "png"},
+		{"id": "1:3", "name": "Arrow", "page": "Icons", "component": "nav-arrow", "scale": 1},
+	}
+	var preview struct {
+		Data struct {
+			Format string `json:"format"`
+			Items  []struct {
+				NodeID  string `json:"node_id"`
+				Path    string `json:"path"`
+				Renamed bool   `json:"renamed"`
+			} `json:"items"`
+		}
+	}
+	res := doJSON(t, app, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", map[string]any{"nodes": nodes}, &preview)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("preview: status=%d", res.StatusCode)
+	}
+	want := []string{"icons/ic-nav-arrow@2x.svg", "icons/ic-nav-arrow@2x.png", "icons/ic-nav-arrow.svg"}
+	if preview.Data.Format != "svg" || len(preview.Data.Items) != len(want) {
+		t.Fatalf("unexpected preview: %+v", preview.Data)
+	}
+	for i, p := range want {
+		if got := preview.Data.Items[i]; got.Path != p || got.NodeID != nodes[i]["id"] {
+			t.Fatalf("item %d = %+v, want %s", i, got, p)
+		}
+	}
+
+	// Unsaved rules replace the config's naming section
+	body := map[string]any{"nodes": nodes, "naming": map[string]any{"template": "{component|flat|constant}", "collision": "suffix"}}
+	res = doJSON(t, app, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", body, &preview)
+	if res.StatusCode != http.StatusOK || preview.Data.Items[2].Path != "NAV_ARROW-2.svg" || !preview.Data.Items[2].Renamed {
+		t.Fatalf("override: status=%d items=%+v", res.StatusCode, preview.Data.Items)
+	}
+	body["naming"] = map[string]any{"template": "{component|flat|constant}", "collision": "error"}
+	if res := doJSON(t, app, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", body, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("collision: status=%d", res.StatusCode)
+	}
+	body["naming"] = map[string]any{"template": "{unknown}"}
+	if res := doJSON(t, app, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", body, nil); res.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("bad template: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", map[string]any{"nodes": []any{}}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("no nodes: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appStranger, http.MethodPost, "/configs/"+leaf.String()+"/naming/preview", map[string]any{"nodes": nodes}, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("stranger: status=%d", res.StatusCode)
+	}
+}
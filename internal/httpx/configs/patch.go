@@ -0,0 +1,142 @@
+package configs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/jsonx"
+)
+
+// Patch media types accepted by PatchConfigHandler.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// PatchConfigHandler applies a JSON Merge Patch or JSON Patch to a config's data.
+//
+//	@Summary      Patch config data
+//	@Description  Apply an RFC 7396 merge patch (application/merge-patch+json) or an RFC 6902 patch (application/json-patch+json) to the config's data (owner or editors). Pointers are relative to data. An optional X-Revision-Message header is stored on the new revision.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id        path    string  true   "Config UUID"
+//	@Param        If-Match  header  string  false  "expected ETag"
+//	@Param        body      body    object  true   "merge patch object or JSON Patch operation array"
+//	@Success      200   {object}  map[string]interface{}
+//	@Failure      400   {object}  map[string]interface{}
+//	@Failure      401   {object}  map[string]interface{}
+//	@Failure      403   {object}  map[string]interface{}
+//	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}  "test op failed"
+//	@Failure      412   {object}  map[string]interface{}
+//	@Failure      415   {object}  map[string]interface{}
+//	@Failure      422   {object}  map[string]interface{}  "op could not be applied"
+//	@Router       /api/v1/configs/{id} [patch]
+func PatchConfigHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+
+		ctype := strings.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0]))
+		if ctype != MergePatchContentType && ctype != JSONPatchContentType {
+			return fiber.NewError(fiber.StatusUnsupportedMediaType, "use "+MergePatchContentType+" or "+JSONPatchContentType)
+		}
+		body := c.Body()
+		if len(body) == 0 {
+			return kit.BadRequest("patch body required", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permEdit {
+			return fiber.ErrForbidden
+		}
+		if err := kit.CheckIfMatch(c, kit.VersionETag(cfg.Revision)); err != nil {
+			return err
+		}
+
+		patched, err := applyDataPatch(cfg.Data, ctype, body)
+		if err != nil {
+			return err
+		}
+
+		msg := strings.TrimSpace(c.Get("X-Revision-Message"))
+		updated, err := commitChange(ctx, client, cfg, uid, configChange{Data: patched, Message: msg, Guarded: kit.HasIfMatch(c)})
+		if err != nil {
+			return err
+		}
+		kit.SetETag(c, kit.VersionETag(updated.Revision))
+		return kit.OK(c, updated)
+	}
+}
+
+// applyDataPatch applies a patch body of the given media type to data and
+// returns the new data, which must still be a JSON object.
+func applyDataPatch(data map[string]any, ctype string, body []byte) (map[string]any, error) {
+	var doc any = map[string]any{}
+	if data != nil {
+		doc = data
+	}
+
+	var out any
+	switch ctype {
+	case MergePatchContentType:
+		var patch any
+		if err := json.Unmarshal(body, &patch); err != nil {
+			return nil, kit.BadRequest("invalid merge patch", err.Error())
+		}
+		out = jsonx.MergePatch(doc, patch)
+	case JSONPatchContentType:
+		ops, err := jsonx.DecodePatch(body)
+		if err != nil {
+			var oe *jsonx.OpError
+			if errors.As(err, &oe) {
+				return nil, kit.BadRequest("invalid json patch", oe)
+			}
+			return nil, kit.BadRequest("invalid json patch", err.Error())
+		}
+		out, err = jsonx.ApplyPatch(doc, ops)
+		if err != nil {
+			var oe *jsonx.OpError
+			if errors.As(err, &oe) && oe.TestFailed {
+				return nil, kit.Conflict("json patch test failed", oe)
+			}
+			if errors.As(err, &oe) {
+				return nil, kit.NewAPIError(http.StatusUnprocessableEntity, "E_PATCH_FAILED", "json patch could not be applied", oe)
+			}
+			return nil, kit.InternalError("apply patch failed", err.Error())
+		}
+	}
+
+	m, ok := out.(map[string]any)
+	if !ok {
+		return nil, kit.NewAPIError(http.StatusUnprocessableEntity, "E_PATCH_FAILED", "patched data must be a JSON object", nil)
+	}
+	return m, nil
+}
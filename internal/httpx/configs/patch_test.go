@@ -0,0 +1,96 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func patchReq(t *testing.T, app *fiber.App, target, ctype, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, target, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", ctype)
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	var env map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&env)
+	return res, env
+}
+
+func TestConfig_Patch_MergeAndJSONPatch(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("PatchOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Patch("/configs/:id", mw.RequireUser(), PatchConfigHandler(client))
+	})
+
+	var created struct{ Data struct{ ID uuid.UUID } }
+	doJSON(t, app, http.MethodPost, "/configs", map[string]any{
+		"name": "PatchCfg",
+		"data": map[string]any{"colors": map[string]any{"primary": "#000", "secondary": "#111"}, "scale": 1},
+	}, &created)
+	target := "/configs/" + created.Data.ID.String()
+
+	// Merge patch: edit one key, delete another, leave the rest
+	res, env := patchReq(t, app, target, MergePatchContentType, `{"colors":{"primary":"#fff","secondary":null}}`)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("merge patch status=%d body=%v", res.StatusCode, env)
+	}
+	data := env["data"].(map[string]any)["data"].(map[string]any)
+	colors := data["colors"].(map[string]any)
+	if colors["primary"] != "#fff" || colors["secondary"] != nil || data["scale"] != float64(1) {
+		t.Fatalf("unexpected merged data: %v", data)
+	}
+
+	// JSON patch guarded by a test op
+	res, env = patchReq(t, app, target, JSONPatchContentType+"; charset=utf-8",
+		`[{"op":"test","path":"/scale","value":1},{"op":"replace","path":"/scale","value":2}]`)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("json patch status=%d body=%v", res.StatusCode, env)
+	}
+	if env["data"].(map[string]any)["revision"] != float64(3) {
+		t.Fatalf("expected revision 3, got %v", env["data"])
+	}
+
+	// Failed test op names the operation
+	res, env = patchReq(t, app, target, JSONPatchContentType,
+		`[{"op":"add","path":"/x","value":1},{"op":"test","path":"/scale","value":1}]`)
+	if res.StatusCode != http.StatusConflict {
+		t.Fatalf("failed test status=%d", res.StatusCode)
+	}
+	details, _ := env["details"].(map[string]any)
+	if details["index"] != float64(1) || details["op"] != "test" || details["path"] != "/scale" {
+		t.Fatalf("unexpected details: %v", env)
+	}
+
+	// Op against a missing path is unprocessable
+	res, env = patchReq(t, app, target, JSONPatchContentType, `[{"op":"remove","path":"/nope"}]`)
+	if res.StatusCode != http.StatusUnprocessableEntity || env["code"] != "E_PATCH_FAILED" {
+		t.Fatalf("missing path status=%d body=%v", res.StatusCode, env)
+	}
+
+	// Plain JSON is not a patch format
+	res, _ = patchReq(t, app, target, "application/json", `{"scale":3}`)
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("unsupported media status=%d", res.StatusCode)
+	}
+}
@@ -0,0 +1,314 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/configrevision"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// RestoreRevisionRequest is the optional request body for restoring a revision
+// swagger:model RestoreRevisionRequest
+type RestoreRevisionRequest struct {
+	Message string `json:"message,omitempty"`
+}
+
+// configChange describes a save applied to a config.
+type configChange struct {
+	Name    *string
+	Kind    *string
+	Parents *[]uuid.UUID
+	Data    map[string]any // nil keeps the current data
+	Message string
+	// Guarded is set when the caller sent If-Match; losing the race then reports 412.
+	Guarded bool
+}
+
+// commitChange applies ch to cfg and records the resulting data as a new head revision,
+// both in one transaction. The resolved result is checked for parent cycles and validated
+// against the config's kind first. The update is guarded by the head revision read into cfg,
+// so a concurrent save surfaces as a conflict instead of an interleaved history.
+func commitChange(ctx context.Context, client *ent.Client, cfg *ent.ConfigItem, authorID uuid.UUID, ch configChange) (*ent.ConfigItem, error) {
+	if ch.Kind != nil || ch.Parents != nil || ch.Data != nil {
+		next := &ent.ConfigItem{ID: cfg.ID, Name: cfg.Name, Revision: cfg.Revision, Kind: cfg.Kind, ParentIds: cfg.ParentIds, Data: cfg.Data}
+		if ch.Kind != nil {
+			next.Kind = *ch.Kind
+		}
+		if ch.Parents != nil {
+			next.ParentIds = *ch.Parents
+		}
+		if ch.Data != nil {
+			next.Data = ch.Data
+		}
+		if err := checkLayers(ctx, client, next); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		return nil, kit.InternalError("begin tx failed", err.Error())
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	next := cfg.Revision + 1
+	upd := tx.ConfigItem.UpdateOneID(cfg.ID).
+		Where(configitem.RevisionEQ(cfg.Revision)).
+		SetRevision(next)
+	if ch.Name != nil {
+		upd = upd.SetName(*ch.Name)
+	}
+	if ch.Kind != nil {
+		upd = upd.SetKind(*ch.Kind)
+	}
+	if ch.Parents != nil {
+		upd = upd.SetParentIds(*ch.Parents)
+	}
+	if ch.Data != nil {
+		upd = upd.SetData(ch.Data)
+	}
+	updated, err := upd.Save(ctx)
+	if ent.IsNotFound(err) {
+		if ch.Guarded {
+			return nil, kit.PreconditionFailed("resource has been modified", fiber.Map{"etag": kit.VersionETag(cfg.Revision)})
+		}
+		return nil, kit.Conflict("config was modified concurrently", fiber.Map{"revision": cfg.Revision})
+	}
+	if err != nil {
+		return nil, kit.InternalError("update config failed", err.Error())
+	}
+
+	if _, err := tx.ConfigRevision.Create().
+		SetConfigID(cfg.ID).
+		SetAuthorID(authorID).
+		SetRevision(next).
+		SetData(updated.Data).
+		SetMessage(ch.Message).
+		Save(ctx); err != nil {
+		if ent.IsConstraintError(err) {
+			return nil, kit.Conflict("config was modified concurrently", fiber.Map{"revision": cfg.Revision})
+		}
+		return nil, kit.InternalError("create revision failed", err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, kit.InternalError("commit failed", err.Error())
+	}
+	return updated, nil
+}
+
+// parseRevision parses a positive revision number path param.
+func parseRevision(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, kit.BadRequest("invalid revision", s)
+	}
+	return n, nil
+}
+
+// ListRevisionsHandler lists the revision history of a config, newest first.
+//
+//	@Summary      List config revisions
+//	@Description  Revision history of a config (owner or shared)
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Config UUID"
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/revisions [get]
+func ListRevisionsHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		_, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		items, err := client.ConfigRevision.Query().
+			Where(configrevision.ConfigIDEQ(cfgID)).
+			Order(ent.Desc(configrevision.FieldRevision)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query revisions failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// GetRevisionHandler returns a single revision of a config.
+//
+//	@Summary      Get config revision
+//	@Description  Fetch one revision by number (owner or shared)
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Config UUID"
+//	@Param        rev  path  int     true  "Revision number"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/revisions/{rev} [get]
+func GetRevisionHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		rev, err := parseRevision(c.Params("rev"))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		_, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permView {
+			return fiber.ErrForbidden
+		}
+
+		item, err := client.ConfigRevision.Query().
+			Where(configrevision.ConfigIDEQ(cfgID), configrevision.RevisionEQ(rev)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("revision not found")
+		}
+		return kit.OK(c, item)
+	}
+}
+
+// RestoreRevisionHandler restores the data of an old revision as a new head revision.
+//
+//	@Summary      Restore config revision
+//	@Description  Copy an old revision's data into a new head revision (owner or editors)
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id        path    string                          true   "Config UUID"
+//	@Param        rev       path    int                             true   "Revision number"
+//	@Param        If-Match  header  string                          false  "expected ETag"
+//	@Param        body      body    configs.RestoreRevisionRequest  false  "restore payload"
+//	@Success      200   {object}  map[string]interface{}
+//	@Failure      400   {object}  map[string]interface{}
+//	@Failure      401   {object}  map[string]interface{}
+//	@Failure      403   {object}  map[string]interface{}
+//	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
+//	@Failure      412   {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/revisions/{rev}/restore [post]
+func RestoreRevisionHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		rev, err := parseRevision(c.Params("rev"))
+		if err != nil {
+			return err
+		}
+		var req RestoreRevisionRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return kit.BadRequest("invalid request body", nil)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, perm, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if perm < permEdit {
+			return fiber.ErrForbidden
+		}
+		if err := kit.CheckIfMatch(c, kit.VersionETag(cfg.Revision)); err != nil {
+			return err
+		}
+
+		old, err := client.ConfigRevision.Query().
+			Where(configrevision.ConfigIDEQ(cfgID), configrevision.RevisionEQ(rev)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("revision not found")
+		}
+
+		msg := strings.TrimSpace(req.Message)
+		if msg == "" {
+			msg = fmt.Sprintf("restore revision %d", rev)
+		}
+		data := old.Data
+		if data == nil {
+			data = map[string]any{}
+		}
+		updated, err := commitChange(ctx, client, cfg, uid, configChange{Data: data, Message: msg, Guarded: kit.HasIfMatch(c)})
+		if err != nil {
+			return err
+		}
+		kit.SetETag(c, kit.VersionETag(updated.Revision))
+		return kit.OK(c, updated)
+	}
+}
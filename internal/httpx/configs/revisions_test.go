@@ -0,0 +1,152 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// asUser mounts a middleware that authenticates every request as the given user.
+func asUser(id uuid.UUID) func(*fiber.App) {
+	return func(app *fiber.App) {
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("auth", &mw.AuthContext{Subject: "user:" + id.String(), Kind: "user"})
+			return c.Next()
+		})
+	}
+}
+
+// doJSON sends a JSON request and decodes the response envelope into out (if non-nil).
+func doJSON(t *testing.T, app *fiber.App, method, target string, body any, out any) *http.Response {
+	t.Helper()
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, rd)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, target, err)
+		}
+	}
+	return res
+}
+
+type revisionEnv struct {
+	Data struct {
+		Revision int            `json:"revision"`
+		Message  string         `json:"message"`
+		Data     map[string]any `json:"data"`
+	}
+}
+
+func TestConfig_Revisions_List_Get_Restore(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("RevOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	other, err := client.User.Create().SetDisplayName("RevOther").Save(ctx)
+	if err != nil {
+		t.Fatalf("create other: %v", err)
+	}
+
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+		app.Delete("/configs/:id", mw.RequireUser(), DeleteConfigHandler(client))
+		app.Get("/configs/:id/revisions", mw.RequireUser(), ListRevisionsHandler(client))
+		app.Get("/configs/:id/revisions/:rev", mw.RequireUser(), GetRevisionHandler(client))
+		app.Post("/configs/:id/revisions/:rev/restore", mw.RequireUser(), RestoreRevisionHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	appOther := testutil.NewApp(asUser(other.ID), routes)
+
+	var created struct {
+		Data struct {
+			ID       uuid.UUID `json:"id"`
+			Revision int       `json:"revision"`
+		}
+	}
+	res := doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "RevCfg", "data": map[string]any{"v": 1}, "message": "init"}, &created)
+	if res.StatusCode != http.StatusCreated || created.Data.Revision != 1 {
+		t.Fatalf("create: status=%d revision=%d", res.StatusCode, created.Data.Revision)
+	}
+	base := "/configs/" + created.Data.ID.String()
+
+	for _, v := range []int{2, 3} {
+		res := doJSON(t, app, http.MethodPut, base, map[string]any{"data": map[string]any{"v": v}}, nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("update v=%d: status=%d", v, res.StatusCode)
+		}
+	}
+
+	var list struct {
+		Data []struct {
+			Revision int `json:"revision"`
+		}
+	}
+	doJSON(t, app, http.MethodGet, base+"/revisions", nil, &list)
+	if len(list.Data) != 3 || list.Data[0].Revision != 3 || list.Data[2].Revision != 1 {
+		t.Fatalf("unexpected revisions: %+v", list.Data)
+	}
+
+	var rev revisionEnv
+	doJSON(t, app, http.MethodGet, base+"/revisions/1", nil, &rev)
+	if rev.Data.Message != "init" || rev.Data.Data["v"] != float64(1) {
+		t.Fatalf("unexpected revision 1: %+v", rev.Data)
+	}
+
+	// Non-owner without a share can neither read nor restore
+	if res := doJSON(t, appOther, http.MethodGet, base+"/revisions", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other list: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appOther, http.MethodPost, base+"/revisions/1/restore", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other restore: status=%d", res.StatusCode)
+	}
+
+	var restored struct {
+		Data struct {
+			Revision int            `json:"revision"`
+			Data     map[string]any `json:"data"`
+		}
+	}
+	res = doJSON(t, app, http.MethodPost, base+"/revisions/1/restore", nil, &restored)
+	if res.StatusCode != http.StatusOK || restored.Data.Revision != 4 || restored.Data.Data["v"] != float64(1) {
+		t.Fatalf("restore: status=%d body=%+v", res.StatusCode, restored.Data)
+	}
+	doJSON(t, app, http.MethodGet, base+"/revisions/4", nil, &rev)
+	if rev.Data.Message != "restore revision 1" {
+		t.Fatalf("unexpected restore message: %q", rev.Data.Message)
+	}
+
+	if res := doJSON(t, app, http.MethodGet, base+"/revisions/9", nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing revision: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodDelete, base, nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("delete with history: status=%d", res.StatusCode)
+	}
+}
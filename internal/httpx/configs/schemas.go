@@ -0,0 +1,154 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configschema"
+	"fiber-ent-apollo-pg/ent/schema"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/schemax"
+)
+
+// compiledSchemas caches compiled schemas by kind; registered schemas are immutable.
+var compiledSchemas sync.Map
+
+// RegisterSchemaRequest is the request body for registering a config schema
+// swagger:model RegisterSchemaRequest
+type RegisterSchemaRequest struct {
+	Kind        string         `json:"kind"`
+	Schema      map[string]any `json:"schema"`
+	Description string         `json:"description,omitempty"`
+}
+
+// validateData checks data against the schema registered for kind.
+// Configs without a kind are not validated.
+func validateData(ctx context.Context, client *ent.Client, kind string, data map[string]any) error {
+	if kind == "" {
+		return nil
+	}
+	var s *schemax.Schema
+	if v, ok := compiledSchemas.Load(kind); ok {
+		s = v.(*schemax.Schema)
+	} else {
+		cs, err := client.ConfigSchema.Query().Where(configschema.KindEQ(kind)).Only(ctx)
+		if ent.IsNotFound(err) {
+			return kit.BadRequest("unknown config kind", kind)
+		}
+		if err != nil {
+			return kit.InternalError("query config schema failed", err.Error())
+		}
+		s, err = schemax.Compile(cs.Schema)
+		if err != nil {
+			return kit.InternalError("stored config schema is invalid", err.Error())
+		}
+		compiledSchemas.Store(kind, s)
+	}
+
+	var doc any = map[string]any{}
+	if data != nil {
+		doc = data
+	}
+	if err := s.Validate(doc); err != nil {
+		var ve *schemax.ValidationError
+		if errors.As(err, &ve) {
+			return kit.BadRequest("data does not match schema "+kind, ve.Violations)
+		}
+		return kit.BadRequest("data does not match schema "+kind, err.Error())
+	}
+	return nil
+}
+
+// RegisterSchemaHandler registers the JSON Schema for a new config kind.
+//
+//	@Summary      Register config schema
+//	@Description  Register the JSON Schema for a config kind and version (admin only). Registered schemas are immutable; publish a new version instead.
+//	@Tags         admin
+//	@Accept       json
+//	@Produce      json
+//	@Security     BearerAuth
+//	@Param        body  body  configs.RegisterSchemaRequest  true  "schema payload"
+//	@Success      201   {object}  map[string]interface{}
+//	@Failure      400   {object}  map[string]interface{}
+//	@Failure      401   {object}  map[string]interface{}
+//	@Failure      403   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
+//	@Router       /api/v1/admin/config-schemas [post]
+func RegisterSchemaHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req RegisterSchemaRequest
+		if err := c.BodyParser(&req); err != nil {
+			return kit.BadRequest("invalid request body", nil)
+		}
+		req.Kind = strings.TrimSpace(req.Kind)
+		if !schema.KindPattern.MatchString(req.Kind) {
+			return kit.BadRequest("kind must look like name/v1", req.Kind)
+		}
+		if req.Schema == nil {
+			return kit.BadRequest("schema required", nil)
+		}
+		if _, err := schemax.Compile(req.Schema); err != nil {
+			return kit.BadRequest("invalid schema", err.Error())
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		created, err := client.ConfigSchema.Create().
+			SetKind(req.Kind).
+			SetSchema(req.Schema).
+			SetDescription(req.Description).
+			Save(ctx)
+		if ent.IsConstraintError(err) {
+			return kit.Conflict("schema already registered for kind", req.Kind)
+		}
+		if err != nil {
+			return kit.InternalError("create config schema failed", err.Error())
+		}
+		return kit.Created(c, created)
+	}
+}
+
+// ListSchemasHandler lists registered config schemas.
+//
+//	@Summary      List config schemas
+//	@Description  Registered config schemas ordered by kind (admin only)
+//	@Tags         admin
+//	@Accept       json
+//	@Produce      json
+//	@Security     BearerAuth
+//	@Param        limit   query  int  false  "page size"  default(20)
+//	@Param        offset  query  int  false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Router       /api/v1/admin/config-schemas [get]
+func ListSchemasHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		items, err := client.ConfigSchema.Query().
+			Order(ent.Asc(configschema.FieldKind)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query config schemas failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
@@ -0,0 +1,97 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_SchemaValidation(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := client.User.Create().SetDisplayName("SchemaOwner").Save(ctx)
+	if err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Post("/admin/config-schemas", RegisterSchemaHandler(client))
+		app.Get("/admin/config-schemas", ListSchemasHandler(client))
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Put("/configs/:id", mw.RequireUser(), UpdateConfigHandler(client))
+	})
+
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"format"},
+		"properties": map[string]any{
+			"format": map[string]any{"enum": []any{"png", "svg"}},
+			"scale":  map[string]any{"type": "number", "minimum": 1},
+		},
+	}
+	reg := map[string]any{"kind": "figma-export/v1", "schema": schema}
+	if res := doJSON(t, app, http.MethodPost, "/admin/config-schemas", reg, nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("register: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPost, "/admin/config-schemas", reg, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate register: status=%d", res.StatusCode)
+	}
+	bad := map[string]any{"kind": "figma-export", "schema": schema}
+	if res := doJSON(t, app, http.MethodPost, "/admin/config-schemas", bad, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unversioned kind: status=%d", res.StatusCode)
+	}
+	var list struct {
+		Data []struct {
+			Kind string `json:"kind"`
+		}
+	}
+	doJSON(t, app, http.MethodGet, "/admin/config-schemas", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].Kind != "figma-export/v1" {
+		t.Fatalf("unexpected schemas: %+v", list.Data)
+	}
+
+	var invalid struct {
+		Code    string `json:"code"`
+		Details []struct {
+			Path    string `json:"path"`
+			Message string `json:"message"`
+		} `json:"details"`
+	}
+	res := doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "Bad", "kind": "figma-export/v1", "data": map[string]any{"scale": 0}}, &invalid)
+	if res.StatusCode != http.StatusBadRequest || len(invalid.Details) != 2 || invalid.Details[0].Path != "/format" || invalid.Details[1].Path != "/scale" {
+		t.Fatalf("invalid create: status=%d body=%+v", res.StatusCode, invalid)
+	}
+	if res := doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "Unknown", "kind": "other/v1", "data": map[string]any{}}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown kind: status=%d", res.StatusCode)
+	}
+
+	var created struct {
+		Data struct {
+			ID   uuid.UUID `json:"id"`
+			Kind string    `json:"kind"`
+		}
+	}
+	res = doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "Good", "kind": "figma-export/v1", "data": map[string]any{"format": "png"}}, &created)
+	if res.StatusCode != http.StatusCreated || created.Data.Kind != "figma-export/v1" {
+		t.Fatalf("valid create: status=%d kind=%q", res.StatusCode, created.Data.Kind)
+	}
+	base := "/configs/" + created.Data.ID.String()
+	if res := doJSON(t, app, http.MethodPut, base, map[string]any{"data": map[string]any{"format": "gif"}}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid update: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPut, base, map[string]any{"kind": "", "data": map[string]any{"format": "gif"}}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("untyped update: status=%d", res.StatusCode)
+	}
+	// Untyped configs are not validated
+	if res := doJSON(t, app, http.MethodPost, "/configs", map[string]any{"name": "Free", "data": map[string]any{"anything": true}}, nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("untyped create: status=%d", res.StatusCode)
+	}
+}
@@ -0,0 +1,383 @@
+package configs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/identity"
+	"fiber-ent-apollo-pg/ent/pendingshare"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// ShareRequest is the request body for sharing a config by login identifier
+// swagger:model ShareRequest
+type ShareRequest struct {
+	Identifier string `json:"identifier" example:"alice@example.com"`
+	Permission string `json:"permission,omitempty" example:"view"`
+}
+
+// ShareResult tells whether a share by identifier reached a user or waits for
+// the identifier to register and be confirmed.
+type ShareResult struct {
+	// Status is "shared" or "pending".
+	Status  string            `json:"status"`
+	Share   *ent.ConfigShare  `json:"share,omitempty"`
+	Pending *ent.PendingShare `json:"pending,omitempty"`
+	// User is the user a pending share was confirmed for.
+	User *ent.User `json:"user,omitempty"`
+}
+
+// shareWithUser shares a config directly with a user, updating the
+// permission when it is already shared with them.
+func shareWithUser(ctx context.Context, client *ent.Client, cfgID, targetID uuid.UUID, perm configshare.Permission) (*ent.ConfigShare, error) {
+	share, err := client.ConfigShare.Create().
+		SetConfigID(cfgID).
+		SetUserID(targetID).
+		SetPermission(perm).
+		Save(ctx)
+	if !ent.IsConstraintError(err) {
+		return share, err
+	}
+	// Already shared: update the permission in place
+	err = client.ConfigShare.Update().
+		Where(configshare.ConfigIDEQ(cfgID), configshare.UserIDEQ(targetID)).
+		SetPermission(perm).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.ConfigShare.Query().
+		Where(configshare.ConfigIDEQ(cfgID), configshare.UserIDEQ(targetID)).
+		Only(ctx)
+}
+
+// findIdentities returns the password identities matching identifier,
+// ignoring case. An exact match is returned alone when identities differ
+// only by case.
+func findIdentities(ctx context.Context, client *ent.Client, identifier string) ([]*ent.Identity, error) {
+	ids, err := client.Identity.Query().
+		Where(identity.ProviderEQ(identity.ProviderPassword), identity.IdentifierEqualFold(identifier)).
+		WithUser().
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, idn := range ids {
+		if idn.Identifier == identifier {
+			ids[0], ids[i] = ids[i], ids[0]
+			return ids[:1], nil
+		}
+	}
+	return ids, nil
+}
+
+// ShareHandler shares a config with a login identifier.
+//
+//	@Summary      Share by identifier
+//	@Description  Share a config with the user whose password login identifier matches (owner or managers). Without a match a pending share is kept; once the identifier has registered, the owner or a manager confirms it. Sharing again updates the permission, view by default. If-Match is not evaluated, as shares are not versioned.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id     path  string                true  "Config UUID"
+//	@Param        body   body  configs.ShareRequest  true  "identifier and permission"
+//	@Success      200    {object}  configs.ShareResult
+//	@Failure      400    {object}  map[string]interface{}
+//	@Failure      401    {object}  map[string]interface{}
+//	@Failure      403    {object}  map[string]interface{}
+//	@Failure      404    {object}  map[string]interface{}
+//	@Failure      409    {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/share [post]
+func ShareHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		var req ShareRequest
+		if err := c.BodyParser(&req); err != nil {
+			return kit.BadRequest("invalid request body", nil)
+		}
+		ident := strings.TrimSpace(req.Identifier)
+		if ident == "" || len(ident) > 320 {
+			return kit.BadRequest("identifier required", nil)
+		}
+		perm := configshare.PermissionView
+		if req.Permission != "" {
+			perm = configshare.Permission(req.Permission)
+			if err := configshare.PermissionValidator(perm); err != nil {
+				return kit.BadRequest("invalid permission", req.Permission)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+		defer cancel()
+
+		cfg, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if access < permManage {
+			return fiber.ErrForbidden
+		}
+
+		ids, err := findIdentities(ctx, client, ident)
+		if err != nil {
+			return kit.InternalError("query identity failed", err.Error())
+		}
+		if len(ids) > 1 {
+			return kit.Conflict("identifier matches several users", ident)
+		}
+		if len(ids) == 1 && ids[0].Edges.User != nil {
+			idn := ids[0]
+			switch idn.Edges.User.ID {
+			case uid:
+				return kit.BadRequest("cannot share to self", nil)
+			case cfg.Edges.Owner.ID:
+				return kit.BadRequest("cannot share to the config owner", nil)
+			}
+			share, err := shareWithUser(ctx, client, cfgID, idn.Edges.User.ID, perm)
+			if err != nil {
+				return kit.InternalError("share failed", err.Error())
+			}
+			return kit.OK(c, ShareResult{Status: "shared", Share: share})
+		}
+
+		pending, err := client.PendingShare.Query().
+			Where(pendingshare.ConfigIDEQ(cfgID), pendingshare.IdentifierEqualFold(ident)).
+			First(ctx)
+		switch {
+		case err == nil:
+			pending, err = pending.Update().SetPermission(pendingshare.Permission(perm)).Save(ctx)
+		case ent.IsNotFound(err):
+			pending, err = client.PendingShare.Create().
+				SetConfigID(cfgID).
+				SetSharerID(uid).
+				SetIdentifier(strings.ToLower(ident)).
+				SetPermission(pendingshare.Permission(perm)).
+				Save(ctx)
+			if ent.IsConstraintError(err) {
+				return kit.Conflict("config is being shared concurrently", nil)
+			}
+		}
+		if err != nil {
+			return kit.InternalError("share failed", err.Error())
+		}
+		return kit.OK(c, ShareResult{Status: "pending", Pending: pending})
+	}
+}
+
+// ListPendingSharesHandler lists the pending shares of a config.
+//
+//	@Summary      List pending shares
+//	@Description  Shares waiting for their identifier to register and to be confirmed (owner or managers)
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id          path    string  true   "Config UUID"
+//	@Param        limit       query   int     false  "page size"      default(20)
+//	@Param        offset      query   int     false  "offset"         default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/pending-shares [get]
+func ListPendingSharesHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		_, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if access < permManage {
+			return fiber.ErrForbidden
+		}
+		items, err := client.PendingShare.Query().
+			Where(pendingshare.ConfigIDEQ(cfgID)).
+			Order(ent.Desc(pendingshare.FieldCreatedAt)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query pending shares failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// RevokePendingShareHandler deletes a pending share before it is confirmed.
+//
+//	@Summary      Revoke pending share
+//	@Description  Delete a pending share (owner or managers)
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Config UUID"
+//	@Param        pid  path  string  true  "Pending share UUID"
+//	@Success      200  {object}  map[string]string
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/configs/{id}/pending-shares/{pid} [delete]
+func RevokePendingShareHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		pid, err := uuid.Parse(c.Params("pid"))
+		if err != nil {
+			return kit.BadRequest("invalid pending share id", c.Params("pid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		_, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if access < permManage {
+			return fiber.ErrForbidden
+		}
+		n, err := client.PendingShare.Delete().
+			Where(pendingshare.IDEQ(pid), pendingshare.ConfigIDEQ(cfgID)).
+			Exec(ctx)
+		if err != nil {
+			return kit.InternalError("revoke pending share failed", err.Error())
+		}
+		if n == 0 {
+			return kit.NotFound("pending share not found")
+		}
+		return kit.OK(c, fiber.Map{"status": "ok"})
+	}
+}
+
+// ConfirmPendingShareHandler turns a pending share into a direct share once
+// its identifier has registered. Registration does not prove that the user
+// owns the identifier, so the share waits for someone who can manage the
+// config to confirm the account it reached.
+//
+//	@Summary      Confirm pending share
+//	@Description  Share the config directly with the user who registered the identifier of a pending share, with its permission (owner or managers). The response names the user the config is now shared with.
+//	@Tags         configs
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Config UUID"
+//	@Param        pid  path  string  true  "Pending share UUID"
+//	@Success      200  {object}  configs.ShareResult
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "identifier has not registered, or matches several users"
+//	@Router       /api/v1/configs/{id}/pending-shares/{pid}/confirm [post]
+func ConfirmPendingShareHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		cfgID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid config id", c.Params("id"))
+		}
+		pid, err := uuid.Parse(c.Params("pid"))
+		if err != nil {
+			return kit.BadRequest("invalid pending share id", c.Params("pid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		cfg, access, err := loadConfig(ctx, client, cfgID, uid)
+		if err != nil {
+			return err
+		}
+		if access < permManage {
+			return fiber.ErrForbidden
+		}
+		pending, err := client.PendingShare.Query().
+			Where(pendingshare.IDEQ(pid), pendingshare.ConfigIDEQ(cfgID)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("pending share not found")
+		}
+		ids, err := findIdentities(ctx, client, pending.Identifier)
+		if err != nil {
+			return kit.InternalError("query identity failed", err.Error())
+		}
+		if len(ids) > 1 {
+			return kit.Conflict("identifier matches several users", pending.Identifier)
+		}
+		if len(ids) == 0 || ids[0].Edges.User == nil {
+			return kit.Conflict("identifier has not registered yet", pending.Identifier)
+		}
+		target := ids[0].Edges.User
+		if target.ID == cfg.Edges.Owner.ID {
+			return kit.BadRequest("cannot share to the config owner", nil)
+		}
+		share, err := shareWithUser(ctx, client, cfgID, target.ID, configshare.Permission(pending.Permission))
+		if err != nil {
+			return kit.InternalError("share failed", err.Error())
+		}
+		// Pending shares of this config in other spellings of the identifier,
+		// stored before identifiers were lower-cased, are settled as well
+		_, err = client.PendingShare.Delete().
+			Where(pendingshare.ConfigIDEQ(cfgID), pendingshare.IdentifierEqualFold(pending.Identifier)).
+			Exec(ctx)
+		if err != nil {
+			return kit.InternalError("delete pending share failed", err.Error())
+		}
+		return kit.OK(c, ShareResult{Status: "shared", Share: share, User: target})
+	}
+}
@@ -0,0 +1,159 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent/configshare"
+	"fiber-ent-apollo-pg/ent/identity"
+	"fiber-ent-apollo-pg/ent/pendingshare"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestConfig_ShareByIdentifier(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner := client.User.Create().SetDisplayName("IdOwner").SaveX(ctx)
+	viewer := client.User.Create().SetDisplayName("IdViewer").SaveX(ctx)
+	known := client.User.Create().SetDisplayName("IdKnown").SaveX(ctx)
+	sfx := uuid.NewString()[:8]
+	knownEmail, newEmail := "known-"+sfx+"@example.com", "new-"+sfx+"@example.com"
+	client.Identity.Create().SetProvider(identity.ProviderPassword).SetIdentifier(knownEmail).SetUser(known).ExecX(ctx)
+
+	routes := func(app *fiber.App) {
+		app.Post("/configs", mw.RequireUser(), CreateConfigHandler(client))
+		app.Post("/configs/:id/share", mw.RequireUser(), ShareHandler(client))
+		app.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), ShareToUserHandler(client))
+		app.Get("/configs/:id/pending-shares", mw.RequireUser(), ListPendingSharesHandler(client))
+		app.Delete("/configs/:id/pending-shares/:pid", mw.RequireUser(), RevokePendingShareHandler(client))
+		app.Post("/configs/:id/pending-shares/:pid/confirm", mw.RequireUser(), ConfirmPendingShareHandler(client))
+	}
+	appOwner := testutil.NewApp(asUser(owner.ID), routes)
+	appViewer := testutil.NewApp(asUser(viewer.ID), routes)
+
+	var created struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		}
+	}
+	doJSON(t, appOwner, http.MethodPost, "/configs", map[string]any{"name": "IdCfg", "data": map[string]any{}}, &created)
+	base := "/configs/" + created.Data.ID.String()
+	doJSON(t, appOwner, http.MethodPost, base+"/share/user/"+viewer.ID.String(), nil, nil)
+
+	type result struct {
+		Data struct {
+			Status string `json:"status"`
+			Share  *struct {
+				UserID     uuid.UUID `json:"user_id"`
+				Permission string    `json:"permission"`
+			} `json:"share"`
+			Pending *struct {
+				ID         uuid.UUID `json:"id"`
+				Identifier string    `json:"identifier"`
+				Permission string    `json:"permission"`
+			} `json:"pending"`
+		}
+	}
+
+	if res := doJSON(t, appOwner, http.MethodPost, base+"/share", map[string]any{"identifier": " "}, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("blank identifier: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appViewer, http.MethodPost, base+"/share", map[string]any{"identifier": newEmail}, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("viewer share: status=%d", res.StatusCode)
+	}
+
+	// A registered identifier is shared directly, ignoring case
+	var got result
+	res := doJSON(t, appOwner, http.MethodPost, base+"/share", map[string]any{"identifier": strings.ToUpper(knownEmail), "permission": "edit"}, &got)
+	if res.StatusCode != http.StatusOK || got.Data.Status != "shared" || got.Data.Share == nil ||
+		got.Data.Share.UserID != known.ID || got.Data.Share.Permission != "edit" {
+		t.Fatalf("share known: status=%d %+v", res.StatusCode, got.Data)
+	}
+	if !client.ConfigShare.Query().Where(configshare.ConfigIDEQ(created.Data.ID), configshare.UserIDEQ(known.ID)).ExistX(ctx) {
+		t.Fatal("direct share missing")
+	}
+
+	// Others wait for registration; sharing again updates the pending share
+	got = result{}
+	res = doJSON(t, appOwner, http.MethodPost, base+"/share", map[string]any{"identifier": "New-" + sfx + "@Example.com"}, &got)
+	if res.StatusCode != http.StatusOK || got.Data.Status != "pending" || got.Data.Pending == nil ||
+		got.Data.Pending.Permission != "view" || got.Data.Pending.Identifier != newEmail {
+		t.Fatalf("share unknown: status=%d %+v", res.StatusCode, got.Data)
+	}
+	pid := got.Data.Pending.ID
+	got = result{}
+	doJSON(t, appOwner, http.MethodPost, base+"/share", map[string]any{"identifier": strings.ToUpper(newEmail), "permission": "manage"}, &got)
+	if got.Data.Pending == nil || got.Data.Pending.ID != pid || got.Data.Pending.Permission != "manage" {
+		t.Fatalf("share unknown again: %+v", got.Data)
+	}
+
+	var list struct {
+		Data []struct {
+			Identifier string `json:"identifier"`
+		}
+	}
+	if res := doJSON(t, appViewer, http.MethodGet, base+"/pending-shares", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("viewer list: status=%d", res.StatusCode)
+	}
+	doJSON(t, appOwner, http.MethodGet, base+"/pending-shares", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].Identifier != newEmail {
+		t.Fatalf("pending shares: %+v", list.Data)
+	}
+
+	// Registering the identifier claims nothing; a manager confirms the account
+	confirm := base + "/pending-shares/" + pid.String() + "/confirm"
+	if res := doJSON(t, appOwner, http.MethodPost, confirm, nil, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("confirm unregistered: status=%d", res.StatusCode)
+	}
+	newcomer := client.User.Create().SetDisplayName("IdNew").SaveX(ctx)
+	client.Identity.Create().SetProvider(identity.ProviderPassword).SetIdentifier(newEmail).SetUser(newcomer).ExecX(ctx)
+	// Stored in another spelling before identifiers were lower-cased
+	client.PendingShare.Create().SetConfigID(created.Data.ID).SetSharerID(owner.ID).SetIdentifier(strings.ToUpper(newEmail)).ExecX(ctx)
+	if client.ConfigShare.Query().Where(configshare.ConfigIDEQ(created.Data.ID), configshare.UserIDEQ(newcomer.ID)).ExistX(ctx) {
+		t.Fatal("shared before confirmation")
+	}
+	if res := doJSON(t, appViewer, http.MethodPost, confirm, nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("viewer confirm: status=%d", res.StatusCode)
+	}
+	var confirmed struct {
+		Data struct {
+			Status string `json:"status"`
+			Share  *struct {
+				Permission string `json:"permission"`
+			} `json:"share"`
+			User *struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"user"`
+		}
+	}
+	res = doJSON(t, appOwner, http.MethodPost, confirm, nil, &confirmed)
+	if res.StatusCode != http.StatusOK || confirmed.Data.Status != "shared" || confirmed.Data.Share == nil ||
+		confirmed.Data.Share.Permission != "manage" || confirmed.Data.User == nil || confirmed.Data.User.ID != newcomer.ID {
+		t.Fatalf("confirm: status=%d %+v", res.StatusCode, confirmed.Data)
+	}
+	if n := client.PendingShare.Query().Where(pendingshare.ConfigIDEQ(created.Data.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("pending shares left: %d", n)
+	}
+	if res := doJSON(t, appOwner, http.MethodPost, confirm, nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("confirm again: status=%d", res.StatusCode)
+	}
+
+	got = result{}
+	doJSON(t, appOwner, http.MethodPost, base+"/share", map[string]any{"identifier": "later-" + sfx + "@example.com"}, &got)
+	pid = got.Data.Pending.ID
+	if res := doJSON(t, appOwner, http.MethodDelete, base+"/pending-shares/"+pid.String(), nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("revoke: status=%d", res.StatusCode)
+	}
+	if res := doJSON(t, appOwner, http.MethodDelete, base+"/pending-shares/"+pid.String(), nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("revoke again: status=%d", res.StatusCode)
+	}
+}
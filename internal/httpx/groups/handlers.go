@@ -11,6 +11,7 @@ import (
 
 	"fiber-ent-apollo-pg/ent"
 	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupmembership"
 	"fiber-ent-apollo-pg/ent/user"
 	"fiber-ent-apollo-pg/internal/httpx/kit"
 	"fiber-ent-apollo-pg/internal/httpx/mw"
@@ -23,10 +24,10 @@ type CreateGroupRequest struct {
 	MemberIDs []uuid.UUID `json:"member_ids"`
 }
 
-// CreateGroupHandler creates a group and adds members (including caller if not present).
+// CreateGroupHandler creates a group owned by the caller and adds members.
 //
 //	@Summary      Create group
-//	@Description  Create a group and add members (caller auto-included)
+//	@Description  Create a group owned by the caller; member_ids join as members
 //	@Tags         groups
 //	@Accept       json
 //	@Produce      json
@@ -49,27 +50,40 @@ func CreateGroupHandler(client *ent.Client) fiber.Handler {
 		if err := c.BodyParser(&req); err != nil {
 			return kit.BadRequest("invalid body", nil)
 		}
-		hasCaller := false
+		memberIDs := make([]uuid.UUID, 0, len(req.MemberIDs))
+		seen := map[uuid.UUID]bool{uid: true}
 		for _, id := range req.MemberIDs {
-			if id == uid {
-				hasCaller = true
-				break
+			if !seen[id] {
+				seen[id] = true
+				memberIDs = append(memberIDs, id)
 			}
 		}
-		if !hasCaller {
-			req.MemberIDs = append(req.MemberIDs, uid)
-		}
 
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
-		g, err := client.Group.Create().SetName(req.Name).Save(ctx)
+		if err := checkUsers(ctx, client, memberIDs); err != nil {
+			return err
+		}
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+		g, err := tx.Group.Create().SetName(req.Name).Save(ctx)
 		if err != nil {
 			return kit.InternalError("create group failed", err.Error())
 		}
-		if len(req.MemberIDs) > 0 {
-			if err := client.Group.UpdateOne(g).AddMemberIDs(req.MemberIDs...).Exec(ctx); err != nil {
-				return kit.InternalError("add members failed", err.Error())
-			}
+		builders := []*ent.GroupMembershipCreate{
+			tx.GroupMembership.Create().SetGroupID(g.ID).SetUserID(uid).SetRole(groupmembership.RoleOwner),
+		}
+		for _, id := range memberIDs {
+			builders = append(builders, tx.GroupMembership.Create().SetGroupID(g.ID).SetUserID(id))
+		}
+		if err := tx.GroupMembership.CreateBulk(builders...).Exec(ctx); err != nil {
+			return kit.InternalError("add members failed", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
 		}
 		return kit.Created(c, g)
 	}
@@ -114,10 +128,10 @@ func ListMyGroupsHandler(client *ent.Client) fiber.Handler {
 	}
 }
 
-// DeleteGroupHandler deletes a group if the current user is a member.
+// DeleteGroupHandler deletes a group if the current user is its owner or an admin.
 //
 //	@Summary      Delete group
-//	@Description  Delete a group (member only)
+//	@Description  Delete a group (owner or admin only)
 //	@Tags         groups
 //	@Accept       json
 //	@Produce      json
@@ -144,14 +158,14 @@ func DeleteGroupHandler(client *ent.Client) fiber.Handler {
 		}
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
-		// ensure membership
-		ok, err := client.Group.Query().Where(group.IDEQ(gid), group.HasMembersWith(user.IDEQ(uid))).Exist(ctx)
+		role, err := roleOf(ctx, client, gid, uid)
 		if err != nil {
-			return kit.InternalError("query group failed", err.Error())
+			return err
 		}
-		if !ok {
+		if !canManage(role) {
 			return fiber.ErrForbidden
 		}
+		// memberships and config shares are removed with the group
 		if err := client.Group.DeleteOneID(gid).Exec(ctx); err != nil {
 			return kit.InternalError("delete failed", err.Error())
 		}
@@ -25,7 +25,8 @@ import (
 
 func newTestClient(t *testing.T) *ent.Client {
 	t.Helper()
-	dsn := "file:ent?mode=memory&cache=shared&_fk=1"
+	// One database per test, so repeated runs do not see each other's rows
+	dsn := "file:" + t.Name() + "?mode=memory&cache=shared&_fk=1"
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		t.Fatalf("open db: %v", err)
@@ -33,6 +34,7 @@ func newTestClient(t *testing.T) *ent.Client {
 	_, _ = db.Exec("PRAGMA foreign_keys = ON")
 	drv := entsql.OpenDB(dialect.SQLite, db)
 	client := ent.NewClient(ent.Driver(drv))
+	t.Cleanup(func() { _ = client.Close() })
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := client.Schema.Create(ctx); err != nil {
@@ -0,0 +1,379 @@
+package groups
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/groupinvitation"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/ent/identity"
+	"fiber-ent-apollo-pg/ent/user"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// Invitation limits.
+const (
+	DefaultInvitationTTL = 7 * 24 * time.Hour
+	MaxInvitationTTL     = 30 * 24 * time.Hour
+	MaxInvitationUses    = 1000
+)
+
+// CreateInvitationRequest is the request payload to invite users to a group.
+// swagger:model CreateInvitationRequest
+type CreateInvitationRequest struct {
+	// Email restricts the invitation to the user with this login identifier.
+	Email string `json:"email,omitempty"`
+	// Role is member (default) or admin; only the owner invites admins.
+	Role string `json:"role,omitempty"`
+	// ExpiresInHours defaults to 168 (7 days), at most 720.
+	ExpiresInHours int `json:"expires_in_hours,omitempty"`
+	// MaxUses defaults to 1, at most 1000.
+	MaxUses int `json:"max_uses,omitempty"`
+}
+
+// AcceptInvitationRequest is the request payload to accept an invitation.
+// swagger:model AcceptInvitationRequest
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// CreatedInvitation is a new invitation with its token, which is not stored
+// and cannot be retrieved later.
+type CreatedInvitation struct {
+	*ent.GroupInvitation
+	Token string `json:"token"`
+}
+
+// newInvitationToken returns a random token and its hash.
+func newInvitationToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashInvitationToken(token), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateInvitationHandler creates an invitation to a group.
+//
+//	@Summary      Create group invitation
+//	@Description  Create an invitation token (owner or admin; only the owner invites admins). The token is returned once; share it as a link for the invitee to accept.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path  string                          true  "Group UUID"
+//	@Param        body  body  groups.CreateInvitationRequest  true  "invitation payload"
+//	@Success      201  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/invitations [post]
+func CreateInvitationHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		var req CreateInvitationRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return kit.BadRequest("invalid body", nil)
+			}
+		}
+		role := groupmembership.RoleMember
+		if req.Role != "" {
+			if role, err = parseRole(req.Role); err != nil {
+				return err
+			}
+		}
+		ttl := DefaultInvitationTTL
+		if req.ExpiresInHours != 0 {
+			ttl = time.Duration(req.ExpiresInHours) * time.Hour
+			if ttl <= 0 || ttl > MaxInvitationTTL {
+				return kit.BadRequest("expires_in_hours must be between 1 and 720", req.ExpiresInHours)
+			}
+		}
+		maxUses := 1
+		if req.MaxUses != 0 {
+			if req.MaxUses < 1 || req.MaxUses > MaxInvitationUses {
+				return kit.BadRequest("max_uses must be between 1 and 1000", req.MaxUses)
+			}
+			maxUses = req.MaxUses
+		}
+		email := strings.TrimSpace(req.Email)
+		if len(email) > 320 || (email != "" && !strings.Contains(email, "@")) {
+			return kit.BadRequest("invalid email", req.Email)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if !canManageMember(actor, role) {
+			return fiber.ErrForbidden
+		}
+		token, hash, err := newInvitationToken()
+		if err != nil {
+			return kit.InternalError("generate token failed", err.Error())
+		}
+		inv, err := client.GroupInvitation.Create().
+			SetGroupID(gid).
+			SetInviterID(uid).
+			SetTokenHash(hash).
+			SetEmail(email).
+			SetRole(groupinvitation.Role(role)).
+			SetExpiresAt(time.Now().Add(ttl)).
+			SetMaxUses(maxUses).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("create invitation failed", err.Error())
+		}
+		return kit.Created(c, CreatedInvitation{GroupInvitation: inv, Token: token})
+	}
+}
+
+// ListInvitationsHandler lists the invitations of a group.
+//
+//	@Summary      List group invitations
+//	@Description  Invitations of the group, newest first, including expired, used up and revoked ones (owner or admin)
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Group UUID"
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/invitations [get]
+func ListInvitationsHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if !canManage(actor) {
+			return fiber.ErrForbidden
+		}
+		items, err := client.GroupInvitation.Query().
+			Where(groupinvitation.GroupIDEQ(gid)).
+			Order(ent.Desc(groupinvitation.FieldCreatedAt)).
+			Limit(pg.Limit).Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query invitations failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// RevokeInvitationHandler revokes an invitation so it can no longer be accepted.
+//
+//	@Summary      Revoke group invitation
+//	@Description  Revoke an invitation (owner or admin; only the owner revokes admin invitations). Revoking twice is a no-op.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Group UUID"
+//	@Param        iid  path  string  true  "Invitation UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/invitations/{iid} [delete]
+func RevokeInvitationHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		iid, err := uuid.Parse(c.Params("iid"))
+		if err != nil {
+			return kit.BadRequest("invalid invitation id", c.Params("iid"))
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		inv, err := client.GroupInvitation.Query().
+			Where(groupinvitation.IDEQ(iid), groupinvitation.GroupIDEQ(gid)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("invitation not found")
+		}
+		if !canManageMember(actor, groupmembership.Role(inv.Role)) {
+			return fiber.ErrForbidden
+		}
+		if inv.RevokedAt == nil {
+			_, err := client.GroupInvitation.Update().
+				Where(groupinvitation.IDEQ(iid), groupinvitation.RevokedAtIsNil()).
+				SetRevokedAt(time.Now()).
+				Save(ctx)
+			if err != nil {
+				return kit.InternalError("revoke invitation failed", err.Error())
+			}
+			if inv, err = client.GroupInvitation.Get(ctx, iid); err != nil {
+				return kit.InternalError("query invitation failed", err.Error())
+			}
+		}
+		return kit.OK(c, inv)
+	}
+}
+
+// AcceptInvitationHandler adds the current user to the group of an invitation.
+//
+//	@Summary      Accept group invitation
+//	@Description  Join the group of an invitation token with the invitation's role. Invitations restricted to an email only work for the user with that login identifier. Invitations whose inviter has since left the group or can no longer grant the role are revoked.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        body  body  groups.AcceptInvitationRequest  true  "invitation token"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}  "invitation is for another user"
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "already a member"
+//	@Failure      410  {object}  map[string]interface{}  "invitation expired, used up or revoked, or its inviter can no longer grant the role"
+//	@Router       /api/v1/invitations/accept [post]
+func AcceptInvitationHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		uid, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		var req AcceptInvitationRequest
+		if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+			return kit.BadRequest("token required", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		inv, err := client.GroupInvitation.Query().
+			Where(groupinvitation.TokenHashEQ(hashInvitationToken(strings.TrimSpace(req.Token)))).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("invitation not found")
+		}
+		switch {
+		case inv.RevokedAt != nil:
+			return kit.NewAPIError(http.StatusGone, "E_INVITATION_REVOKED", "invitation was revoked", nil)
+		case !time.Now().Before(inv.ExpiresAt):
+			return kit.NewAPIError(http.StatusGone, "E_INVITATION_EXPIRED", "invitation expired", nil)
+		case inv.Uses >= inv.MaxUses:
+			return kit.NewAPIError(http.StatusGone, "E_INVITATION_USED", "invitation was used up", nil)
+		}
+		if inv.Email != "" {
+			ok, err := client.Identity.Query().
+				Where(identity.IdentifierEqualFold(inv.Email), identity.HasUserWith(user.IDEQ(uid))).
+				Exist(ctx)
+			if err != nil {
+				return kit.InternalError("query identity failed", err.Error())
+			}
+			if !ok {
+				return kit.NewAPIError(http.StatusForbidden, "E_INVITATION_RECIPIENT", "invitation is for another user", nil)
+			}
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+		member, err := tx.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(inv.GroupID), groupmembership.UserIDEQ(uid)).
+			Exist(ctx)
+		if err != nil {
+			return kit.InternalError("query membership failed", err.Error())
+		}
+		if member {
+			return kit.Conflict("already a member of this group", inv.GroupID)
+		}
+		// The inviter must still be able to grant the role; invitations of
+		// removed or demoted inviters are revoked when first used
+		inviter, err := tx.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(inv.GroupID), groupmembership.UserIDEQ(inv.InviterID)).
+			Only(ctx)
+		if err != nil && !ent.IsNotFound(err) {
+			return kit.InternalError("query membership failed", err.Error())
+		}
+		if inviter == nil || !canManageMember(inviter.Role, groupmembership.Role(inv.Role)) {
+			if err := tx.GroupInvitation.Update().
+				Where(groupinvitation.IDEQ(inv.ID), groupinvitation.RevokedAtIsNil()).
+				SetRevokedAt(time.Now()).
+				Exec(ctx); err != nil {
+				return kit.InternalError("revoke invitation failed", err.Error())
+			}
+			if err := tx.Commit(); err != nil {
+				return kit.InternalError("commit failed", err.Error())
+			}
+			return kit.NewAPIError(http.StatusGone, "E_INVITATION_REVOKED", "invitation was revoked, its inviter can no longer grant this role", nil)
+		}
+		// Claim a use; the guards lose against concurrent accepts and revokes
+		n, err := tx.GroupInvitation.Update().
+			Where(groupinvitation.IDEQ(inv.ID), groupinvitation.UsesEQ(inv.Uses), groupinvitation.RevokedAtIsNil()).
+			AddUses(1).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("accept invitation failed", err.Error())
+		}
+		if n == 0 {
+			return kit.Conflict("invitation changed, retry", nil)
+		}
+		m, err := tx.GroupMembership.Create().
+			SetGroupID(inv.GroupID).
+			SetUserID(uid).
+			SetRole(groupmembership.Role(inv.Role)).
+			Save(ctx)
+		if ent.IsConstraintError(err) {
+			return kit.Conflict("already a member of this group", inv.GroupID)
+		}
+		if err != nil {
+			return kit.InternalError("join group failed", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
+		return kit.OK(c, m)
+	}
+}
@@ -0,0 +1,202 @@
+package groups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/groupinvitation"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func newInvitationsApp(client *ent.Client) *fiber.App {
+	return testutil.NewApp(
+		func(app *fiber.App) {
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("auth", &mw.AuthContext{Subject: "user:" + c.Get("X-User"), Kind: "user"})
+				return c.Next()
+			})
+		},
+		func(app *fiber.App) {
+			app.Get("/groups/:id/invitations", ListInvitationsHandler(client))
+			app.Post("/groups/:id/invitations", CreateInvitationHandler(client))
+			app.Delete("/groups/:id/invitations/:iid", RevokeInvitationHandler(client))
+			app.Post("/invitations/accept", AcceptInvitationHandler(client))
+		},
+	)
+}
+
+// send calls the app as user and decodes the envelope data into out.
+func send(t *testing.T, app *fiber.App, as uuid.UUID, method, target string, body any, out any) (int, string) {
+	t.Helper()
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", as.String())
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	var env struct {
+		Code string          `json:"code"`
+		Data json.RawMessage `json:"data"`
+	}
+	_ = json.NewDecoder(res.Body).Decode(&env)
+	if out != nil && len(env.Data) > 0 {
+		_ = json.Unmarshal(env.Data, out)
+	}
+	return res.StatusCode, env.Code
+}
+
+func TestInvitations(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	mkUser := func(login string) uuid.UUID {
+		u := client.User.Create().SetDisplayName(login).SaveX(ctx)
+		client.Identity.Create().SetIdentifier(login + "-" + u.ID.String()[:8] + "@example.com").SetUser(u).SaveX(ctx)
+		return u.ID
+	}
+	owner, admin, member, alice, bob := mkUser("owner"), mkUser("admin"), mkUser("member"), mkUser("alice"), mkUser("bob")
+	g := client.Group.Create().SetName("Design").SaveX(ctx)
+	for uid, role := range map[uuid.UUID]groupmembership.Role{owner: groupmembership.RoleOwner, admin: groupmembership.RoleAdmin, member: groupmembership.RoleMember} {
+		client.GroupMembership.Create().SetGroupID(g.ID).SetUserID(uid).SetRole(role).SaveX(ctx)
+	}
+	base := "/groups/" + g.ID.String() + "/invitations"
+	app := newInvitationsApp(client)
+
+	if code, _ := send(t, app, member, http.MethodPost, base, map[string]any{}, nil); code != http.StatusForbidden {
+		t.Fatalf("member invite: %d", code)
+	}
+	if code, _ := send(t, app, admin, http.MethodPost, base, map[string]any{"role": "admin"}, nil); code != http.StatusForbidden {
+		t.Fatalf("admin invites admin: %d", code)
+	}
+	if code, _ := send(t, app, admin, http.MethodPost, base, map[string]any{"max_uses": 0, "expires_in_hours": 1000}, nil); code != http.StatusBadRequest {
+		t.Fatalf("long expiry: %d", code)
+	}
+
+	// A two-use link
+	var link struct {
+		ID      uuid.UUID `json:"id"`
+		Token   string    `json:"token"`
+		MaxUses int       `json:"max_uses"`
+		Hash    string    `json:"token_hash"`
+	}
+	if code, _ := send(t, app, admin, http.MethodPost, base, map[string]any{"max_uses": 2}, &link); code != http.StatusCreated || link.Token == "" || link.MaxUses != 2 || link.Hash != "" {
+		t.Fatalf("create link: %d %+v", code, link)
+	}
+	accept := func(as uuid.UUID, token string) (int, string) {
+		return send(t, app, as, http.MethodPost, "/invitations/accept", map[string]string{"token": token}, nil)
+	}
+	if code, _ := accept(alice, "nope"); code != http.StatusNotFound {
+		t.Fatalf("bad token: %d", code)
+	}
+	if code, _ := accept(alice, link.Token); code != http.StatusOK {
+		t.Fatalf("alice accepts: %d", code)
+	}
+	if code, _ := accept(alice, link.Token); code != http.StatusConflict {
+		t.Fatalf("alice again: %d", code)
+	}
+	if code, _ := accept(bob, link.Token); code != http.StatusOK {
+		t.Fatalf("bob accepts: %d", code)
+	}
+	if code, errCode := accept(mkUser("carol"), link.Token); code != http.StatusGone || errCode != "E_INVITATION_USED" {
+		t.Fatalf("used up: %d %s", code, errCode)
+	}
+	if n := client.GroupMembership.Query().Where(groupmembership.GroupIDEQ(g.ID), groupmembership.RoleEQ(groupmembership.RoleMember)).CountX(ctx); n != 3 {
+		t.Fatalf("%d members", n)
+	}
+
+	// An admin invitation for one email
+	dave := client.User.Create().SetDisplayName("dave").SaveX(ctx)
+	client.Identity.Create().SetIdentifier("dave-" + dave.ID.String()[:8] + "@example.com").SetUser(dave).SaveX(ctx)
+	var personal struct {
+		ID    uuid.UUID `json:"id"`
+		Token string    `json:"token"`
+	}
+	if code, _ := send(t, app, owner, http.MethodPost, base, map[string]any{"email": "Dave-" + dave.ID.String()[:8] + "@Example.com", "role": "admin"}, &personal); code != http.StatusCreated {
+		t.Fatalf("create personal: %d", code)
+	}
+	if code, errCode := accept(mkUser("eve"), personal.Token); code != http.StatusForbidden || errCode != "E_INVITATION_RECIPIENT" {
+		t.Fatalf("wrong recipient: %d %s", code, errCode)
+	}
+	if code, _ := accept(dave.ID, personal.Token); code != http.StatusOK {
+		t.Fatalf("dave accepts: %d", code)
+	}
+	if m := client.GroupMembership.Query().Where(groupmembership.GroupIDEQ(g.ID), groupmembership.UserIDEQ(dave.ID)).OnlyX(ctx); m.Role != groupmembership.RoleAdmin {
+		t.Fatalf("dave role: %s", m.Role)
+	}
+
+	// Revoked and expired invitations
+	var revoked struct {
+		ID    uuid.UUID `json:"id"`
+		Token string    `json:"token"`
+	}
+	send(t, app, admin, http.MethodPost, base, nil, &revoked)
+	if code, _ := send(t, app, member, http.MethodDelete, base+"/"+revoked.ID.String(), nil, nil); code != http.StatusForbidden {
+		t.Fatalf("member revokes: %d", code)
+	}
+	if code, _ := send(t, app, admin, http.MethodDelete, base+"/"+personal.ID.String(), nil, nil); code != http.StatusForbidden {
+		t.Fatalf("admin revokes admin invitation: %d", code)
+	}
+	if code, _ := send(t, app, admin, http.MethodDelete, base+"/"+revoked.ID.String(), nil, nil); code != http.StatusOK {
+		t.Fatalf("revoke: %d", code)
+	}
+	if code, errCode := accept(mkUser("frank"), revoked.Token); code != http.StatusGone || errCode != "E_INVITATION_REVOKED" {
+		t.Fatalf("revoked: %d %s", code, errCode)
+	}
+	token, hash, _ := newInvitationToken()
+	client.GroupInvitation.Create().SetGroupID(g.ID).SetInviterID(owner).SetTokenHash(hash).
+		SetExpiresAt(time.Now().Add(-time.Minute)).SaveX(ctx)
+	if code, errCode := accept(mkUser("grace"), token); code != http.StatusGone || errCode != "E_INVITATION_EXPIRED" {
+		t.Fatalf("expired: %d %s", code, errCode)
+	}
+
+	// The inviter was demoted before the admin invitation was used
+	var stale struct {
+		ID    uuid.UUID `json:"id"`
+		Token string    `json:"token"`
+	}
+	send(t, app, admin, http.MethodPost, base, nil, &stale)
+	client.GroupMembership.Update().
+		Where(groupmembership.GroupIDEQ(g.ID), groupmembership.UserIDEQ(admin)).
+		SetRole(groupmembership.RoleMember).
+		ExecX(ctx)
+	heidi := mkUser("heidi")
+	if code, errCode := accept(heidi, stale.Token); code != http.StatusGone || errCode != "E_INVITATION_REVOKED" {
+		t.Fatalf("demoted inviter: %d %s", code, errCode)
+	}
+	if client.GroupMembership.Query().Where(groupmembership.GroupIDEQ(g.ID), groupmembership.UserIDEQ(heidi)).ExistX(ctx) {
+		t.Fatal("joined through a demoted inviter")
+	}
+	if inv := client.GroupInvitation.GetX(ctx, stale.ID); inv.RevokedAt == nil || inv.Uses != 0 {
+		t.Fatalf("stale invitation: revoked %v, uses %d", inv.RevokedAt, inv.Uses)
+	}
+	client.GroupMembership.Update().
+		Where(groupmembership.GroupIDEQ(g.ID), groupmembership.UserIDEQ(admin)).
+		SetRole(groupmembership.RoleAdmin).
+		ExecX(ctx)
+
+	var list []map[string]any
+	if code, _ := send(t, app, admin, http.MethodGet, base, nil, &list); code != http.StatusOK || len(list) != 5 {
+		t.Fatalf("list: %d %d", code, len(list))
+	}
+	if _, ok := list[0]["token_hash"]; ok {
+		t.Fatal("token hash listed")
+	}
+	if n := client.GroupInvitation.Query().Where(groupinvitation.IDEQ(link.ID), groupinvitation.UsesEQ(2)).CountX(ctx); n != 1 {
+		t.Fatal("link uses not counted")
+	}
+}
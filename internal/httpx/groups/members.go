@@ -0,0 +1,499 @@
+package groups
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/group"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/ent/user"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// AddMembersRequest is the request payload to add group members.
+// swagger:model AddMembersRequest
+type AddMembersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids"`
+	// Role is member (default) or admin; only the owner adds admins.
+	Role string `json:"role,omitempty"`
+}
+
+// UpdateMemberRoleRequest is the request payload to change a member's role.
+// swagger:model UpdateMemberRoleRequest
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// TransferOwnershipRequest is the request payload to transfer group ownership.
+// swagger:model TransferOwnershipRequest
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// roleOf returns the role of uid in a group: not found when the group does
+// not exist, forbidden when uid is not a member.
+func roleOf(ctx context.Context, client *ent.Client, gid, uid uuid.UUID) (groupmembership.Role, error) {
+	m, err := client.GroupMembership.Query().
+		Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(uid)).
+		Only(ctx)
+	if err == nil {
+		return m.Role, nil
+	}
+	if !ent.IsNotFound(err) {
+		return "", kit.InternalError("query membership failed", err.Error())
+	}
+	exists, err := client.Group.Query().Where(group.IDEQ(gid)).Exist(ctx)
+	if err != nil {
+		return "", kit.InternalError("query group failed", err.Error())
+	}
+	if !exists {
+		return "", kit.NotFound("group not found")
+	}
+	return "", fiber.ErrForbidden
+}
+
+// canManage reports whether a role may delete the group and manage members.
+func canManage(r groupmembership.Role) bool {
+	return r == groupmembership.RoleOwner || r == groupmembership.RoleAdmin
+}
+
+// canManageMember reports whether actor may remove or change target: owners
+// manage everyone else, admins manage plain members.
+func canManageMember(actor, target groupmembership.Role) bool {
+	switch actor {
+	case groupmembership.RoleOwner:
+		return target != groupmembership.RoleOwner
+	case groupmembership.RoleAdmin:
+		return target == groupmembership.RoleMember
+	}
+	return false
+}
+
+// parseRole accepts the roles that can be assigned directly; ownership
+// changes hands only through a transfer.
+func parseRole(s string) (groupmembership.Role, error) {
+	r := groupmembership.Role(s)
+	if r != groupmembership.RoleAdmin && r != groupmembership.RoleMember {
+		return "", kit.BadRequest("role must be admin or member", s)
+	}
+	return r, nil
+}
+
+// checkUsers fails with bad request unless all ids are existing users.
+func checkUsers(ctx context.Context, client *ent.Client, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	found, err := client.User.Query().Where(user.IDIn(ids...)).IDs(ctx)
+	if err != nil {
+		return kit.InternalError("query users failed", err.Error())
+	}
+	if len(found) == len(ids) {
+		return nil
+	}
+	known := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		known[id] = true
+	}
+	var missing []uuid.UUID
+	for _, id := range ids {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+	return kit.BadRequest("unknown users", missing)
+}
+
+// groupParams parses the caller and the group id of a group route.
+func groupParams(c *fiber.Ctx) (uid, gid uuid.UUID, err error) {
+	ac, _ := c.Locals("auth").(*mw.AuthContext)
+	if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+		return uuid.Nil, uuid.Nil, fiber.ErrUnauthorized
+	}
+	uid, err = uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.ErrUnauthorized
+	}
+	gid, err = uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, kit.BadRequest("invalid group id", c.Params("id"))
+	}
+	return uid, gid, nil
+}
+
+// ListMembersHandler lists the members of a group with their roles.
+//
+//	@Summary      List group members
+//	@Description  Members of the group with their roles, in join order (members only)
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Group UUID"
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/members [get]
+func ListMembersHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		if _, err := roleOf(ctx, client, gid, uid); err != nil {
+			return err
+		}
+		items, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(gid)).
+			WithUser().
+			Order(ent.Asc(groupmembership.FieldCreatedAt), ent.Asc(groupmembership.FieldUserID)).
+			Limit(pg.Limit).Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query members failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// AddMembersHandler adds users to a group.
+//
+//	@Summary      Add group members
+//	@Description  Add users to the group (owner or admin; only the owner adds admins). Existing members are left unchanged.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path  string                    true  "Group UUID"
+//	@Param        body  body  groups.AddMembersRequest  true  "members payload"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/members [post]
+func AddMembersHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		var req AddMembersRequest
+		if err := c.BodyParser(&req); err != nil || len(req.UserIDs) == 0 {
+			return kit.BadRequest("user_ids required", nil)
+		}
+		role := groupmembership.RoleMember
+		if req.Role != "" {
+			if role, err = parseRole(req.Role); err != nil {
+				return err
+			}
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if !canManageMember(actor, role) {
+			return fiber.ErrForbidden
+		}
+
+		ids := make([]uuid.UUID, 0, len(req.UserIDs))
+		seen := map[uuid.UUID]bool{}
+		for _, id := range req.UserIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if err := checkUsers(ctx, client, ids); err != nil {
+			return err
+		}
+		existing, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDIn(ids...)).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query members failed", err.Error())
+		}
+		for _, m := range existing {
+			seen[m.UserID] = false
+		}
+		var builders []*ent.GroupMembershipCreate
+		for _, id := range ids {
+			if seen[id] {
+				builders = append(builders, client.GroupMembership.Create().SetGroupID(gid).SetUserID(id).SetRole(role))
+			}
+		}
+		if len(builders) > 0 {
+			err := client.GroupMembership.CreateBulk(builders...).Exec(ctx)
+			if ent.IsConstraintError(err) {
+				return kit.Conflict("membership changed, retry", nil)
+			}
+			if err != nil {
+				return kit.InternalError("add members failed", err.Error())
+			}
+		}
+		members, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDIn(ids...)).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query members failed", err.Error())
+		}
+		return kit.OK(c, members)
+	}
+}
+
+// RemoveMemberHandler removes a member from a group.
+//
+//	@Summary      Remove group member
+//	@Description  Remove a member (owner: anyone but the owner; admin: plain members). Use leave to remove yourself.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id       path  string  true  "Group UUID"
+//	@Param        user_id  path  string  true  "Member User UUID"
+//	@Success      200  {object}  map[string]string
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/members/{user_id} [delete]
+func RemoveMemberHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		target, err := uuid.Parse(c.Params("user_id"))
+		if err != nil {
+			return kit.BadRequest("invalid user id", c.Params("user_id"))
+		}
+		if target == uid {
+			return kit.BadRequest("use leave to remove yourself", nil)
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if !canManage(actor) {
+			return fiber.ErrForbidden
+		}
+		m, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(target)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("member not found")
+		}
+		if !canManageMember(actor, m.Role) {
+			return fiber.ErrForbidden
+		}
+		// the role guard keeps a concurrent promotion from being removed
+		n, err := client.GroupMembership.Delete().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(target), groupmembership.RoleEQ(m.Role)).
+			Exec(ctx)
+		if err != nil {
+			return kit.InternalError("remove member failed", err.Error())
+		}
+		if n == 0 {
+			return kit.Conflict("membership changed, retry", nil)
+		}
+		return kit.OK(c, fiber.Map{"status": "ok"})
+	}
+}
+
+// UpdateMemberRoleHandler changes the role of a member.
+//
+//	@Summary      Change member role
+//	@Description  Make a member an admin or a plain member (owner only). Ownership moves through transfer.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id       path  string                          true  "Group UUID"
+//	@Param        user_id  path  string                          true  "Member User UUID"
+//	@Param        body     body  groups.UpdateMemberRoleRequest  true  "role payload"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/members/{user_id} [put]
+func UpdateMemberRoleHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		target, err := uuid.Parse(c.Params("user_id"))
+		if err != nil {
+			return kit.BadRequest("invalid user id", c.Params("user_id"))
+		}
+		var req UpdateMemberRoleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return kit.BadRequest("invalid body", nil)
+		}
+		role, err := parseRole(req.Role)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		actor, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if actor != groupmembership.RoleOwner {
+			return fiber.ErrForbidden
+		}
+		if target == uid {
+			return kit.BadRequest("transfer ownership to change your own role", nil)
+		}
+		n, err := client.GroupMembership.Update().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(target)).
+			SetRole(role).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("update role failed", err.Error())
+		}
+		if n == 0 {
+			return kit.NotFound("member not found")
+		}
+		m, err := client.GroupMembership.Query().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(target)).
+			Only(ctx)
+		if err != nil {
+			return kit.InternalError("query member failed", err.Error())
+		}
+		return kit.OK(c, m)
+	}
+}
+
+// LeaveGroupHandler removes the current user from a group.
+//
+//	@Summary      Leave group
+//	@Description  Leave the group. The owner must transfer ownership or delete the group instead.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Group UUID"
+//	@Success      200  {object}  map[string]string
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "owner cannot leave"
+//	@Router       /api/v1/groups/{id}/leave [post]
+func LeaveGroupHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+		role, err := roleOf(ctx, client, gid, uid)
+		if err != nil {
+			return err
+		}
+		if role == groupmembership.RoleOwner {
+			return kit.Conflict("the owner must transfer ownership before leaving", nil)
+		}
+		n, err := client.GroupMembership.Delete().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(uid), groupmembership.RoleNEQ(groupmembership.RoleOwner)).
+			Exec(ctx)
+		if err != nil {
+			return kit.InternalError("leave group failed", err.Error())
+		}
+		if n == 0 {
+			return kit.Conflict("the owner must transfer ownership before leaving", nil)
+		}
+		return kit.OK(c, fiber.Map{"status": "ok"})
+	}
+}
+
+// TransferOwnershipHandler makes another member the owner of a group.
+//
+//	@Summary      Transfer group ownership
+//	@Description  Make another member the owner (owner only); the previous owner becomes an admin.
+//	@Tags         groups
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path  string                           true  "Group UUID"
+//	@Param        body  body  groups.TransferOwnershipRequest  true  "new owner"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/groups/{id}/transfer [post]
+func TransferOwnershipHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, gid, err := groupParams(c)
+		if err != nil {
+			return err
+		}
+		var req TransferOwnershipRequest
+		if err := c.BodyParser(&req); err != nil || req.UserID == uuid.Nil {
+			return kit.BadRequest("user_id required", nil)
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+		role, err := roleOf(ctx, tx.Client(), gid, uid)
+		if err != nil {
+			return err
+		}
+		if role != groupmembership.RoleOwner {
+			return fiber.ErrForbidden
+		}
+		if req.UserID == uid {
+			return kit.BadRequest("already the owner", nil)
+		}
+		n, err := tx.GroupMembership.Update().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(req.UserID)).
+			SetRole(groupmembership.RoleOwner).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("transfer ownership failed", err.Error())
+		}
+		if n == 0 {
+			return kit.NotFound("member not found")
+		}
+		n, err = tx.GroupMembership.Update().
+			Where(groupmembership.GroupIDEQ(gid), groupmembership.UserIDEQ(uid), groupmembership.RoleEQ(groupmembership.RoleOwner)).
+			SetRole(groupmembership.RoleAdmin).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("transfer ownership failed", err.Error())
+		}
+		if n == 0 {
+			return kit.Conflict("ownership changed, retry", nil)
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
+		return kit.OK(c, fiber.Map{"status": "ok", "owner_id": req.UserID})
+	}
+}
@@ -0,0 +1,150 @@
+package groups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/groupmembership"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// newMembersApp mounts the group routes; requests act as the user in X-User.
+func newMembersApp(client *ent.Client) *fiber.App {
+	return testutil.NewApp(
+		func(app *fiber.App) {
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("auth", &mw.AuthContext{Subject: "user:" + c.Get("X-User"), Kind: "user"})
+				return c.Next()
+			})
+		},
+		func(app *fiber.App) {
+			app.Post("/groups", CreateGroupHandler(client))
+			app.Delete("/groups/:id", DeleteGroupHandler(client))
+			app.Get("/groups/:id/members", ListMembersHandler(client))
+			app.Post("/groups/:id/members", AddMembersHandler(client))
+			app.Put("/groups/:id/members/:user_id", UpdateMemberRoleHandler(client))
+			app.Delete("/groups/:id/members/:user_id", RemoveMemberHandler(client))
+			app.Post("/groups/:id/leave", LeaveGroupHandler(client))
+			app.Post("/groups/:id/transfer", TransferOwnershipHandler(client))
+		},
+	)
+}
+
+func call(t *testing.T, app *fiber.App, as uuid.UUID, method, target string, body any) int {
+	t.Helper()
+	var r *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		r = bytes.NewReader(b)
+	} else {
+		r = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, r)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", as.String())
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	return res.StatusCode
+}
+
+func TestGroupMembers_Roles(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	users := make([]*ent.User, 5)
+	for i := range users {
+		users[i] = client.User.Create().SetDisplayName("U").SaveX(ctx)
+	}
+	owner, admin, member, other, outsider := users[0].ID, users[1].ID, users[2].ID, users[3].ID, users[4].ID
+	app := newMembersApp(client)
+
+	// The creator owns the group
+	b, _ := json.Marshal(map[string]any{"name": "Design", "member_ids": []uuid.UUID{admin, member, admin}})
+	req := httptest.NewRequest(http.MethodPost, "/groups", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", owner.String())
+	res, _ := app.Test(req)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %d", res.StatusCode)
+	}
+	var env struct{ Data struct{ ID uuid.UUID } }
+	_ = json.NewDecoder(res.Body).Decode(&env)
+	g := "/groups/" + env.Data.ID.String()
+	roleOf := func(uid uuid.UUID) groupmembership.Role {
+		m, err := client.GroupMembership.Query().Where(groupmembership.GroupIDEQ(env.Data.ID), groupmembership.UserIDEQ(uid)).Only(ctx)
+		if err != nil {
+			return ""
+		}
+		return m.Role
+	}
+	if roleOf(owner) != groupmembership.RoleOwner || roleOf(admin) != groupmembership.RoleMember {
+		t.Fatalf("roles after create: %s %s", roleOf(owner), roleOf(admin))
+	}
+
+	steps := []struct {
+		name   string
+		as     uuid.UUID
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"member cannot promote", member, http.MethodPut, g + "/members/" + admin.String(), map[string]string{"role": "admin"}, http.StatusForbidden},
+		{"owner promotes", owner, http.MethodPut, g + "/members/" + admin.String(), map[string]string{"role": "admin"}, http.StatusOK},
+		{"no owner role", owner, http.MethodPut, g + "/members/" + member.String(), map[string]string{"role": "owner"}, http.StatusBadRequest},
+		{"member cannot add", member, http.MethodPost, g + "/members", map[string]any{"user_ids": []uuid.UUID{other}}, http.StatusForbidden},
+		{"admin cannot add admins", admin, http.MethodPost, g + "/members", map[string]any{"user_ids": []uuid.UUID{other}, "role": "admin"}, http.StatusForbidden},
+		{"unknown user", admin, http.MethodPost, g + "/members", map[string]any{"user_ids": []uuid.UUID{uuid.New()}}, http.StatusBadRequest},
+		{"admin adds", admin, http.MethodPost, g + "/members", map[string]any{"user_ids": []uuid.UUID{other, member}}, http.StatusOK},
+		{"outsider cannot list", outsider, http.MethodGet, g + "/members", nil, http.StatusForbidden},
+		{"admin cannot remove owner", admin, http.MethodDelete, g + "/members/" + owner.String(), nil, http.StatusForbidden},
+		{"admin removes member", admin, http.MethodDelete, g + "/members/" + other.String(), nil, http.StatusOK},
+		{"removed is gone", admin, http.MethodDelete, g + "/members/" + other.String(), nil, http.StatusNotFound},
+		{"owner cannot leave", owner, http.MethodPost, g + "/leave", nil, http.StatusConflict},
+		{"transfer to non-member", owner, http.MethodPost, g + "/transfer", map[string]any{"user_id": outsider}, http.StatusNotFound},
+		{"admin cannot transfer", admin, http.MethodPost, g + "/transfer", map[string]any{"user_id": admin}, http.StatusForbidden},
+		{"owner transfers", owner, http.MethodPost, g + "/transfer", map[string]any{"user_id": member}, http.StatusOK},
+		{"former owner leaves", owner, http.MethodPost, g + "/leave", nil, http.StatusOK},
+		{"member cannot delete", outsider, http.MethodDelete, g, nil, http.StatusForbidden},
+	}
+	for _, s := range steps {
+		if got := call(t, app, s.as, s.method, s.path, s.body); got != s.want {
+			t.Fatalf("%s: status %d, want %d", s.name, got, s.want)
+		}
+	}
+	if roleOf(member) != groupmembership.RoleOwner || roleOf(admin) != groupmembership.RoleAdmin || roleOf(owner) != "" || roleOf(other) != "" {
+		t.Fatalf("roles: member=%s admin=%s owner=%s other=%s", roleOf(member), roleOf(admin), roleOf(owner), roleOf(other))
+	}
+
+	var list struct {
+		Data []struct {
+			UserID uuid.UUID `json:"user_id"`
+			Role   string    `json:"role"`
+		}
+	}
+	lreq := httptest.NewRequest(http.MethodGet, g+"/members", nil)
+	lreq.Header.Set("X-User", admin.String())
+	lres, _ := app.Test(lreq)
+	_ = json.NewDecoder(lres.Body).Decode(&list)
+	if len(list.Data) != 2 {
+		t.Fatalf("members: %+v", list.Data)
+	}
+
+	// Admins may delete the group; memberships go with it
+	if got := call(t, app, admin, http.MethodDelete, g, nil); got != http.StatusOK {
+		t.Fatalf("admin delete: %d", got)
+	}
+	if n := client.GroupMembership.Query().Where(groupmembership.GroupIDEQ(env.Data.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("%d memberships left", n)
+	}
+}
@@ -0,0 +1,2 @@
+// Package integrations provides HTTP handlers called by third-party services.
+package integrations
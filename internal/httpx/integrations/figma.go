@@ -0,0 +1,90 @@
+package integrations
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+)
+
+// FigmaWebhookResult reports what a webhook event triggered.
+type FigmaWebhookResult struct {
+	EventType string `json:"event_type"`
+	// Projects is the number of opted-in projects an export was scheduled for.
+	Projects int `json:"projects"`
+	// Ignored is set for event types that trigger nothing.
+	Ignored bool `json:"ignored,omitempty"`
+}
+
+// FigmaWebhookHandler receives Figma webhook events. FILE_UPDATE and
+// LIBRARY_PUBLISH events schedule an export of every project backed by the
+// file that has auto_export set; auto debounces bursts of events.
+//
+//	@Summary      Figma webhook
+//	@Description  Receiver for Figma webhooks (v2). The body passcode must match FIGMA_WEBHOOK_PASSCODE. FILE_UPDATE and LIBRARY_PUBLISH schedule an export with the active config of each project whose file or branch key matches file_key and that has auto_export set; events of a project arriving within the debounce window trigger one export. PING and other events are acknowledged and ignored.
+//	@Tags         integrations
+//	@Accept       json
+//	@Produce      json
+//	@Param        body  body  figmax.WebhookEvent  true  "webhook event"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}  "passcode mismatch"
+//	@Failure      503  {object}  map[string]interface{}  "webhooks are not configured"
+//	@Router       /api/v1/integrations/figma/webhook [post]
+func FigmaWebhookHandler(client *ent.Client, auto *exportx.AutoExporter, passcode string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if passcode == "" || auto == nil {
+			return kit.NewAPIError(http.StatusServiceUnavailable, "E_WEBHOOK_DISABLED", "figma webhooks are not configured", nil)
+		}
+		var ev figmax.WebhookEvent
+		if err := json.Unmarshal(c.Body(), &ev); err != nil {
+			return kit.BadRequest("invalid webhook payload", nil)
+		}
+		if subtle.ConstantTimeCompare([]byte(ev.Passcode), []byte(passcode)) != 1 {
+			return kit.NewAPIError(http.StatusUnauthorized, "E_INVALID_PASSCODE", "webhook passcode mismatch", nil)
+		}
+
+		res := FigmaWebhookResult{EventType: ev.EventType}
+		switch ev.EventType {
+		case figmax.EventFileUpdate, figmax.EventLibraryPublish:
+		default:
+			res.Ignored = true
+			return kit.OK(c, res)
+		}
+		if ev.FileKey == "" {
+			return kit.BadRequest("file_key required", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		ids, err := client.Project.Query().
+			Where(
+				project.AutoExportEQ(true),
+				// events of a branch carry the branch key; those of the main
+				// file do not concern projects tracking one of its branches
+				project.Or(
+					project.And(project.FileKeyEQ(ev.FileKey), project.Or(project.BranchKeyIsNil(), project.BranchKeyEQ(""))),
+					project.BranchKeyEQ(ev.FileKey),
+				),
+			).
+			IDs(ctx)
+		if err != nil {
+			return kit.InternalError("query projects failed", err.Error())
+		}
+		for _, id := range ids {
+			auto.Notify(id)
+		}
+		res.Projects = len(ids)
+		return kit.OK(c, res)
+	}
+}
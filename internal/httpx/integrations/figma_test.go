@@ -0,0 +1,126 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/mqx"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	// One database per test, so repeated runs do not see each other's rows
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	t.Cleanup(func() { _ = client.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+func post(t *testing.T, app *fiber.App, body any) (int, FigmaWebhookResult) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	var env struct {
+		Data FigmaWebhookResult `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&env)
+	return resp.StatusCode, env.Data
+}
+
+func TestFigmaWebhook(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	u := client.User.Create().SetDisplayName("Designer").SaveX(ctx)
+	cfg := client.ConfigItem.Create().SetName("c").SetData(map[string]any{"format": "png"}).SetOwnerID(u.ID).SaveX(ctx)
+	newProject := func(url, fileKey, branchKey string, auto bool) *ent.Project {
+		p := client.Project.Create().SetName("p").SetURL(url).SetFileKey(fileKey).SetBranchKey(branchKey).
+			SetAutoExport(auto).SetOwnerID(u.ID).SaveX(ctx)
+		client.ProjectConfig.Create().SetProjectID(p.ID).SetConfigItemID(cfg.ID).SetActive(true).SaveX(ctx)
+		return p
+	}
+	key := func() string { return strings.ReplaceAll(uuid.NewString(), "-", "")[:22] }
+	libKey, branchKey, otherKey := key(), key(), key()
+	lib := newProject("https://www.figma.com/design/"+libKey, libKey, "", true)
+	branch := newProject("https://www.figma.com/design/"+libKey+"/branch/"+branchKey, libKey, branchKey, true)
+	manual := newProject("https://www.figma.com/design/"+otherKey, otherKey, "", false)
+
+	bus := mqx.NewLocalBus(1)
+	auto := exportx.NewAutoExporter(exportx.NewService(client, bus, nil), 30*time.Millisecond)
+	app := testutil.NewApp(func(app *fiber.App) {
+		app.Post("/webhook", FigmaWebhookHandler(client, auto, "s3cret"))
+	})
+
+	if code, _ := post(t, app, map[string]any{"event_type": "FILE_UPDATE", "passcode": "wrong", "file_key": libKey}); code != http.StatusUnauthorized {
+		t.Fatalf("bad passcode: %d", code)
+	}
+	if code, res := post(t, app, map[string]any{"event_type": "PING", "passcode": "s3cret"}); code != http.StatusOK || !res.Ignored {
+		t.Fatalf("ping: %d %+v", code, res)
+	}
+	if code, _ := post(t, app, map[string]any{"event_type": "LIBRARY_PUBLISH", "passcode": "s3cret"}); code != http.StatusBadRequest {
+		t.Fatalf("missing file key: %d", code)
+	}
+	for i := 0; i < 3; i++ {
+		if code, res := post(t, app, map[string]any{"event_type": "FILE_UPDATE", "passcode": "s3cret", "file_key": libKey}); code != http.StatusOK || res.Projects != 1 {
+			t.Fatalf("file update: %d %+v", code, res)
+		}
+	}
+	if code, res := post(t, app, map[string]any{"event_type": "LIBRARY_PUBLISH", "passcode": "s3cret", "file_key": branchKey}); code != http.StatusOK || res.Projects != 1 {
+		t.Fatalf("branch publish: %d %+v", code, res)
+	}
+	if code, res := post(t, app, map[string]any{"event_type": "FILE_UPDATE", "passcode": "s3cret", "file_key": otherKey}); code != http.StatusOK || res.Projects != 0 {
+		t.Fatalf("opted out: %d %+v", code, res)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	auto.Close()
+	for _, tc := range []struct {
+		p    *ent.Project
+		jobs int
+	}{{lib, 1}, {branch, 1}, {manual, 0}} {
+		n := client.ExportJob.Query().Where(exportjob.ProjectIDEQ(tc.p.ID), exportjob.TriggerEQ(exportjob.TriggerWebhook)).CountX(ctx)
+		if n != tc.jobs {
+			t.Errorf("project %s: %d jobs, want %d", tc.p.FileKey, n, tc.jobs)
+		}
+	}
+}
+
+func TestFigmaWebhook_Disabled(t *testing.T) {
+	app := testutil.NewApp(func(app *fiber.App) {
+		app.Post("/webhook", FigmaWebhookHandler(nil, nil, ""))
+	})
+	if code, _ := post(t, app, map[string]any{"event_type": "PING"}); code != http.StatusServiceUnavailable {
+		t.Fatalf("disabled: %d", code)
+	}
+}
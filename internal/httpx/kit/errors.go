@@ -30,6 +30,16 @@ func BadRequest(msg string, details interface{}) error {
 // NotFound creates a 404 Not Found error
 func NotFound(msg string) error { return NewAPIError(http.StatusNotFound, "E_NOT_FOUND", msg, nil) }
 
+// Conflict creates a 409 Conflict error
+func Conflict(msg string, details interface{}) error {
+	return NewAPIError(http.StatusConflict, "E_CONFLICT", msg, details)
+}
+
+// PreconditionFailed creates a 412 Precondition Failed error
+func PreconditionFailed(msg string, details interface{}) error {
+	return NewAPIError(http.StatusPreconditionFailed, "E_PRECONDITION_FAILED", msg, details)
+}
+
 // InternalError creates a 500 Internal Server Error
 func InternalError(msg string, details interface{}) error {
 	return NewAPIError(http.StatusInternalServerError, "E_INTERNAL", msg, details)
@@ -78,6 +88,12 @@ func httpStatusToCode(status int) string {
 		return "E_UNAUTHORIZED"
 	case http.StatusForbidden:
 		return "E_FORBIDDEN"
+	case http.StatusConflict:
+		return "E_CONFLICT"
+	case http.StatusPreconditionFailed:
+		return "E_PRECONDITION_FAILED"
+	case http.StatusUnsupportedMediaType:
+		return "E_UNSUPPORTED_MEDIA_TYPE"
 	default:
 		if status >= 500 {
 			return "E_INTERNAL"
@@ -0,0 +1,71 @@
+package kit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionETag formats a strong entity tag for a version counter.
+func VersionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// HashETag formats a strong entity tag from a digest of the given parts.
+func HashETag(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		_, _ = h.Write([]byte(p))
+		_, _ = h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// SetETag sets the ETag response header.
+func SetETag(c *fiber.Ctx, etag string) {
+	c.Set(fiber.HeaderETag, etag)
+}
+
+// HasIfMatch reports whether the request carries an If-Match precondition.
+func HasIfMatch(c *fiber.Ctx) bool {
+	return strings.TrimSpace(c.Get(fiber.HeaderIfMatch)) != ""
+}
+
+// CheckIfMatch evaluates the If-Match header against the current entity tag
+// using strong comparison. A missing header always passes.
+func CheckIfMatch(c *fiber.Ctx, current string) error {
+	h := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if h == "" || h == "*" {
+		return nil
+	}
+	for _, tag := range strings.Split(h, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, "W/") && tag == current {
+			return nil
+		}
+	}
+	return PreconditionFailed("resource has been modified", fiber.Map{"etag": current})
+}
+
+// IfNoneMatch reports whether the If-None-Match header matches the current
+// entity tag using weak comparison, i.e. the client's copy is still fresh and
+// a 304 Not Modified can be sent.
+func IfNoneMatch(c *fiber.Ctx, current string) bool {
+	h := strings.TrimSpace(c.Get(fiber.HeaderIfNoneMatch))
+	if h == "" {
+		return false
+	}
+	if h == "*" {
+		return true
+	}
+	current = strings.TrimPrefix(current, "W/")
+	for _, tag := range strings.Split(h, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package kit
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	cases := []struct {
+		header string
+		ok     bool
+	}{
+		{"", true},
+		{"*", true},
+		{`"3"`, true},
+		{`"1", "3"`, true},
+		{`"2"`, false},
+		{`W/"3"`, false},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		var got error
+		app.Put("/t", func(c *fiber.Ctx) error {
+			got = CheckIfMatch(c, VersionETag(3))
+			return nil
+		})
+		req := httptest.NewRequest("PUT", "/t", nil)
+		if tc.header != "" {
+			req.Header.Set("If-Match", tc.header)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("request err: %v", err)
+		}
+		if tc.ok != (got == nil) {
+			t.Fatalf("If-Match %q: got %v", tc.header, got)
+		}
+		var apiErr *APIError
+		if got != nil && (!errors.As(got, &apiErr) || apiErr.HTTPStatus != 412 || apiErr.Code != "E_PRECONDITION_FAILED") {
+			t.Fatalf("If-Match %q: unexpected error %#v", tc.header, got)
+		}
+	}
+}
+
+func TestHashETag_Stable(t *testing.T) {
+	if HashETag("a", "b") != HashETag("a", "b") {
+		t.Fatalf("hash etag not stable")
+	}
+	if HashETag("ab") == HashETag("a", "b") {
+		t.Fatalf("hash etag parts not delimited")
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	cases := map[string]bool{
+		"":               false,
+		"*":              true,
+		`"a"`:            true,
+		`W/"a"`:          true,
+		`"b", W/"a"`:     true,
+		`"b"`:            false,
+		`"a-other-repr"`: false,
+	}
+	for header, want := range cases {
+		app := fiber.New()
+		var got bool
+		app.Get("/t", func(c *fiber.Ctx) error {
+			got = IfNoneMatch(c, `"a"`)
+			return nil
+		})
+		req := httptest.NewRequest("GET", "/t", nil)
+		if header != "" {
+			req.Header.Set("If-None-Match", header)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("request err: %v", err)
+		}
+		if got != want {
+			t.Fatalf("If-None-Match %q: got %v, want %v", header, got, want)
+		}
+	}
+}
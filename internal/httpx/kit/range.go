@@ -0,0 +1,73 @@
+package kit
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrRangeNotSatisfiable is returned by ParseRange when no requested byte is
+// inside the resource.
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// ByteRange is a resolved byte range of a resource.
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange formats the Content-Range header value for r.
+func (r ByteRange) ContentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(r.Start, 10) + "-" + strconv.FormatInt(r.Start+r.Length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// ParseRange parses a Range header for a resource of size bytes. Only a
+// single byte range is supported: a missing, malformed or multi-range header
+// yields nil, meaning the full content should be sent.
+func ParseRange(header string, size int64) (*ByteRange, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+	if first == "" {
+		// suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, ErrRangeNotSatisfiable
+		}
+		n = min(n, size)
+		return &ByteRange{Start: size - n, Length: n}, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return nil, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, ErrRangeNotSatisfiable
+	}
+	return &ByteRange{Start: start, Length: end - start + 1}, nil
+}
+
+// IfRange reports whether a Range request may be honored given the If-Range
+// header: it must be absent or strongly match the current entity tag. Dates
+// are not supported and never match.
+func IfRange(c *fiber.Ctx, current string) bool {
+	h := strings.TrimSpace(c.Get(fiber.HeaderIfRange))
+	return h == "" || (!strings.HasPrefix(h, "W/") && h == current)
+}
@@ -0,0 +1,39 @@
+package kit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header string
+		want   *ByteRange
+		err    error
+	}{
+		{"", nil, nil},
+		{"bytes=0-3", &ByteRange{0, 4}, nil},
+		{"bytes=5-", &ByteRange{5, 5}, nil},
+		{"bytes=8-100", &ByteRange{8, 2}, nil},
+		{"bytes=-3", &ByteRange{7, 3}, nil},
+		{"bytes=-30", &ByteRange{0, 10}, nil},
+		{"bytes=10-", nil, ErrRangeNotSatisfiable},
+		{"bytes=-0", nil, ErrRangeNotSatisfiable},
+		{"bytes=0-1,4-5", nil, nil},
+		{"bytes=4-2", nil, nil},
+		{"items=0-1", nil, nil},
+		{"bytes=a-b", nil, nil},
+	}
+	for _, tc := range cases {
+		got, err := ParseRange(tc.header, 10)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("ParseRange(%q) err = %v, want %v", tc.header, err, tc.err)
+		}
+		if (got == nil) != (tc.want == nil) || got != nil && *got != *tc.want {
+			t.Fatalf("ParseRange(%q) = %+v, want %+v", tc.header, got, tc.want)
+		}
+	}
+	if got := (ByteRange{Start: 2, Length: 3}).ContentRange(10); got != "bytes 2-4/10" {
+		t.Fatalf("ContentRange = %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package kit
+
+import (
+	"errors"
+	"net/http"
+
+	"fiber-ent-apollo-pg/internal/configx"
+)
+
+// ResolveError maps errors from resolving a config's parent chain, or a
+// project's active config, to API errors.
+func ResolveError(err error) error {
+	if errors.Is(err, configx.ErrNoActiveConfig) {
+		return NotFound("project has no active config")
+	}
+	var ce *configx.CycleError
+	if errors.As(err, &ce) {
+		return Conflict("config parents form a cycle", ce.Path)
+	}
+	var pe *configx.ParentError
+	if errors.As(err, &pe) {
+		return NewAPIError(http.StatusUnprocessableEntity, "E_RESOLVE_FAILED", "parent config not found or not visible", pe.ID)
+	}
+	if errors.Is(err, configx.ErrTooManyLayers) {
+		return NewAPIError(http.StatusUnprocessableEntity, "E_RESOLVE_FAILED", err.Error(), nil)
+	}
+	return InternalError("resolve config failed", err.Error())
+}
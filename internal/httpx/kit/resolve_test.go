@@ -0,0 +1,34 @@
+package kit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/configx"
+)
+
+func TestResolveError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{configx.ErrNoActiveConfig, http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", &configx.CycleError{Path: []uuid.UUID{uuid.New()}}), http.StatusConflict},
+		{&configx.ParentError{ID: uuid.New(), Err: errors.New("not found")}, http.StatusUnprocessableEntity},
+		{configx.ErrTooManyLayers, http.StatusUnprocessableEntity},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		var apiErr *APIError
+		if !errors.As(ResolveError(tc.err), &apiErr) {
+			t.Fatalf("%v: not an APIError", tc.err)
+		}
+		if apiErr.HTTPStatus != tc.status {
+			t.Fatalf("%v: status %d, want %d", tc.err, apiErr.HTTPStatus, tc.status)
+		}
+	}
+}
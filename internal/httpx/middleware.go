@@ -21,7 +21,7 @@ var httpxLogger = logx.GetScope("httpx")
 func RegisterCommonMiddlewares(app *fiber.App) {
 	app.Use(recover.New())
 	app.Use(requestid.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{ExposeHeaders: fiber.HeaderETag}))
 
 	// Structured access log
 	app.Use(func(c *fiber.Ctx) error {
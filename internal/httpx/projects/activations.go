@@ -0,0 +1,267 @@
+package projects
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/ent/projectconfig"
+	"fiber-ent-apollo-pg/ent/projectconfigactivation"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// activate makes the association of configID the only active config of a project
+// and records the change. A nil configID leaves the project without an active
+// config. The returned activation is nil when the state did not change.
+func activate(ctx context.Context, tx *ent.Tx, projID, actorID uuid.UUID, configID, rollbackOf *uuid.UUID) (*ent.ProjectConfig, *ent.ProjectConfigActivation, error) {
+	var target *ent.ProjectConfig
+	if configID != nil {
+		pc, err := tx.ProjectConfig.Query().
+			Where(projectconfig.And(
+				projectconfig.HasProjectWith(project.IDEQ(projID)),
+				projectconfig.HasConfigItemWith(configitem.IDEQ(*configID)),
+			)).
+			Only(ctx)
+		if err != nil {
+			return nil, nil, kit.NotFound("project config association not found")
+		}
+		target = pc
+	}
+
+	from, err := activeConfigID(ctx, tx, projID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sameConfig(from, configID) {
+		return target, nil, nil
+	}
+
+	// Both writes are guarded by the state read above, so a request racing
+	// another activation fails instead of recording a stale change.
+	deactivate := tx.ProjectConfig.Update().
+		Where(projectconfig.HasProjectWith(project.IDEQ(projID)), projectconfig.ActiveEQ(true))
+	if target != nil {
+		deactivate = deactivate.Where(projectconfig.IDNEQ(target.ID))
+	}
+	n, err := deactivate.SetActive(false).Save(ctx)
+	if err != nil {
+		return nil, nil, activeConfigError(err, "deactivate configs failed")
+	}
+	if from != nil && n == 0 {
+		return nil, nil, errActiveChanged()
+	}
+	if target != nil {
+		target, err = tx.ProjectConfig.UpdateOneID(target.ID).
+			Where(projectconfig.ActiveEQ(false)).
+			SetActive(true).
+			Save(ctx)
+		if ent.IsNotFound(err) {
+			return nil, nil, errActiveChanged()
+		}
+		if err != nil {
+			return nil, nil, activeConfigError(err, "activate config failed")
+		}
+	}
+
+	act, err := recordActivation(ctx, tx, projID, actorID, from, configID, rollbackOf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return target, act, nil
+}
+
+// activeConfigID returns the config id of a project's active association, or nil.
+func activeConfigID(ctx context.Context, tx *ent.Tx, projID uuid.UUID) (*uuid.UUID, error) {
+	id, err := tx.ConfigItem.Query().
+		Where(configitem.HasProjectConfigsWith(
+			projectconfig.HasProjectWith(project.IDEQ(projID)),
+			projectconfig.ActiveEQ(true),
+		)).
+		FirstID(ctx)
+	if ent.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, kit.InternalError("query active config failed", err.Error())
+	}
+	return &id, nil
+}
+
+// recordActivation appends an entry to a project's activation history.
+func recordActivation(ctx context.Context, tx *ent.Tx, projID, actorID uuid.UUID, from, to, rollbackOf *uuid.UUID) (*ent.ProjectConfigActivation, error) {
+	act, err := tx.ProjectConfigActivation.Create().
+		SetProjectID(projID).
+		SetActorID(actorID).
+		SetNillableFromConfigID(from).
+		SetNillableToConfigID(to).
+		SetNillableRollbackOfID(rollbackOf).
+		Save(ctx)
+	if err != nil {
+		return nil, kit.InternalError("record activation failed", err.Error())
+	}
+	return act, nil
+}
+
+func sameConfig(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ListActivationsHandler lists the active config changes of a project.
+//
+//	@Summary      List active config history
+//	@Description  Changes of the project's active config, newest first (owner only). A null from_config_id or to_config_id means the project had no active config.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Project UUID"
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/activations [get]
+func ListActivationsHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+		if err != nil {
+			return kit.NotFound("project not found")
+		}
+		if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
+			return fiber.ErrForbidden
+		}
+
+		items, err := client.ProjectConfigActivation.Query().
+			Where(projectconfigactivation.ProjectIDEQ(projID)).
+			Order(ent.Desc(projectconfigactivation.FieldCreatedAt)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query activations failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// RollbackActivationHandler restores the active config a project had before an activation.
+//
+//	@Summary      Roll back active config change
+//	@Description  Re-activate the config that was active before the given change (owner only). The rollback is itself recorded in the history.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Project UUID"
+//	@Param        aid  path  string  true  "Activation UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/activations/{aid}/rollback [post]
+func RollbackActivationHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		actID, err := uuid.Parse(c.Params("aid"))
+		if err != nil {
+			return kit.BadRequest("invalid activation id", c.Params("aid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+		defer cancel()
+
+		proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+		if err != nil {
+			return kit.NotFound("project not found")
+		}
+		if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
+			return fiber.ErrForbidden
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		prev, err := tx.ProjectConfigActivation.Query().
+			Where(projectconfigactivation.IDEQ(actID), projectconfigactivation.ProjectIDEQ(projID)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("activation not found")
+		}
+		if prev.FromConfigID != nil {
+			ok, err := tx.ProjectConfig.Query().
+				Where(projectconfig.And(
+					projectconfig.HasProjectWith(project.IDEQ(projID)),
+					projectconfig.HasConfigItemWith(configitem.IDEQ(*prev.FromConfigID)),
+				)).
+				Exist(ctx)
+			if err != nil {
+				return kit.InternalError("check project config failed", err.Error())
+			}
+			if !ok {
+				return kit.Conflict("previous config is no longer associated with this project", prev.FromConfigID)
+			}
+		}
+
+		_, act, err := activate(ctx, tx, projID, ownerID, prev.FromConfigID, &prev.ID)
+		if err != nil {
+			return err
+		}
+		if act == nil {
+			return kit.Conflict("project already has this active config", prev.FromConfigID)
+		}
+		if err := tx.Commit(); err != nil {
+			return activeConfigError(err, "commit failed")
+		}
+		return kit.OK(c, act)
+	}
+}
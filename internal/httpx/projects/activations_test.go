@@ -0,0 +1,213 @@
+package projects
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/hook"
+	"fiber-ent-apollo-pg/ent/projectconfig"
+	"fiber-ent-apollo-pg/ent/projectconfigactivation"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+type activationList struct {
+	Data []ent.ProjectConfigActivation `json:"data"`
+}
+
+func TestActivations_List_Rollback(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	other := client.User.Create().SetDisplayName("Other").SaveX(ctx)
+
+	routes := func(app *fiber.App) {
+		app.Post("/projects/:id/configs", mw.RequireUser(), AddConfigToProjectHandler(client))
+		app.Delete("/projects/:id/configs/:config_id", mw.RequireUser(), RemoveConfigFromProjectHandler(client))
+		app.Put("/projects/:id/active-config", mw.RequireUser(), SetActiveConfigHandler(client))
+		app.Get("/projects/:id/activations", mw.RequireUser(), ListActivationsHandler(client))
+		app.Post("/projects/:id/activations/:aid/rollback", mw.RequireUser(), RollbackActivationHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	base := "/projects/" + p.ID.String()
+
+	c1 := client.ConfigItem.Create().SetName("c1").SetData(map[string]any{}).SetOwnerID(owner.ID).SaveX(ctx)
+	c2 := client.ConfigItem.Create().SetName("c2").SetData(map[string]any{}).SetOwnerID(owner.ID).SaveX(ctx)
+	if res := doJSON(t, app, http.MethodPost, base+"/configs", map[string]any{"config_id": c1.ID, "active": true}, nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("add c1: expected 201, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPost, base+"/configs", map[string]any{"config_id": c2.ID}, nil); res.StatusCode != http.StatusCreated {
+		t.Fatalf("add c2: expected 201, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPut, base+"/active-config", map[string]any{"config_id": c2.ID}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("activate c2: expected 200, got %d", res.StatusCode)
+	}
+	// Re-activating the active config is not a change
+	if res := doJSON(t, app, http.MethodPut, base+"/active-config", map[string]any{"config_id": c2.ID}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("re-activate c2: expected 200, got %d", res.StatusCode)
+	}
+
+	var list activationList
+	if res := doJSON(t, app, http.MethodGet, base+"/activations", nil, &list); res.StatusCode != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", res.StatusCode)
+	}
+	if len(list.Data) != 2 {
+		t.Fatalf("list: expected 2 activations, got %d", len(list.Data))
+	}
+	latest, first := list.Data[0], list.Data[1]
+	if first.FromConfigID != nil || first.ToConfigID == nil || *first.ToConfigID != c1.ID {
+		t.Fatalf("first activation: %+v", first)
+	}
+	if latest.FromConfigID == nil || *latest.FromConfigID != c1.ID || *latest.ToConfigID != c2.ID || latest.ActorID != owner.ID {
+		t.Fatalf("latest activation: %+v", latest)
+	}
+	list = activationList{}
+	doJSON(t, app, http.MethodGet, base+"/activations?limit=1&offset=1", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != first.ID {
+		t.Fatalf("second page: %+v", list.Data)
+	}
+
+	// Rolling back the switch to c2 re-activates c1 and is recorded
+	var rolled struct {
+		Data ent.ProjectConfigActivation `json:"data"`
+	}
+	if res := doJSON(t, app, http.MethodPost, base+"/activations/"+latest.ID.String()+"/rollback", nil, &rolled); res.StatusCode != http.StatusOK {
+		t.Fatalf("rollback: expected 200, got %d", res.StatusCode)
+	}
+	if rolled.Data.RollbackOfID == nil || *rolled.Data.RollbackOfID != latest.ID || *rolled.Data.FromConfigID != c2.ID || *rolled.Data.ToConfigID != c1.ID {
+		t.Fatalf("rollback activation: %+v", rolled.Data)
+	}
+	if id := activeConfigOf(t, client, p.ID); id == nil || *id != c1.ID {
+		t.Fatalf("after rollback: active %v, want %s", id, c1.ID)
+	}
+
+	// Rolling back the first activation leaves the project without an active config
+	if res := doJSON(t, app, http.MethodPost, base+"/activations/"+first.ID.String()+"/rollback", nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("rollback to none: expected 200, got %d", res.StatusCode)
+	}
+	if id := activeConfigOf(t, client, p.ID); id != nil {
+		t.Fatalf("after rollback to none: active %s", id)
+	}
+
+	// A previous config removed from the project cannot be restored
+	if res := doJSON(t, app, http.MethodPost, base+"/activations/"+rolled.Data.ID.String()+"/rollback", nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("rollback to c2: expected 200, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodDelete, base+"/configs/"+c1.ID.String(), nil, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("remove c1: expected 200, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPost, base+"/activations/"+latest.ID.String()+"/rollback", nil, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("rollback to removed config: expected 409, got %d", res.StatusCode)
+	}
+
+	// Unknown, malformed and foreign activation ids
+	_, foreign := newProject(t, client)
+	fc := client.ConfigItem.Create().SetName("f").SetData(map[string]any{}).SetOwnerID(owner.ID).SaveX(ctx)
+	client.ProjectConfig.Create().SetProjectID(foreign.ID).SetConfigItemID(fc.ID).SetActive(true).ExecX(ctx)
+	foreignAct := client.ProjectConfigActivation.Create().SetProjectID(foreign.ID).SetActorID(owner.ID).SetToConfigID(fc.ID).SaveX(ctx)
+	for aid, want := range map[string]int{
+		uuid.NewString():       http.StatusNotFound,
+		"nope":                 http.StatusBadRequest,
+		foreignAct.ID.String(): http.StatusNotFound,
+	} {
+		if res := doJSON(t, app, http.MethodPost, base+"/activations/"+aid+"/rollback", nil, nil); res.StatusCode != want {
+			t.Fatalf("rollback %s: expected %d, got %d", aid, want, res.StatusCode)
+		}
+	}
+
+	// Only the owner sees or rolls back the history
+	otherApp := testutil.NewApp(asUser(other.ID), routes)
+	if res := doJSON(t, otherApp, http.MethodGet, base+"/activations", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other list: expected 403, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, otherApp, http.MethodPost, base+"/activations/"+first.ID.String()+"/rollback", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other rollback: expected 403, got %d", res.StatusCode)
+	}
+}
+
+func TestActivations_ConcurrentRollback(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+
+	routes := func(app *fiber.App) {
+		app.Post("/projects/:id/configs", mw.RequireUser(), AddConfigToProjectHandler(client))
+		app.Put("/projects/:id/active-config", mw.RequireUser(), SetActiveConfigHandler(client))
+		app.Post("/projects/:id/activations/:aid/rollback", mw.RequireUser(), RollbackActivationHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	base := "/projects/" + p.ID.String()
+
+	c1 := client.ConfigItem.Create().SetName("c1").SetData(map[string]any{}).SetOwnerID(owner.ID).SaveX(ctx)
+	c2 := client.ConfigItem.Create().SetName("c2").SetData(map[string]any{}).SetOwnerID(owner.ID).SaveX(ctx)
+	doJSON(t, app, http.MethodPost, base+"/configs", map[string]any{"config_id": c1.ID, "active": true}, nil)
+	doJSON(t, app, http.MethodPost, base+"/configs", map[string]any{"config_id": c2.ID}, nil)
+	doJSON(t, app, http.MethodPut, base+"/active-config", map[string]any{"config_id": c2.ID}, nil)
+	target := client.ProjectConfigActivation.Query().
+		Where(projectconfigactivation.ProjectIDEQ(p.ID), projectconfigactivation.ToConfigIDEQ(c2.ID)).
+		OnlyIDX(ctx)
+
+	// Another rollback of the same change commits after this request read the
+	// active config but before it writes
+	raced := false
+	client.ProjectConfig.Use(func(next ent.Mutator) ent.Mutator {
+		return hook.ProjectConfigFunc(func(ctx context.Context, m *ent.ProjectConfigMutation) (ent.Value, error) {
+			if !raced && m.Op().Is(ent.OpUpdate) {
+				raced = true
+				tx := m.Client()
+				tx.ProjectConfig.Update().Where(projectconfig.HasConfigItemWith(configitem.IDEQ(c2.ID))).SetActive(false).ExecX(ctx)
+				tx.ProjectConfig.Update().Where(projectconfig.HasConfigItemWith(configitem.IDEQ(c1.ID))).SetActive(true).ExecX(ctx)
+				tx.ProjectConfigActivation.Create().SetProjectID(p.ID).SetActorID(owner.ID).
+					SetFromConfigID(c2.ID).SetToConfigID(c1.ID).SetRollbackOfID(target).ExecX(ctx)
+			}
+			return next.Mutate(ctx, m)
+		})
+	})
+	var errBody struct {
+		Message string `json:"message"`
+	}
+	res := doJSON(t, app, http.MethodPost, base+"/activations/"+target.String()+"/rollback", nil, &errBody)
+	if res.StatusCode != http.StatusConflict || errBody.Message != "project configs changed concurrently, retry" {
+		t.Fatalf("racing rollback: got %d %q", res.StatusCode, errBody.Message)
+	}
+	if !raced {
+		t.Fatal("rollback did not update project configs")
+	}
+
+	// The losing request rolled back entirely: nothing was committed
+	if id := activeConfigOf(t, client, p.ID); id == nil || *id != c2.ID {
+		t.Fatalf("after racing rollback: active %v, want %s", id, c2.ID)
+	}
+	if n := client.ProjectConfigActivation.Query().Where(projectconfigactivation.ProjectIDEQ(p.ID)).CountX(ctx); n != 2 {
+		t.Fatalf("activations: %d, want 2", n)
+	}
+
+	// Replaying the rollback once the winner committed is a plain conflict
+	client.ProjectConfig.Update().Where(projectconfig.HasConfigItemWith(configitem.IDEQ(c2.ID))).SetActive(false).ExecX(ctx)
+	client.ProjectConfig.Update().Where(projectconfig.HasConfigItemWith(configitem.IDEQ(c1.ID))).SetActive(true).ExecX(ctx)
+	if res := doJSON(t, app, http.MethodPost, base+"/activations/"+target.String()+"/rollback", nil, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("repeated rollback: expected 409, got %d", res.StatusCode)
+	}
+}
+
+// activeConfigOf returns the config id active on a project, or nil.
+func activeConfigOf(t *testing.T, client *ent.Client, projID uuid.UUID) *uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		t.Fatalf("begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	id, err := activeConfigID(ctx, tx, projID)
+	if err != nil {
+		t.Fatalf("active config: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,148 @@
+package projects
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/configx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// ActiveConfigResponse is the resolved active config of a project
+// swagger:model ActiveConfigResponse
+type ActiveConfigResponse struct {
+	ProjectID       uuid.UUID            `json:"project_id"`
+	ProjectConfigID uuid.UUID            `json:"project_config_id"`
+	ConfigID        uuid.UUID            `json:"config_id"`
+	Name            string               `json:"name"`
+	Kind            string               `json:"kind,omitempty"`
+	Revision        int                  `json:"revision"`
+	Data            map[string]any       `json:"data"`
+	Layers          []configx.Layer      `json:"layers"`
+	Sources         map[string]uuid.UUID `json:"sources"`
+}
+
+// activeConfig loads and resolves the active config of a project owned by ownerID.
+// The returned entity tag covers the association and every resolved layer revision.
+func activeConfig(ctx context.Context, client *ent.Client, projID, ownerID uuid.UUID) (*ActiveConfigResponse, []string, error) {
+	proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+	if err != nil {
+		return nil, nil, kit.NotFound("project not found")
+	}
+	if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
+		return nil, nil, fiber.ErrForbidden
+	}
+
+	pc, res, err := configx.ResolveActive(ctx, client, projID, ownerID)
+	if err != nil {
+		return nil, nil, kit.ResolveError(err)
+	}
+	cfg := pc.Edges.ConfigItem
+
+	tag := []string{pc.ID.String()}
+	for _, l := range res.Layers {
+		tag = append(tag, l.ConfigID.String()+":"+strconv.Itoa(l.Revision))
+	}
+	return &ActiveConfigResponse{
+		ProjectID:       projID,
+		ProjectConfigID: pc.ID,
+		ConfigID:        cfg.ID,
+		Name:            cfg.Name,
+		Kind:            cfg.Kind,
+		Revision:        cfg.Revision,
+		Data:            res.Data,
+		Layers:          res.Layers,
+		Sources:         res.Sources,
+	}, tag, nil
+}
+
+// GetActiveConfigHandler returns the resolved data of a project's active config.
+//
+//	@Summary      Get active config
+//	@Description  Resolved data of the project's active config (owner only). Send If-None-Match with the last ETag to get 304 when nothing changed.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id             path    string  true   "Project UUID"
+//	@Param        If-None-Match  header  string  false  "ETag from a previous response"
+//	@Success      200  {object}  map[string]interface{}
+//	@Success      304  "not modified"
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      422  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/active-config [get]
+func GetActiveConfigHandler(client *ent.Client) fiber.Handler {
+	return activeConfigHandler(client, false)
+}
+
+// GetActiveConfigRawHandler returns only the resolved JSON data of a project's active config.
+//
+//	@Summary      Get active config (raw)
+//	@Description  Bare resolved JSON of the project's active config without the response envelope, for build scripts (owner only). Supports If-None-Match.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id             path    string  true   "Project UUID"
+//	@Param        If-None-Match  header  string  false  "ETag from a previous response"
+//	@Success      200  {object}  map[string]interface{}
+//	@Success      304  "not modified"
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      422  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/active-config/raw [get]
+func GetActiveConfigRawHandler(client *ent.Client) fiber.Handler {
+	return activeConfigHandler(client, true)
+}
+
+func activeConfigHandler(client *ent.Client, raw bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		resp, tag, err := activeConfig(ctx, client, projID, ownerID)
+		if err != nil {
+			return err
+		}
+
+		// The envelope and the bare body are different representations
+		variant := "envelope"
+		if raw {
+			variant = "raw"
+		}
+		etag := kit.HashETag(append([]string{variant}, tag...)...)
+		kit.SetETag(c, etag)
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		if kit.IfNoneMatch(c, etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		if raw {
+			return c.JSON(resp.Data)
+		}
+		return kit.OK(c, resp)
+	}
+}
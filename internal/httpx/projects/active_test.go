@@ -0,0 +1,106 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestActiveConfig_Get_Raw_NotModified(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	other := client.User.Create().SetDisplayName("Other").SaveX(ctx)
+
+	routes := func(app *fiber.App) {
+		app.Get("/projects/:id/active-config", mw.RequireUser(), GetActiveConfigHandler(client))
+		app.Get("/projects/:id/active-config/raw", mw.RequireUser(), GetActiveConfigRawHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	base := "/projects/" + p.ID.String() + "/active-config"
+
+	// No active config yet
+	if res := doJSON(t, app, http.MethodGet, base, nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("no active: expected 404, got %d", res.StatusCode)
+	}
+
+	parent := client.ConfigItem.Create().SetName("base").SetData(map[string]any{"format": "png", "scale": 1}).
+		SetOwnerID(owner.ID).SaveX(ctx)
+	cfg := client.ConfigItem.Create().SetName("child").SetData(map[string]any{"scale": 2}).
+		SetParentIds([]uuid.UUID{parent.ID}).SetOwnerID(owner.ID).SaveX(ctx)
+	client.ProjectConfig.Create().SetProjectID(p.ID).SetConfigItemID(cfg.ID).SetActive(true).SaveX(ctx)
+
+	var env struct {
+		Data ActiveConfigResponse `json:"data"`
+	}
+	res := doJSON(t, app, http.MethodGet, base, nil, &env)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d", res.StatusCode)
+	}
+	if env.Data.ConfigID != cfg.ID || env.Data.Data["format"] != "png" || env.Data.Data["scale"] != float64(2) {
+		t.Fatalf("unexpected active config: %+v", env.Data)
+	}
+	if len(env.Data.Layers) != 2 || env.Data.Sources["/format"] != parent.ID {
+		t.Fatalf("unexpected layers/sources: %+v %+v", env.Data.Layers, env.Data.Sources)
+	}
+	etag := res.Header.Get("ETag")
+	if etag == "" || res.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("missing caching headers: %v", res.Header)
+	}
+
+	// Raw returns the bare data under its own tag
+	res = doJSON(t, app, http.MethodGet, base+"/raw", nil, nil)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("raw: expected 200, got %d", res.StatusCode)
+	}
+	var raw map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode raw: %v", err)
+	}
+	if raw["format"] != "png" || raw["scale"] != float64(2) || raw["data"] != nil {
+		t.Fatalf("unexpected raw body: %v", raw)
+	}
+	rawTag := res.Header.Get("ETag")
+	if rawTag == "" || rawTag == etag {
+		t.Fatalf("raw etag should differ from envelope etag: %q %q", rawTag, etag)
+	}
+
+	// If-None-Match with the current tag is answered with 304
+	for target, tag := range map[string]string{base: etag, base + "/raw": rawTag} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("If-None-Match", tag)
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("conditional get: %v", err)
+		}
+		if res.StatusCode != http.StatusNotModified {
+			t.Fatalf("%s: expected 304, got %d", target, res.StatusCode)
+		}
+	}
+
+	// Editing a parent layer changes the tag
+	client.ConfigItem.UpdateOneID(parent.ID).SetData(map[string]any{"format": "svg"}).AddRevision(1).ExecX(ctx)
+	req := httptest.NewRequest(http.MethodGet, base, nil)
+	req.Header.Set("If-None-Match", etag)
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("conditional get: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == etag {
+		t.Fatalf("parent edit: expected 200 with a new etag, got %d %q", res.StatusCode, res.Header.Get("ETag"))
+	}
+
+	// Other users cannot read the project's config
+	otherApp := testutil.NewApp(asUser(other.ID), routes)
+	if res := doJSON(t, otherApp, http.MethodGet, base, nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other user: expected 403, got %d", res.StatusCode)
+	}
+}
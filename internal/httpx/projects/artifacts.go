@@ -0,0 +1,298 @@
+package projects
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/logx"
+)
+
+var artifactLogger = logx.GetScope("artifacts")
+
+// exportOf loads an export job of an owned project.
+func exportOf(ctx context.Context, client *ent.Client, projID, jobID, ownerID uuid.UUID) (*ent.ExportJob, error) {
+	if _, err := ownedProject(ctx, client, projID, ownerID); err != nil {
+		return nil, err
+	}
+	job, err := client.ExportJob.Query().
+		Where(exportjob.IDEQ(jobID), exportjob.ProjectIDEQ(projID)).
+		Only(ctx)
+	if err != nil {
+		return nil, kit.NotFound("export job not found")
+	}
+	return job, nil
+}
+
+func artifactsDisabled() error {
+	return kit.NewAPIError(http.StatusServiceUnavailable, "E_ARTIFACTS_DISABLED", "artifact storage is not configured", nil)
+}
+
+// ListArtifactsHandler lists the output files of an export job.
+//
+//	@Summary      List export artifacts
+//	@Description  Files produced by an export job, ordered by name (owner only)
+//	@Tags         exports
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Project UUID"
+//	@Param        eid  path  string  true  "Export job UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/exports/{eid}/artifacts [get]
+func ListArtifactsHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		jobID, err := uuid.Parse(c.Params("eid"))
+		if err != nil {
+			return kit.BadRequest("invalid export id", c.Params("eid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		if _, err := exportOf(ctx, client, projID, jobID, ownerID); err != nil {
+			return err
+		}
+		items, err := client.Artifact.Query().
+			Where(artifact.JobIDEQ(jobID)).
+			Order(ent.Asc(artifact.FieldName)).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query artifacts failed", err.Error())
+		}
+		return kit.OK(c, items)
+	}
+}
+
+// DownloadArtifactHandler streams the content of an artifact.
+//
+//	@Summary      Download export artifact
+//	@Description  Content of an export artifact (owner only). A single byte range may be requested with Range; If-Range and If-None-Match take the ETag, which is the content digest.
+//	@Tags         exports
+//	@Produce      octet-stream
+//	@Param        id             path    string  true   "Project UUID"
+//	@Param        eid            path    string  true   "Export job UUID"
+//	@Param        aid            path    string  true   "Artifact UUID"
+//	@Param        Range          header  string  false  "byte range, e.g. bytes=0-1023"
+//	@Param        If-Range       header  string  false  "ETag the range is valid for"
+//	@Param        If-None-Match  header  string  false  "ETag from a previous response"
+//	@Success      200  {file}  file
+//	@Success      206  {file}  file  "partial content"
+//	@Success      304  "not modified"
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      416  {object}  map[string]interface{}
+//	@Failure      503  {object}  map[string]interface{}  "artifact storage not configured"
+//	@Router       /api/v1/projects/{id}/exports/{eid}/artifacts/{aid}/download [get]
+func DownloadArtifactHandler(client *ent.Client, store blobx.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		jobID, err := uuid.Parse(c.Params("eid"))
+		if err != nil {
+			return kit.BadRequest("invalid export id", c.Params("eid"))
+		}
+		artID, err := uuid.Parse(c.Params("aid"))
+		if err != nil {
+			return kit.BadRequest("invalid artifact id", c.Params("aid"))
+		}
+		if store == nil {
+			return artifactsDisabled()
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		if _, err := exportOf(ctx, client, projID, jobID, ownerID); err != nil {
+			return err
+		}
+		a, err := client.Artifact.Query().
+			Where(artifact.IDEQ(artID), artifact.JobIDEQ(jobID)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("artifact not found")
+		}
+
+		// Content is addressed by digest, so the digest is a strong validator
+		// and the response never changes
+		etag := `"` + a.Digest + `"`
+		kit.SetETag(c, etag)
+		c.Set(fiber.HeaderCacheControl, "private, max-age=31536000, immutable")
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+		if kit.IfNoneMatch(c, etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		var rng *kit.ByteRange
+		if h := c.Get(fiber.HeaderRange); h != "" && kit.IfRange(c, etag) {
+			if rng, err = kit.ParseRange(h, a.Size); errors.Is(err, kit.ErrRangeNotSatisfiable) {
+				c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(a.Size, 10))
+				return kit.NewAPIError(http.StatusRequestedRangeNotSatisfiable, "E_RANGE_NOT_SATISFIABLE", "requested range not satisfiable", h)
+			}
+		}
+
+		// The body is streamed after the handler returns, so the blob is
+		// opened with the request context rather than the handler timeout
+		blob, err := store.Open(c.Context(), a.Digest)
+		if errors.Is(err, blobx.ErrNotFound) {
+			return kit.NotFound("artifact content not found")
+		}
+		if err != nil {
+			return kit.InternalError("open artifact failed", err.Error())
+		}
+
+		c.Attachment(path.Base(a.Name))
+		c.Set(fiber.HeaderContentType, a.ContentType)
+		if rng == nil {
+			return c.SendStream(blob, int(a.Size))
+		}
+		if _, err := blob.Seek(rng.Start, io.SeekStart); err != nil {
+			_ = blob.Close()
+			return kit.InternalError("seek artifact failed", err.Error())
+		}
+		c.Set(fiber.HeaderContentRange, rng.ContentRange(a.Size))
+		c.Status(fiber.StatusPartialContent)
+		return c.SendStream(struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(blob, rng.Length), blob}, int(rng.Length))
+	}
+}
+
+// BundleExportHandler streams all artifacts of an export job as one zip file.
+//
+//	@Summary      Download export bundle
+//	@Description  All artifacts of a succeeded export job as a zip archive (owner only)
+//	@Tags         exports
+//	@Produce      application/zip
+//	@Param        id   path  string  true  "Project UUID"
+//	@Param        eid  path  string  true  "Export job UUID"
+//	@Success      200  {file}  file
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "job has not succeeded"
+//	@Failure      503  {object}  map[string]interface{}  "artifact storage not configured"
+//	@Router       /api/v1/projects/{id}/exports/{eid}/bundle.zip [get]
+func BundleExportHandler(client *ent.Client, store blobx.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		jobID, err := uuid.Parse(c.Params("eid"))
+		if err != nil {
+			return kit.BadRequest("invalid export id", c.Params("eid"))
+		}
+		if store == nil {
+			return artifactsDisabled()
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		job, err := exportOf(ctx, client, projID, jobID, ownerID)
+		if err != nil {
+			return err
+		}
+		if job.Status != exportjob.StatusSucceeded {
+			return kit.Conflict("export job has not succeeded", fiber.Map{"status": job.Status})
+		}
+		items, err := client.Artifact.Query().
+			Where(artifact.JobIDEQ(jobID)).
+			Order(ent.Asc(artifact.FieldName)).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query artifacts failed", err.Error())
+		}
+
+		c.Attachment("export-" + jobID.String() + ".zip")
+		c.Set(fiber.HeaderContentType, "application/zip")
+		// Once streaming has started the status cannot change, so a failure
+		// truncates the archive and is only logged
+		reqCtx := c.Context()
+		reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			zw := zip.NewWriter(w)
+			for _, a := range items {
+				if err := writeZipEntry(reqCtx, zw, store, a); err != nil {
+					artifactLogger.Warn("bundle aborted", zap.String("job", jobID.String()), zap.String("artifact", a.Name), zap.Error(err))
+					return
+				}
+			}
+			if err := zw.Close(); err != nil {
+				artifactLogger.Warn("bundle close failed", zap.String("job", jobID.String()), zap.Error(err))
+			}
+		})
+		return nil
+	}
+}
+
+func writeZipEntry(ctx context.Context, zw *zip.Writer, store blobx.Store, a *ent.Artifact) error {
+	blob, err := store.Open(ctx, a.Digest)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: a.Name, Method: zip.Deflate, Modified: a.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, blob)
+	return err
+}
@@ -0,0 +1,181 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/filesnapshot"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/snapshot"
+)
+
+// loadSnapshot returns the stored snapshot of a version of the Figma file
+// key, capturing it from Figma first when needed. An empty version captures
+// the current one.
+func loadSnapshot(ctx context.Context, c *fiber.Ctx, client *ent.Client, fg *figmax.Client, proj *ent.Project, key, version string) (*ent.FileSnapshot, error) {
+	if version != "" {
+		snap, err := client.FileSnapshot.Query().
+			Where(filesnapshot.ProjectIDEQ(proj.ID), filesnapshot.FileKeyEQ(key), filesnapshot.VersionEQ(version)).
+			Only(ctx)
+		if err == nil {
+			return snap, nil
+		}
+		if !ent.IsNotFound(err) {
+			return nil, kit.InternalError("query snapshot failed", err.Error())
+		}
+	}
+	file, err := fg.GetFile(ctx, key, figmax.FileOptions{Version: version})
+	if err != nil {
+		return nil, figmaError(c, err)
+	}
+	if version == "" {
+		// The current version may have been captured already
+		snap, err := client.FileSnapshot.Query().
+			Where(filesnapshot.ProjectIDEQ(proj.ID), filesnapshot.FileKeyEQ(key), filesnapshot.VersionEQ(file.Version)).
+			Only(ctx)
+		if err == nil {
+			return snap, nil
+		}
+	}
+	if file.Version == "" {
+		return nil, kit.NewAPIError(http.StatusBadGateway, "E_FIGMA_UPSTREAM", "figma returned a file without a version", nil)
+	}
+	sum, err := snapshot.Build(file)
+	if err != nil {
+		return nil, kit.NewAPIError(http.StatusBadGateway, "E_FIGMA_UPSTREAM", "figma file could not be read", err.Error())
+	}
+	create := client.FileSnapshot.Create().
+		SetProjectID(proj.ID).
+		SetFileKey(key).
+		SetVersion(file.Version).
+		SetFileName(file.Name).
+		SetEntries(sum.Entries)
+	if !file.LastModified.IsZero() {
+		create = create.SetLastModified(file.LastModified)
+	}
+	snap, err := create.Save(ctx)
+	if ent.IsConstraintError(err) {
+		// Captured concurrently
+		return client.FileSnapshot.Query().
+			Where(filesnapshot.ProjectIDEQ(proj.ID), filesnapshot.FileKeyEQ(key), filesnapshot.VersionEQ(file.Version)).
+			Only(ctx)
+	}
+	if err != nil {
+		return nil, kit.InternalError("store snapshot failed", err.Error())
+	}
+	return snap, nil
+}
+
+// ProjectChangesHandler diffs the components and styles of two versions of a project's Figma file.
+//
+//	@Summary      File changes
+//	@Description  Diff two versions of the project's Figma file (owner only) at component and style level: added, removed, renamed and visually changed entries. to defaults to the current version; from defaults to the stored snapshot of the same file last modified before it (by Figma's last-modified time, else capture time), or to an empty file when there is none. Versions are fetched from Figma once and stored.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id    path   string  true   "Project UUID"
+//	@Param        from  query  string  false  "base Figma version id"
+//	@Param        to    query  string  false  "target Figma version id"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}  "project or version not found"
+//	@Failure      429  {object}  map[string]interface{}  "Figma rate limit, see Retry-After"
+//	@Failure      502  {object}  map[string]interface{}  "Figma rejected the token or failed"
+//	@Failure      503  {object}  map[string]interface{}  "Figma is not configured"
+//	@Router       /api/v1/projects/{id}/changes [get]
+func ProjectChangesHandler(client *ent.Client, fg *figmax.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		from, to := strings.TrimSpace(c.Query("from")), strings.TrimSpace(c.Query("to"))
+		if len(from) > 64 || len(to) > 64 {
+			return kit.BadRequest("invalid version id", nil)
+		}
+		if fg == nil {
+			return kit.NewAPIError(http.StatusServiceUnavailable, "E_FIGMA_DISABLED", "figma integration is not configured", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+		defer cancel()
+
+		proj, err := ownedProject(ctx, client, projID, ownerID)
+		if err != nil {
+			return err
+		}
+		if proj.FileKey == "" {
+			return kit.BadRequest("project has no Figma file, update its url first", nil)
+		}
+		key := proj.FileKey
+		if proj.BranchKey != "" {
+			key = proj.BranchKey
+		}
+
+		target, err := loadSnapshot(ctx, c, client, fg, proj, key, to)
+		if err != nil {
+			return err
+		}
+		base := &snapshot.Summary{Entries: []snapshot.Entry{}}
+		switch {
+		case from == target.Version:
+			base = &snapshot.Summary{Version: target.Version, Entries: target.Entries}
+		case from != "":
+			snap, err := loadSnapshot(ctx, c, client, fg, proj, key, from)
+			if err != nil {
+				return err
+			}
+			base = &snapshot.Summary{Version: snap.Version, Entries: snap.Entries}
+		default:
+			// Versions are ordered by when they were saved in Figma, not when they
+			// were captured, so an older version fetched later is not taken as the base
+			at := target.CreatedAt
+			if target.LastModified != nil {
+				at = *target.LastModified
+			}
+			prev, err := client.FileSnapshot.Query().
+				Where(
+					filesnapshot.ProjectIDEQ(proj.ID),
+					filesnapshot.FileKeyEQ(key),
+					filesnapshot.VersionNEQ(target.Version),
+					filesnapshot.Or(
+						filesnapshot.LastModifiedLTE(at),
+						filesnapshot.And(filesnapshot.LastModifiedIsNil(), filesnapshot.CreatedAtLTE(at)),
+					),
+				).
+				Order(func(s *sql.Selector) {
+					s.OrderExpr(sql.Expr(fmt.Sprintf("COALESCE(%s, %s) DESC", s.C(filesnapshot.FieldLastModified), s.C(filesnapshot.FieldCreatedAt))))
+				}).
+				First(ctx)
+			if err != nil && !ent.IsNotFound(err) {
+				return kit.InternalError("query snapshot failed", err.Error())
+			}
+			if prev != nil {
+				base = &snapshot.Summary{Version: prev.Version, Entries: prev.Entries}
+			}
+		}
+
+		diff := snapshot.Compare(base, &snapshot.Summary{Version: target.Version, Entries: target.Entries})
+		return kit.OK(c, diff)
+	}
+}
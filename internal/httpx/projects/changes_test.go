@@ -0,0 +1,187 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fiber-ent-apollo-pg/ent/filesnapshot"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/figmax/figmatest"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+	"fiber-ent-apollo-pg/internal/snapshot"
+)
+
+// designFile is a file version with one page of components.
+func designFile(t *testing.T, version string, modified time.Time, components ...string) figmax.File {
+	t.Helper()
+	nodes := make([]any, len(components))
+	for i, name := range components {
+		nodes[i] = map[string]any{"id": "1:" + name, "name": name, "type": "COMPONENT"}
+	}
+	doc, err := json.Marshal(map[string]any{"id": "0:0", "type": "DOCUMENT", "children": []any{
+		map[string]any{"id": "0:1", "name": "Page", "type": "CANVAS", "children": nodes},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return figmax.File{Name: "Design", Version: version, LastModified: modified, Document: doc}
+}
+
+func TestProjectChanges(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	srv.SetFile(p.FileKey, figmatest.File{
+		File: designFile(t, "3", day.AddDate(0, 0, 2), "Button", "Card", "Tab"),
+		History: map[string]figmax.File{
+			"1": designFile(t, "1", day, "Button"),
+			"2": designFile(t, "2", day.AddDate(0, 0, 1), "Button", "Card"),
+		},
+	})
+	routes := func(fg *figmax.Client) func(*fiber.App) {
+		return func(app *fiber.App) {
+			app.Get("/projects/:id/changes", mw.RequireUser(), ProjectChangesHandler(client, fg))
+		}
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes(srv.FigmaClient()))
+	base := "/projects/" + p.ID.String() + "/changes"
+	names := func(entries []snapshot.Entry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+	changes := func(query string) snapshot.Diff {
+		t.Helper()
+		var env struct {
+			Data snapshot.Diff `json:"data"`
+		}
+		if res := doJSON(t, app, http.MethodGet, base+query, nil, &env); res.StatusCode != http.StatusOK {
+			t.Fatalf("changes%s: expected 200, got %d", query, res.StatusCode)
+		}
+		return env.Data
+	}
+
+	// With nothing stored the current version is compared to an empty file
+	d := changes("")
+	if d.From != "" || d.To != "3" || len(d.Added) != 3 {
+		t.Fatalf("first diff: %+v", d)
+	}
+
+	// An older version captured afterwards becomes the base of the current one
+	d = changes("?to=1")
+	if d.To != "1" || d.From != "" {
+		t.Fatalf("diff to 1: %+v", d)
+	}
+	d = changes("")
+	if d.From != "1" || d.To != "3" || len(d.Added) != 2 {
+		t.Fatalf("default base: %+v added %v", d, names(d.Added))
+	}
+
+	// Explicit versions, both fetched once and stored
+	d = changes("?from=2&to=3")
+	if d.From != "2" || len(d.Added) != 1 || d.Added[0].Name != "Tab" {
+		t.Fatalf("diff 2..3: %+v", d)
+	}
+	d = changes("?from=3&to=2")
+	if len(d.Removed) != 1 || d.Removed[0].Name != "Tab" {
+		t.Fatalf("diff 3..2: %+v", d)
+	}
+	// Version 2 now sits between 1 and 3
+	if d = changes(""); d.From != "2" {
+		t.Fatalf("default base after capturing 2: %+v", d)
+	}
+	fileRequests := 0
+	for _, r := range srv.Requests() {
+		if r == "/v1/files/"+p.FileKey {
+			fileRequests++
+		}
+	}
+	// Versions 1 and 2 are fetched once, and each of the three requests
+	// without "to" checks which version is current
+	if fileRequests != 2+3 {
+		t.Fatalf("figma file requests: %d", fileRequests)
+	}
+
+	if res := doJSON(t, app, http.MethodGet, base+"?from=9", nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown version: expected 404, got %d", res.StatusCode)
+	}
+	srv.FailNext(http.StatusTooManyRequests, 7)
+	if res := doJSON(t, app, http.MethodGet, base, nil, nil); res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") != "7" {
+		t.Fatalf("rate limited: got %d Retry-After=%q", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+	other := client.User.Create().SetDisplayName("Other").SaveX(ctx)
+	if res := doJSON(t, testutil.NewApp(asUser(other.ID), routes(srv.FigmaClient())), http.MethodGet, base, nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other user: expected 403, got %d", res.StatusCode)
+	}
+	client.Project.UpdateOne(p).ClearFileKey().SaveX(ctx)
+	if res := doJSON(t, app, http.MethodGet, base, nil, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("no figma file: expected 400, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, testutil.NewApp(asUser(owner.ID), routes(nil)), http.MethodGet, base, nil, nil); res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("figma disabled: expected 503, got %d", res.StatusCode)
+	}
+}
+
+func TestProjectChanges_FileChanged(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	srv.SetFile(p.FileKey, figmatest.File{File: designFile(t, "1", day, "Button", "Card")})
+	moved := figmaKey()
+	srv.SetFile(moved, figmatest.File{
+		File:    designFile(t, "2", day.AddDate(0, 0, 1), "Tab", "Menu"),
+		History: map[string]figmax.File{"1": designFile(t, "1", day, "Tab")},
+	})
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Put("/projects/:id", mw.RequireUser(), UpdateProjectHandler(client))
+		app.Get("/projects/:id/changes", mw.RequireUser(), ProjectChangesHandler(client, srv.FigmaClient()))
+	})
+	base := "/projects/" + p.ID.String()
+	changes := func(query string) snapshot.Diff {
+		t.Helper()
+		var env struct {
+			Data snapshot.Diff `json:"data"`
+		}
+		if res := doJSON(t, app, http.MethodGet, base+"/changes"+query, nil, &env); res.StatusCode != http.StatusOK {
+			t.Fatalf("changes%s: expected 200, got %d", query, res.StatusCode)
+		}
+		return env.Data
+	}
+	changes("")
+
+	url := "https://www.figma.com/design/" + moved
+	if res := doJSON(t, app, http.MethodPut, base, map[string]any{"url": url}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("update url: expected 200, got %d", res.StatusCode)
+	}
+	if n := client.FileSnapshot.Query().Where(filesnapshot.ProjectIDEQ(p.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("snapshots of the previous file left: %d", n)
+	}
+	// A snapshot captured before files were recorded is never used
+	client.FileSnapshot.Create().SetProjectID(p.ID).SetVersion("0").
+		SetLastModified(day.AddDate(0, 0, -1)).SetEntries([]snapshot.Entry{}).SaveX(ctx)
+
+	// Nothing of the new file is stored yet, so the base is empty
+	if d := changes(""); d.From != "" || d.To != "2" || len(d.Added) != 2 {
+		t.Fatalf("diff after moving: %+v", d)
+	}
+	// Version ids of the previous file name other versions of the new one
+	if d := changes("?from=1"); d.From != "1" || len(d.Added) != 1 || d.Added[0].Name != "Menu" {
+		t.Fatalf("diff 1..2: %+v", d)
+	}
+}
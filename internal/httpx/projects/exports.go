@@ -0,0 +1,288 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/configx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// ownedProject loads a project and checks that ownerID owns it.
+func ownedProject(ctx context.Context, client *ent.Client, projID, ownerID uuid.UUID) (*ent.Project, error) {
+	proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+	if err != nil {
+		return nil, kit.NotFound("project not found")
+	}
+	if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
+		return nil, fiber.ErrForbidden
+	}
+	return proj, nil
+}
+
+// CreateExportHandler queues an export of a project with its active config.
+//
+//	@Summary      Start export
+//	@Description  Queue an export of the project (owner only). The resolved active config is snapshotted into the job, so later config changes do not affect it. Poll the job for status and progress.
+//	@Tags         exports
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Project UUID"
+//	@Success      201  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}  "project or active config not found"
+//	@Failure      422  {object}  map[string]interface{}
+//	@Failure      503  {object}  map[string]interface{}  "queue unavailable"
+//	@Router       /api/v1/projects/{id}/exports [post]
+func CreateExportHandler(client *ent.Client, svc *exportx.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		if svc == nil {
+			return kit.NewAPIError(http.StatusServiceUnavailable, "E_EXPORTS_DISABLED", "exports are not configured", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 8*time.Second)
+		defer cancel()
+
+		if _, err := ownedProject(ctx, client, projID, ownerID); err != nil {
+			return err
+		}
+		pc, res, err := configx.ResolveActive(ctx, client, projID, ownerID)
+		if err != nil {
+			return kit.ResolveError(err)
+		}
+
+		job, err := client.ExportJob.Create().
+			SetProjectID(projID).
+			SetRequestedByID(ownerID).
+			SetConfigID(pc.Edges.ConfigItem.ID).
+			SetConfigRevision(pc.Edges.ConfigItem.Revision).
+			SetConfig(res.Data).
+			Save(ctx)
+		if err != nil {
+			return kit.InternalError("create export job failed", err.Error())
+		}
+		if err := svc.Enqueue(ctx, job.ID); err != nil {
+			_, _ = client.ExportJob.UpdateOneID(job.ID).
+				SetStatus(exportjob.StatusFailed).
+				SetError("enqueue failed: " + err.Error()).
+				SetFinishedAt(time.Now()).
+				Save(ctx)
+			return kit.NewAPIError(http.StatusServiceUnavailable, "E_QUEUE_UNAVAILABLE", "could not queue export job", job.ID)
+		}
+		return kit.Created(c, job)
+	}
+}
+
+// ListExportsHandler lists the export jobs of a project.
+//
+//	@Summary      List exports
+//	@Description  Export jobs of the project, newest first (owner only)
+//	@Tags         exports
+//	@Accept       json
+//	@Produce      json
+//	@Param        id      path   string  true   "Project UUID"
+//	@Param        status  query  string  false  "filter by status"  Enums(queued, running, succeeded, failed, canceled)
+//	@Param        limit   query  int     false  "page size"  default(20)
+//	@Param        offset  query  int     false  "offset"     default(0)
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/exports [get]
+func ListExportsHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		pg, err := kit.ParsePaging(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		if _, err := ownedProject(ctx, client, projID, ownerID); err != nil {
+			return err
+		}
+		q := client.ExportJob.Query().Where(exportjob.ProjectIDEQ(projID))
+		if s := c.Query("status"); s != "" {
+			if err := exportjob.StatusValidator(exportjob.Status(s)); err != nil {
+				return kit.BadRequest("invalid status", s)
+			}
+			q = q.Where(exportjob.StatusEQ(exportjob.Status(s)))
+		}
+		items, err := q.
+			Order(ent.Desc(exportjob.FieldCreatedAt)).
+			Limit(pg.Limit).
+			Offset(pg.Offset).
+			All(ctx)
+		if err != nil {
+			return kit.InternalError("query export jobs failed", err.Error())
+		}
+		nextOff := pg.Offset + len(items)
+		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		return kit.List(c, items, meta)
+	}
+}
+
+// GetExportHandler returns the status of an export job.
+//
+//	@Summary      Get export
+//	@Description  Status and progress of an export job (owner only). Pollers can send If-None-Match to get 304 while nothing changed.
+//	@Tags         exports
+//	@Accept       json
+//	@Produce      json
+//	@Param        id             path    string  true   "Project UUID"
+//	@Param        eid            path    string  true   "Export job UUID"
+//	@Param        If-None-Match  header  string  false  "ETag from a previous response"
+//	@Success      200  {object}  map[string]interface{}
+//	@Success      304  "not modified"
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Router       /api/v1/projects/{id}/exports/{eid} [get]
+func GetExportHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		jobID, err := uuid.Parse(c.Params("eid"))
+		if err != nil {
+			return kit.BadRequest("invalid export id", c.Params("eid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 3*time.Second)
+		defer cancel()
+
+		if _, err := ownedProject(ctx, client, projID, ownerID); err != nil {
+			return err
+		}
+		job, err := client.ExportJob.Query().
+			Where(exportjob.IDEQ(jobID), exportjob.ProjectIDEQ(projID)).
+			Only(ctx)
+		if err != nil {
+			return kit.NotFound("export job not found")
+		}
+
+		etag := kit.HashETag(job.ID.String(), string(job.Status), strconv.Itoa(job.Progress))
+		kit.SetETag(c, etag)
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		if kit.IfNoneMatch(c, etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return kit.OK(c, job)
+	}
+}
+
+// CancelExportHandler cancels a queued or running export job.
+//
+//	@Summary      Cancel export
+//	@Description  Cancel an export job that has not finished (owner only). A running job stops before its next step.
+//	@Tags         exports
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Project UUID"
+//	@Param        eid  path  string  true  "Export job UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}  "job already finished"
+//	@Router       /api/v1/projects/{id}/exports/{eid}/cancel [post]
+func CancelExportHandler(client *ent.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		jobID, err := uuid.Parse(c.Params("eid"))
+		if err != nil {
+			return kit.BadRequest("invalid export id", c.Params("eid"))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		if _, err := ownedProject(ctx, client, projID, ownerID); err != nil {
+			return err
+		}
+		exists, err := client.ExportJob.Query().
+			Where(exportjob.IDEQ(jobID), exportjob.ProjectIDEQ(projID)).
+			Exist(ctx)
+		if err != nil {
+			return kit.InternalError("query export job failed", err.Error())
+		}
+		if !exists {
+			return kit.NotFound("export job not found")
+		}
+
+		job, err := exportx.Cancel(ctx, client, jobID)
+		if errors.Is(err, exportx.ErrNotCancelable) {
+			return kit.Conflict("export job already finished", fiber.Map{"status": job.Status})
+		}
+		if err != nil {
+			return kit.InternalError("cancel export job failed", err.Error())
+		}
+		return kit.OK(c, job)
+	}
+}
@@ -0,0 +1,150 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// queue is a publisher keeping published bodies, or failing with err.
+type queue struct {
+	bodies []string
+	err    error
+}
+
+func (q *queue) Publish(_ context.Context, _ string, body []byte) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.bodies = append(q.bodies, string(body))
+	return nil
+}
+
+func (q *queue) Close() error { return nil }
+
+type exportEnv struct {
+	Data ent.ExportJob `json:"data"`
+}
+
+func TestExports_Create_List_Get_Cancel(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	other := client.User.Create().SetDisplayName("Other").SaveX(ctx)
+
+	pub := &queue{}
+	svc := exportx.NewService(client, pub, nil)
+	routes := func(svc *exportx.Service) func(*fiber.App) {
+		return func(app *fiber.App) {
+			app.Post("/projects/:id/exports", mw.RequireUser(), CreateExportHandler(client, svc))
+			app.Get("/projects/:id/exports", mw.RequireUser(), ListExportsHandler(client))
+			app.Get("/projects/:id/exports/:eid", mw.RequireUser(), GetExportHandler(client))
+			app.Post("/projects/:id/exports/:eid/cancel", mw.RequireUser(), CancelExportHandler(client))
+		}
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes(svc))
+	base := "/projects/" + p.ID.String() + "/exports"
+
+	// Nothing to export without an active config
+	if res := doJSON(t, app, http.MethodPost, base, nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("no active config: expected 404, got %d", res.StatusCode)
+	}
+	cfg := client.ConfigItem.Create().SetName("c").SetData(map[string]any{"format": "svg"}).SetOwnerID(owner.ID).SaveX(ctx)
+	client.ProjectConfig.Create().SetProjectID(p.ID).SetConfigItemID(cfg.ID).SetActive(true).ExecX(ctx)
+
+	var created exportEnv
+	if res := doJSON(t, app, http.MethodPost, base, nil, &created); res.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", res.StatusCode)
+	}
+	job := created.Data
+	if job.Status != exportjob.StatusQueued || job.ConfigID != cfg.ID || job.Config["format"] != "svg" || job.RequestedByID != owner.ID {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if len(pub.bodies) != 1 || pub.bodies[0] != `{"job_id":"`+job.ID.String()+`"}` {
+		t.Fatalf("published: %v", pub.bodies)
+	}
+	// The job keeps the config it was created with
+	client.ConfigItem.UpdateOneID(cfg.ID).SetData(map[string]any{"format": "png"}).ExecX(ctx)
+
+	// A queue that is down fails the job right away
+	pub.err = errors.New("broker down")
+	if res := doJSON(t, app, http.MethodPost, base, nil, nil); res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("queue down: expected 503, got %d", res.StatusCode)
+	}
+	pub.err = nil
+	if res := doJSON(t, testutil.NewApp(asUser(owner.ID), routes(nil)), http.MethodPost, base, nil, nil); res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("exports disabled: expected 503, got %d", res.StatusCode)
+	}
+
+	var list struct {
+		Data []ent.ExportJob `json:"data"`
+	}
+	if res := doJSON(t, app, http.MethodGet, base, nil, &list); res.StatusCode != http.StatusOK || len(list.Data) != 2 {
+		t.Fatalf("list: got %d with %d jobs", res.StatusCode, len(list.Data))
+	}
+	if failed := list.Data[0]; failed.Status != exportjob.StatusFailed || failed.Error == "" {
+		t.Fatalf("enqueue failure not recorded: %+v", failed)
+	}
+	list.Data = nil
+	if res := doJSON(t, app, http.MethodGet, base+"?status=queued", nil, &list); res.StatusCode != http.StatusOK || len(list.Data) != 1 || list.Data[0].ID != job.ID {
+		t.Fatalf("queued filter: got %d %+v", res.StatusCode, list.Data)
+	}
+	if res := doJSON(t, app, http.MethodGet, base+"?status=bogus", nil, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad filter: expected 400, got %d", res.StatusCode)
+	}
+
+	// Polling a job answers 304 until it changes
+	var got exportEnv
+	res := doJSON(t, app, http.MethodGet, base+"/"+job.ID.String(), nil, &got)
+	if res.StatusCode != http.StatusOK || got.Data.Config["format"] != "svg" {
+		t.Fatalf("get: got %d %+v", res.StatusCode, got.Data)
+	}
+	etag := res.Header.Get("ETag")
+	poll := func() int {
+		req := httptest.NewRequest(http.MethodGet, base+"/"+job.ID.String(), nil)
+		req.Header.Set("If-None-Match", etag)
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+		return res.StatusCode
+	}
+	if code := poll(); code != http.StatusNotModified {
+		t.Fatalf("unchanged poll: expected 304, got %d", code)
+	}
+
+	var canceled exportEnv
+	if res := doJSON(t, app, http.MethodPost, base+"/"+job.ID.String()+"/cancel", nil, &canceled); res.StatusCode != http.StatusOK || canceled.Data.Status != exportjob.StatusCanceled {
+		t.Fatalf("cancel: got %d %s", res.StatusCode, canceled.Data.Status)
+	}
+	if code := poll(); code != http.StatusOK {
+		t.Fatalf("poll after cancel: expected 200, got %d", code)
+	}
+	if res := doJSON(t, app, http.MethodPost, base+"/"+job.ID.String()+"/cancel", nil, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("cancel finished job: expected 409, got %d", res.StatusCode)
+	}
+
+	// Jobs are only reachable through their own project, by its owner
+	if res := doJSON(t, app, http.MethodGet, base+"/"+uuid.NewString(), nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown job: expected 404, got %d", res.StatusCode)
+	}
+	sibling := client.Project.Create().SetName("sibling").SetURL("https://www.figma.com/design/" + figmaKey()).SetOwnerID(owner.ID).SaveX(ctx)
+	if res := doJSON(t, app, http.MethodGet, "/projects/"+sibling.ID.String()+"/exports/"+job.ID.String(), nil, nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("job of another project: expected 404, got %d", res.StatusCode)
+	}
+	otherApp := testutil.NewApp(asUser(other.ID), routes(svc))
+	if res := doJSON(t, otherApp, http.MethodPost, base+"/"+job.ID.String()+"/cancel", nil, nil); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("other cancel: expected 403, got %d", res.StatusCode)
+	}
+}
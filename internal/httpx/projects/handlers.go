@@ -3,6 +3,7 @@ package projects
 
 import (
 	"context"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,10 +11,15 @@ import (
 	"github.com/google/uuid"
 
 	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
 	"fiber-ent-apollo-pg/ent/configitem"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/ent/filesnapshot"
 	"fiber-ent-apollo-pg/ent/project"
 	"fiber-ent-apollo-pg/ent/projectconfig"
+	"fiber-ent-apollo-pg/ent/projectconfigactivation"
 	"fiber-ent-apollo-pg/ent/user"
+	"fiber-ent-apollo-pg/internal/figmax"
 	"fiber-ent-apollo-pg/internal/httpx/kit"
 	"fiber-ent-apollo-pg/internal/httpx/mw"
 )
@@ -24,6 +30,7 @@ type CreateProjectRequest struct {
 	Name        string `json:"name"`
 	URL         string `json:"url"`
 	Description string `json:"description,omitempty"`
+	AutoExport  bool   `json:"auto_export,omitempty"`
 }
 
 // UpdateProjectRequest is the request body for updating a project
@@ -32,6 +39,7 @@ type UpdateProjectRequest struct {
 	Name        *string `json:"name,omitempty"`
 	URL         *string `json:"url,omitempty"`
 	Description *string `json:"description,omitempty"`
+	AutoExport  *bool   `json:"auto_export,omitempty"`
 }
 
 // AddConfigToProjectRequest is the request body for adding config to project
@@ -84,8 +92,13 @@ func ListProjectsHandler(client *ent.Client) fiber.Handler {
 			return kit.InternalError("query projects failed", err.Error())
 		}
 
+		parts := make([]string, 0, len(items))
+		for _, it := range items {
+			parts = append(parts, it.ID.String()+":"+strconv.Itoa(it.Version))
+		}
 		nextOff := pg.Offset + len(items)
 		meta := kit.PageMeta{Limit: pg.Limit, Offset: pg.Offset, Count: len(items), NextOffset: &nextOff, HasMore: len(items) == pg.Limit, Mode: "offset"}
+		kit.SetETag(c, kit.HashETag(parts...))
 		return kit.List(c, items, meta)
 	}
 }
@@ -93,7 +106,7 @@ func ListProjectsHandler(client *ent.Client) fiber.Handler {
 // CreateProjectHandler creates a new project owned by the current user.
 //
 //	@Summary      Create project
-//	@Description  Create a project owned by the current user
+//	@Description  Create a project owned by the current user. url must be a Figma file, design, proto or branch link; it is stored in canonical form and each Figma file can back one project per user.
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
@@ -122,26 +135,31 @@ func CreateProjectHandler(client *ent.Client) fiber.Handler {
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 
-		// Check if URL already exists for this user
-		exists, err := client.Project.Query().
-			Where(project.And(project.HasOwnerWith(user.IDEQ(uid)), project.URLEQ(req.URL))).
-			Exist(ctx)
+		ref, err := figmax.ParseURL(req.URL)
 		if err != nil {
-			return kit.InternalError("check project url failed", err.Error())
+			return kit.BadRequest(err.Error(), req.URL)
 		}
-		if exists {
-			return kit.BadRequest("project url already exists for this user", req.URL)
+		if err := checkFileKey(ctx, client, uid, uuid.Nil, ref); err != nil {
+			return err
 		}
 
 		created, err := client.Project.Create().
 			SetName(req.Name).
-			SetURL(req.URL).
+			SetURL(ref.URL()).
+			SetFileKey(ref.FileKey).
+			SetBranchKey(ref.BranchKey).
+			SetNodeID(ref.NodeID).
 			SetNillableDescription(&req.Description).
+			SetAutoExport(req.AutoExport).
 			SetOwnerID(uid).
 			Save(ctx)
+		if ent.IsConstraintError(err) {
+			return kit.Conflict("a project for this Figma file already exists", ref.FileKey)
+		}
 		if err != nil {
 			return kit.InternalError("create project failed", err.Error())
 		}
+		kit.SetETag(c, kit.VersionETag(created.Version))
 		return kit.Created(c, created)
 	}
 }
@@ -192,6 +210,7 @@ func GetProjectHandler(client *ent.Client) fiber.Handler {
 			return fiber.ErrForbidden
 		}
 
+		kit.SetETag(c, kit.VersionETag(proj.Version))
 		return kit.OK(c, proj)
 	}
 }
@@ -199,17 +218,20 @@ func GetProjectHandler(client *ent.Client) fiber.Handler {
 // UpdateProjectHandler updates a project owned by the current user.
 //
 //	@Summary      Update project
-//	@Description  Update project details (owner only)
+//	@Description  Update project details (owner only). Pointing the url at another Figma file or branch clears the file metadata of the last sync and the stored version snapshots
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
-//	@Param        id    path  string                        true  "Project UUID"
-//	@Param        body  body  projects.UpdateProjectRequest true  "project payload"
+//	@Param        id        path    string                         true   "Project UUID"
+//	@Param        If-Match  header  string                         false  "expected ETag"
+//	@Param        body      body    projects.UpdateProjectRequest  true   "project payload"
 //	@Success      200   {object}  map[string]interface{}
 //	@Failure      400   {object}  map[string]interface{}
 //	@Failure      401   {object}  map[string]interface{}
 //	@Failure      403   {object}  map[string]interface{}
 //	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
+//	@Failure      412   {object}  map[string]interface{}
 //	@Router       /api/v1/projects/{id} [put]
 func UpdateProjectHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -242,36 +264,84 @@ func UpdateProjectHandler(client *ent.Client) fiber.Handler {
 		if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
 			return fiber.ErrForbidden
 		}
+		if err := kit.CheckIfMatch(c, kit.VersionETag(proj.Version)); err != nil {
+			return err
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
 
-		upd := client.Project.UpdateOneID(projID)
+		// Guard on the version we read so concurrent writers cannot clobber each other
+		upd := tx.Project.UpdateOneID(projID).
+			Where(project.VersionEQ(proj.Version)).
+			AddVersion(1)
 		if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
 			upd = upd.SetName(*req.Name)
 		}
+		fileChanged := false
 		if req.URL != nil && strings.TrimSpace(*req.URL) != "" {
-			// Check if new URL conflicts with existing projects for this user
-			exists, err := client.Project.Query().
-				Where(project.And(
-					project.HasOwnerWith(user.IDEQ(ownerID)),
-					project.URLEQ(*req.URL),
-					project.IDNEQ(projID),
-				)).
-				Exist(ctx)
+			ref, err := figmax.ParseURL(*req.URL)
 			if err != nil {
-				return kit.InternalError("check project url failed", err.Error())
+				return kit.BadRequest(err.Error(), *req.URL)
+			}
+			if err := checkFileKey(ctx, client, ownerID, projID, ref); err != nil {
+				return err
 			}
-			if exists {
-				return kit.BadRequest("project url already exists for this user", *req.URL)
+			upd = upd.SetURL(ref.URL()).
+				SetFileKey(ref.FileKey).
+				SetBranchKey(ref.BranchKey).
+				SetNodeID(ref.NodeID)
+			fileChanged = ref.FileKey != proj.FileKey || ref.BranchKey != proj.BranchKey
+			if fileChanged {
+				// The synced metadata describes the previous file; the next sync
+				// fills it in again
+				upd = upd.ClearFileName().
+					ClearThumbnailURL().
+					ClearFigmaVersion().
+					ClearLastModified().
+					ClearSyncedAt()
 			}
-			upd = upd.SetURL(*req.URL)
 		}
 		if req.Description != nil {
 			upd = upd.SetDescription(*req.Description)
 		}
+		if req.AutoExport != nil {
+			upd = upd.SetAutoExport(*req.AutoExport)
+		}
 
 		updated, err := upd.Save(ctx)
+		if ent.IsNotFound(err) {
+			if kit.HasIfMatch(c) {
+				return kit.PreconditionFailed("resource has been modified", fiber.Map{"etag": kit.VersionETag(proj.Version)})
+			}
+			return kit.Conflict("project was modified concurrently", fiber.Map{"version": proj.Version})
+		}
+		if ent.IsConstraintError(err) {
+			return kit.Conflict("a project for this Figma file already exists", nil)
+		}
 		if err != nil {
 			return kit.InternalError("update project failed", err.Error())
 		}
+		if fileChanged {
+			// Snapshots of the previous file cannot be diffed against the new one
+			key := updated.FileKey
+			if updated.BranchKey != "" {
+				key = updated.BranchKey
+			}
+			_, err = tx.FileSnapshot.Delete().
+				Where(filesnapshot.ProjectIDEQ(projID), filesnapshot.FileKeyNEQ(key)).
+				Exec(ctx)
+			if err != nil {
+				return kit.InternalError("delete snapshots failed", err.Error())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
+		kit.SetETag(c, kit.VersionETag(updated.Version))
 		return kit.OK(c, updated)
 	}
 }
@@ -283,11 +353,13 @@ func UpdateProjectHandler(client *ent.Client) fiber.Handler {
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
-//	@Param        id   path  string  true  "Project UUID"
+//	@Param        id        path    string  true   "Project UUID"
+//	@Param        If-Match  header  string  false  "expected ETag"
 //	@Success      200  {object}  map[string]string
 //	@Failure      401  {object}  map[string]interface{}
 //	@Failure      403  {object}  map[string]interface{}
 //	@Failure      404  {object}  map[string]interface{}
+//	@Failure      412  {object}  map[string]interface{}
 //	@Router       /api/v1/projects/{id} [delete]
 func DeleteProjectHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -315,16 +387,56 @@ func DeleteProjectHandler(client *ent.Client) fiber.Handler {
 		if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
 			return fiber.ErrForbidden
 		}
+		if err := kit.CheckIfMatch(c, kit.VersionETag(proj.Version)); err != nil {
+			return err
+		}
+
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
 
-		// Delete project configs first (cascade delete)
-		_, err = client.ProjectConfig.Delete().Where(projectconfig.HasProjectWith(project.IDEQ(projID))).Exec(ctx)
+		// Delete project configs, history, exports and snapshots first (cascade delete);
+		// artifact blobs are left to the retention sweep
+		_, err = tx.ProjectConfig.Delete().Where(projectconfig.HasProjectWith(project.IDEQ(projID))).Exec(ctx)
 		if err != nil {
 			return kit.InternalError("delete project configs failed", err.Error())
 		}
+		_, err = tx.ProjectConfigActivation.Delete().Where(projectconfigactivation.ProjectIDEQ(projID)).Exec(ctx)
+		if err != nil {
+			return kit.InternalError("delete activation history failed", err.Error())
+		}
+		_, err = tx.Artifact.Delete().Where(artifact.HasJobWith(exportjob.ProjectIDEQ(projID))).Exec(ctx)
+		if err != nil {
+			return kit.InternalError("delete export artifacts failed", err.Error())
+		}
+		_, err = tx.ExportJob.Delete().Where(exportjob.ProjectIDEQ(projID)).Exec(ctx)
+		if err != nil {
+			return kit.InternalError("delete export jobs failed", err.Error())
+		}
+		_, err = tx.FileSnapshot.Delete().Where(filesnapshot.ProjectIDEQ(projID)).Exec(ctx)
+		if err != nil {
+			return kit.InternalError("delete file snapshots failed", err.Error())
+		}
 
-		if err := client.Project.DeleteOneID(projID).Exec(ctx); err != nil {
+		del := tx.Project.Delete().Where(project.IDEQ(projID))
+		if kit.HasIfMatch(c) {
+			del = del.Where(project.VersionEQ(proj.Version))
+		}
+		n, err := del.Exec(ctx)
+		if err != nil {
 			return kit.InternalError("delete failed", err.Error())
 		}
+		if n == 0 {
+			if kit.HasIfMatch(c) {
+				return kit.PreconditionFailed("resource has been modified", fiber.Map{"etag": kit.VersionETag(proj.Version)})
+			}
+			return kit.NotFound("project not found")
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
+		}
 		return kit.OK(c, fiber.Map{"status": "ok"})
 	}
 }
@@ -332,7 +444,7 @@ func DeleteProjectHandler(client *ent.Client) fiber.Handler {
 // AddConfigToProjectHandler adds a config to a project.
 //
 //	@Summary      Add config to project
-//	@Description  Add a config item to project (owner only)
+//	@Description  Add a config item to project (owner only). If-Match is not evaluated, as project configs are not versioned; a conflicting change of the active config returns 409.
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
@@ -343,6 +455,7 @@ func DeleteProjectHandler(client *ent.Client) fiber.Handler {
 //	@Failure      401   {object}  map[string]interface{}
 //	@Failure      403   {object}  map[string]interface{}
 //	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
 //	@Router       /api/v1/projects/{id}/configs [post]
 func AddConfigToProjectHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -386,8 +499,14 @@ func AddConfigToProjectHandler(client *ent.Client) fiber.Handler {
 			return fiber.ErrForbidden
 		}
 
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
 		// Check if config already associated with this project
-		exists, err := client.ProjectConfig.Query().
+		exists, err := tx.ProjectConfig.Query().
 			Where(projectconfig.And(
 				projectconfig.HasProjectWith(project.IDEQ(projID)),
 				projectconfig.HasConfigItemWith(configitem.IDEQ(req.ConfigID)),
@@ -397,29 +516,29 @@ func AddConfigToProjectHandler(client *ent.Client) fiber.Handler {
 			return kit.InternalError("check project config failed", err.Error())
 		}
 		if exists {
-			return kit.BadRequest("config already associated with this project", nil)
+			return kit.Conflict("config already associated with this project", nil)
 		}
 
-		// If setting this as active, deactivate others first
-		if req.Active {
-			_, err = client.ProjectConfig.Update().
-				Where(projectconfig.HasProjectWith(project.IDEQ(projID))).
-				SetActive(false).
-				Save(ctx)
-			if err != nil {
-				return kit.InternalError("deactivate existing configs failed", err.Error())
-			}
-		}
-
-		// Create the association
-		projConfig, err := client.ProjectConfig.Create().
+		// Create the association, then switch it active so the change is recorded.
+		// A concurrent request may have added the same config since the check above.
+		projConfig, err := tx.ProjectConfig.Create().
 			SetProjectID(projID).
 			SetConfigItemID(req.ConfigID).
-			SetActive(req.Active).
 			Save(ctx)
+		if ent.IsConstraintError(err) {
+			return kit.Conflict("config already associated with this project", nil)
+		}
 		if err != nil {
 			return kit.InternalError("create project config failed", err.Error())
 		}
+		if req.Active {
+			if projConfig, _, err = activate(ctx, tx, projID, ownerID, &req.ConfigID, nil); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return activeConfigError(err, "commit failed")
+		}
 
 		return kit.Created(c, projConfig)
 	}
@@ -428,7 +547,7 @@ func AddConfigToProjectHandler(client *ent.Client) fiber.Handler {
 // RemoveConfigFromProjectHandler removes a config from a project.
 //
 //	@Summary      Remove config from project
-//	@Description  Remove a config item from project (owner only)
+//	@Description  Remove a config item from project (owner only). If-Match is not evaluated, as project configs are not versioned.
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
@@ -472,18 +591,34 @@ func RemoveConfigFromProjectHandler(client *ent.Client) fiber.Handler {
 			return fiber.ErrForbidden
 		}
 
-		// Delete the association
-		deleted, err := client.ProjectConfig.Delete().
+		tx, err := client.Tx(ctx)
+		if err != nil {
+			return kit.InternalError("begin tx failed", err.Error())
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		projConfig, err := tx.ProjectConfig.Query().
 			Where(projectconfig.And(
 				projectconfig.HasProjectWith(project.IDEQ(projID)),
 				projectconfig.HasConfigItemWith(configitem.IDEQ(configID)),
 			)).
-			Exec(ctx)
+			Only(ctx)
 		if err != nil {
+			return kit.NotFound("project config association not found")
+		}
+
+		// Delete the association
+		if err := tx.ProjectConfig.DeleteOneID(projConfig.ID).Exec(ctx); err != nil {
 			return kit.InternalError("remove project config failed", err.Error())
 		}
-		if deleted == 0 {
-			return kit.NotFound("project config association not found")
+		// Removing the active config leaves the project without one
+		if projConfig.Active {
+			if _, err := recordActivation(ctx, tx, projID, ownerID, &configID, nil, nil); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return kit.InternalError("commit failed", err.Error())
 		}
 
 		return kit.OK(c, fiber.Map{"status": "ok"})
@@ -493,7 +628,7 @@ func RemoveConfigFromProjectHandler(client *ent.Client) fiber.Handler {
 // SetActiveConfigHandler sets the active config for a project.
 //
 //	@Summary      Set active config
-//	@Description  Set which config is active for a project (owner only)
+//	@Description  Set which config is active for a project (owner only). If-Match is not evaluated, as project configs are not versioned; a conflicting change returns 409.
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
@@ -504,6 +639,7 @@ func RemoveConfigFromProjectHandler(client *ent.Client) fiber.Handler {
 //	@Failure      401   {object}  map[string]interface{}
 //	@Failure      403   {object}  map[string]interface{}
 //	@Failure      404   {object}  map[string]interface{}
+//	@Failure      409   {object}  map[string]interface{}
 //	@Router       /api/v1/projects/{id}/active-config [put]
 func SetActiveConfigHandler(client *ent.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -538,32 +674,18 @@ func SetActiveConfigHandler(client *ent.Client) fiber.Handler {
 			return fiber.ErrForbidden
 		}
 
-		// Verify the config is associated with this project
-		projConfig, err := client.ProjectConfig.Query().
-			Where(projectconfig.And(
-				projectconfig.HasProjectWith(project.IDEQ(projID)),
-				projectconfig.HasConfigItemWith(configitem.IDEQ(req.ConfigID)),
-			)).
-			Only(ctx)
+		tx, err := client.Tx(ctx)
 		if err != nil {
-			return kit.NotFound("project config association not found")
+			return kit.InternalError("begin tx failed", err.Error())
 		}
+		defer func() { _ = tx.Rollback() }()
 
-		// Deactivate all configs for this project
-		_, err = client.ProjectConfig.Update().
-			Where(projectconfig.HasProjectWith(project.IDEQ(projID))).
-			SetActive(false).
-			Save(ctx)
+		updated, _, err := activate(ctx, tx, projID, ownerID, &req.ConfigID, nil)
 		if err != nil {
-			return kit.InternalError("deactivate configs failed", err.Error())
+			return err
 		}
-
-		// Activate the specified config
-		updated, err := client.ProjectConfig.UpdateOneID(projConfig.ID).
-			SetActive(true).
-			Save(ctx)
-		if err != nil {
-			return kit.InternalError("activate config failed", err.Error())
+		if err := tx.Commit(); err != nil {
+			return activeConfigError(err, "commit failed")
 		}
 
 		return kit.OK(c, updated)
@@ -573,7 +695,7 @@ func SetActiveConfigHandler(client *ent.Client) fiber.Handler {
 // ListProjectConfigsHandler lists all configs associated with a project.
 //
 //	@Summary      List project configs
-//	@Description  List configs associated with a project (owner only)
+//	@Description  List configs associated with a project (owner only), active first. The ETag header covers the associations, their active flags and the head revision of each config.
 //	@Tags         projects
 //	@Accept       json
 //	@Produce      json
@@ -621,6 +743,51 @@ func ListProjectConfigsHandler(client *ent.Client) fiber.Handler {
 			return kit.InternalError("query project configs failed", err.Error())
 		}
 
+		parts := make([]string, 0, len(projConfigs))
+		for _, pc := range projConfigs {
+			rev := 0
+			if pc.Edges.ConfigItem != nil {
+				rev = pc.Edges.ConfigItem.Revision
+			}
+			parts = append(parts, pc.ID.String()+":"+strconv.FormatBool(pc.Active)+":"+strconv.Itoa(rev))
+		}
+		kit.SetETag(c, kit.HashETag(parts...))
 		return kit.OK(c, projConfigs)
 	}
 }
+
+// activeConfigError maps a failed write of the active flag to an API error. The
+// database allows one active config per project, so a constraint violation means
+// a concurrent request activated another config first.
+func activeConfigError(err error, msg string) error {
+	if ent.IsConstraintError(err) {
+		return errActiveChanged()
+	}
+	return kit.InternalError(msg, err.Error())
+}
+
+// errActiveChanged reports that another request changed the active config of
+// a project while this one was switching it.
+func errActiveChanged() error {
+	return kit.Conflict("project configs changed concurrently, retry", nil)
+}
+
+// checkFileKey rejects a Figma file (or branch) that already backs another project
+// of the owner. projID is the project being edited (uuid.Nil on create).
+func checkFileKey(ctx context.Context, client *ent.Client, ownerID, projID uuid.UUID, ref *figmax.FileRef) error {
+	existing, err := client.Project.Query().
+		Where(
+			project.HasOwnerWith(user.IDEQ(ownerID)),
+			project.FileKeyEQ(ref.FileKey),
+			project.BranchKeyEQ(ref.BranchKey),
+			project.IDNEQ(projID),
+		).
+		FirstID(ctx)
+	if ent.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return kit.InternalError("check project file failed", err.Error())
+	}
+	return kit.Conflict("a project for this Figma file already exists", fiber.Map{"project_id": existing, "file_key": ref.FileKey, "branch_key": ref.BranchKey})
+}
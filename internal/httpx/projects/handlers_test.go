@@ -0,0 +1,272 @@
+package projects
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/ent/projectconfig"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	dsn := "file:projects?mode=memory&cache=shared&_fk=1"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	drv := entsql.OpenDB(dialect.SQLite, db)
+	client := ent.NewClient(ent.Driver(drv))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+// asUser mounts a middleware that authenticates every request as the given user.
+func asUser(id uuid.UUID) func(*fiber.App) {
+	return func(app *fiber.App) {
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("auth", &mw.AuthContext{Subject: "user:" + id.String(), Kind: "user"})
+			return c.Next()
+		})
+	}
+}
+
+// doJSON sends a JSON request and decodes the response envelope into out (if non-nil).
+func doJSON(t *testing.T, app *fiber.App, method, target string, body any, out any) *http.Response {
+	t.Helper()
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, rd)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, target, err)
+		}
+	}
+	return res
+}
+
+// figmaKey returns a random key shaped like a Figma file key.
+func figmaKey() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")[:22]
+}
+
+// newProject creates a user owning a project backed by a fresh Figma file key.
+func newProject(t *testing.T, client *ent.Client) (*ent.User, *ent.Project) {
+	t.Helper()
+	ctx := context.Background()
+	u := client.User.Create().SetDisplayName("Owner").SaveX(ctx)
+	key := figmaKey()
+	p := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/" + key).
+		SetFileKey(key).SetOwnerID(u.ID).SaveX(ctx)
+	return u, p
+}
+
+func TestProjectConfigs_SingleActive(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+
+	routes := func(app *fiber.App) {
+		app.Post("/projects/:id/configs", mw.RequireUser(), AddConfigToProjectHandler(client))
+		app.Put("/projects/:id/active-config", mw.RequireUser(), SetActiveConfigHandler(client))
+		app.Get("/projects/:id/configs", mw.RequireUser(), ListProjectConfigsHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	base := "/projects/" + p.ID.String()
+	listETag := func() string {
+		t.Helper()
+		res := doJSON(t, app, http.MethodGet, base+"/configs", nil, nil)
+		if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == "" {
+			t.Fatalf("list configs: got %d, ETag %q", res.StatusCode, res.Header.Get("ETag"))
+		}
+		return res.Header.Get("ETag")
+	}
+
+	var cfgs []uuid.UUID
+	for i := 0; i < 3; i++ {
+		cfg := client.ConfigItem.Create().SetName("c").SetData(map[string]any{"i": i}).SetOwnerID(owner.ID).SaveX(ctx)
+		cfgs = append(cfgs, cfg.ID)
+		body := map[string]any{"config_id": cfg.ID, "active": true}
+		if res := doJSON(t, app, http.MethodPost, base+"/configs", body, nil); res.StatusCode != http.StatusCreated {
+			t.Fatalf("add config %d: expected 201, got %d", i, res.StatusCode)
+		}
+	}
+	activeIDs := func() []uuid.UUID {
+		return client.ProjectConfig.Query().
+			Where(projectconfig.HasProjectWith(project.IDEQ(p.ID)), projectconfig.ActiveEQ(true)).
+			QueryConfigItem().IDsX(ctx)
+	}
+	if got := activeIDs(); len(got) != 1 || got[0] != cfgs[2] {
+		t.Fatalf("after add: active %v, want %s", got, cfgs[2])
+	}
+	etag := listETag()
+	if again := listETag(); again != etag {
+		t.Fatalf("list ETag changed without writes: %s, %s", etag, again)
+	}
+
+	if res := doJSON(t, app, http.MethodPut, base+"/active-config", map[string]any{"config_id": cfgs[0]}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("set active: expected 200, got %d", res.StatusCode)
+	}
+	if got := activeIDs(); len(got) != 1 || got[0] != cfgs[0] {
+		t.Fatalf("after set: active %v, want %s", got, cfgs[0])
+	}
+	next := listETag()
+	if next == etag {
+		t.Fatal("list ETag unchanged after switching the active config")
+	}
+	// Editing an associated config changes it too
+	client.ConfigItem.UpdateOneID(cfgs[1]).AddRevision(1).ExecX(ctx)
+	if listETag() == next {
+		t.Fatal("list ETag unchanged after a config revision")
+	}
+
+	// Adding the same config again is a conflict, not a concurrent change
+	var errBody struct {
+		Message string `json:"message"`
+	}
+	res := doJSON(t, app, http.MethodPost, base+"/configs", map[string]any{"config_id": cfgs[1]}, &errBody)
+	if res.StatusCode != http.StatusConflict || errBody.Message != "config already associated with this project" {
+		t.Fatalf("duplicate add: got %d %q", res.StatusCode, errBody.Message)
+	}
+
+	// The database refuses a second active config outright, and writers
+	// losing that race see a retryable conflict
+	other := client.ProjectConfig.Query().
+		Where(projectconfig.HasProjectWith(project.IDEQ(p.ID)), projectconfig.ActiveEQ(false)).
+		FirstX(ctx)
+	err := client.ProjectConfig.UpdateOneID(other.ID).SetActive(true).Exec(ctx)
+	if !ent.IsConstraintError(err) {
+		t.Fatalf("second active config: %v", err)
+	}
+	var apiErr *kit.APIError
+	if !errors.As(activeConfigError(err, "activate config failed"), &apiErr) || apiErr.HTTPStatus != http.StatusConflict {
+		t.Fatalf("activation race: got %v", apiErr)
+	}
+}
+
+func TestProject_Create_Update_DedupesFigmaFile(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner := client.User.Create().SetDisplayName("Owner").SaveX(ctx)
+	other := client.User.Create().SetDisplayName("Other").SaveX(ctx)
+
+	routes := func(app *fiber.App) {
+		app.Post("/projects", mw.RequireUser(), CreateProjectHandler(client))
+		app.Put("/projects/:id", mw.RequireUser(), UpdateProjectHandler(client))
+	}
+	app := testutil.NewApp(asUser(owner.ID), routes)
+	file, branch := figmaKey(), figmaKey()
+	fileURL := "https://www.figma.com/file/" + file + "/Design"
+	create := func(app *fiber.App, url string) (*http.Response, *ent.Project) {
+		var env struct {
+			Data ent.Project `json:"data"`
+		}
+		res := doJSON(t, app, http.MethodPost, "/projects", map[string]any{"name": "p", "url": url}, &env)
+		return res, &env.Data
+	}
+
+	res, main := create(app, fileURL)
+	if res.StatusCode != http.StatusCreated || main.FileKey != file || main.BranchKey != "" {
+		t.Fatalf("create: got %d %+v", res.StatusCode, main)
+	}
+	// The same file under another link or node is the same project
+	if res, _ := create(app, "https://figma.com/design/"+file+"?node-id=1-2"); res.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate file: expected 409, got %d", res.StatusCode)
+	}
+	// A branch of the file is a project of its own, once
+	branchURL := "https://www.figma.com/design/" + file + "/branch/" + branch + "/Design"
+	res, br := create(app, branchURL)
+	if res.StatusCode != http.StatusCreated || br.FileKey != file || br.BranchKey != branch {
+		t.Fatalf("branch: got %d %+v", res.StatusCode, br)
+	}
+	if res, _ := create(app, branchURL+"?node-id=3-4"); res.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate branch: expected 409, got %d", res.StatusCode)
+	}
+	// Other owners track the same file independently
+	if res, _ := create(testutil.NewApp(asUser(other.ID), routes), fileURL); res.StatusCode != http.StatusCreated {
+		t.Fatalf("other owner: expected 201, got %d", res.StatusCode)
+	}
+
+	// Pointing another project at a tracked file is refused; re-saving its own file is not
+	res, side := create(app, "https://www.figma.com/design/"+figmaKey())
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("create side: got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPut, "/projects/"+side.ID.String(), map[string]any{"url": fileURL}, nil); res.StatusCode != http.StatusConflict {
+		t.Fatalf("update to tracked file: expected 409, got %d", res.StatusCode)
+	}
+	if res := doJSON(t, app, http.MethodPut, "/projects/"+main.ID.String(), map[string]any{"url": fileURL + "?node-id=5-6"}, nil); res.StatusCode != http.StatusOK {
+		t.Fatalf("update own file: expected 200, got %d", res.StatusCode)
+	}
+	// The index backs the check for writers racing past it
+	err := client.Project.UpdateOneID(side.ID).SetFileKey(file).SetBranchKey(branch).Exec(ctx)
+	if !ent.IsConstraintError(err) {
+		t.Fatalf("duplicate keys: %v", err)
+	}
+}
+
+func TestProject_Update_ClearsSyncedMetadata(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	now := time.Now()
+	client.Project.UpdateOne(p).SetFileName("Design").SetThumbnailURL("https://example.com/t.png").
+		SetFigmaVersion("42").SetLastModified(now).SetSyncedAt(now).ExecX(ctx)
+
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Put("/projects/:id", mw.RequireUser(), UpdateProjectHandler(client))
+	})
+	update := func(url string) {
+		t.Helper()
+		if res := doJSON(t, app, http.MethodPut, "/projects/"+p.ID.String(), map[string]any{"url": url}, nil); res.StatusCode != http.StatusOK {
+			t.Fatalf("update %s: expected 200, got %d", url, res.StatusCode)
+		}
+	}
+
+	// Another node of the same file keeps the metadata
+	update(p.URL + "?node-id=1-2")
+	if got := client.Project.GetX(ctx, p.ID); got.FigmaVersion != "42" || got.SyncedAt == nil {
+		t.Fatalf("same file: %+v", got)
+	}
+	// A branch is another file
+	update("https://www.figma.com/design/" + p.FileKey + "/branch/" + figmaKey() + "/Design")
+	got := client.Project.GetX(ctx, p.ID)
+	if got.FileName != "" || got.ThumbnailURL != "" || got.FigmaVersion != "" || got.LastModified != nil || got.SyncedAt != nil {
+		t.Fatalf("another file: %+v", got)
+	}
+}
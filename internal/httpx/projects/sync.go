@@ -0,0 +1,129 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/project"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+// SyncProjectHandler pulls the Figma file metadata of a project onto it.
+//
+//	@Summary      Sync project from Figma
+//	@Description  Fetch the file name, thumbnail, last-modified time and version of the project's Figma file (owner only). Branch projects read the branch.
+//	@Tags         projects
+//	@Accept       json
+//	@Produce      json
+//	@Param        id   path  string  true  "Project UUID"
+//	@Success      200  {object}  map[string]interface{}
+//	@Failure      400  {object}  map[string]interface{}
+//	@Failure      401  {object}  map[string]interface{}
+//	@Failure      403  {object}  map[string]interface{}
+//	@Failure      404  {object}  map[string]interface{}
+//	@Failure      409  {object}  map[string]interface{}
+//	@Failure      429  {object}  map[string]interface{}  "Figma rate limit, see Retry-After"
+//	@Failure      502  {object}  map[string]interface{}  "Figma rejected the token or failed"
+//	@Failure      503  {object}  map[string]interface{}  "Figma is not configured"
+//	@Router       /api/v1/projects/{id}/sync [post]
+func SyncProjectHandler(client *ent.Client, fg *figmax.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ac, _ := c.Locals("auth").(*mw.AuthContext)
+		if ac == nil || ac.Kind != "user" || !strings.HasPrefix(ac.Subject, "user:") {
+			return fiber.ErrUnauthorized
+		}
+		ownerID, err := uuid.Parse(strings.TrimPrefix(ac.Subject, "user:"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+
+		projID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return kit.BadRequest("invalid project id", c.Params("id"))
+		}
+		if fg == nil {
+			return kit.NewAPIError(http.StatusServiceUnavailable, "E_FIGMA_DISABLED", "figma integration is not configured", nil)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 20*time.Second)
+		defer cancel()
+
+		proj, err := client.Project.Query().Where(project.IDEQ(projID)).WithOwner().Only(ctx)
+		if err != nil {
+			return kit.NotFound("project not found")
+		}
+		if proj.Edges.Owner == nil || proj.Edges.Owner.ID != ownerID {
+			return fiber.ErrForbidden
+		}
+		if proj.FileKey == "" {
+			return kit.BadRequest("project has no Figma file, update its url first", nil)
+		}
+
+		// Branches are addressed by their own key
+		key := proj.FileKey
+		if proj.BranchKey != "" {
+			key = proj.BranchKey
+		}
+		file, err := fg.GetFile(ctx, key, figmax.FileOptions{Depth: 1})
+		if err != nil {
+			return figmaError(c, err)
+		}
+
+		upd := client.Project.UpdateOneID(projID).
+			Where(project.VersionEQ(proj.Version)).
+			AddVersion(1).
+			SetFileName(file.Name).
+			SetThumbnailURL(file.ThumbnailURL).
+			SetFigmaVersion(file.Version).
+			SetSyncedAt(time.Now())
+		if !file.LastModified.IsZero() {
+			upd = upd.SetLastModified(file.LastModified)
+		}
+		updated, err := upd.Save(ctx)
+		if ent.IsNotFound(err) {
+			return kit.Conflict("project was modified concurrently", fiber.Map{"version": proj.Version})
+		}
+		if err != nil {
+			return kit.InternalError("update project failed", err.Error())
+		}
+		kit.SetETag(c, kit.VersionETag(updated.Version))
+		return kit.OK(c, updated)
+	}
+}
+
+// figmaError maps a Figma client error to an API error. Rate limits keep the
+// upstream Retry-After so clients know when to try again.
+func figmaError(c *fiber.Ctx, err error) error {
+	var fe *figmax.Error
+	switch {
+	case errors.Is(err, figmax.ErrRateLimited):
+		details := fiber.Map{}
+		if errors.As(err, &fe) && fe.RetryAfter > 0 {
+			secs := int(math.Ceil(fe.RetryAfter.Seconds()))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(secs))
+			details["retry_after"] = secs
+		}
+		return kit.NewAPIError(http.StatusTooManyRequests, "E_FIGMA_RATE_LIMITED", "figma rate limit exceeded", details)
+	case errors.Is(err, figmax.ErrUnauthorized):
+		return kit.NewAPIError(http.StatusBadGateway, "E_FIGMA_UNAUTHORIZED", "figma rejected the access token or denied access to the file", nil)
+	case errors.Is(err, figmax.ErrNotFound):
+		return kit.NewAPIError(http.StatusNotFound, "E_FIGMA_NOT_FOUND", "figma file not found", nil)
+	case errors.As(err, &fe):
+		return kit.NewAPIError(http.StatusBadGateway, "E_FIGMA_UPSTREAM", "figma request failed", fe.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return kit.NewAPIError(http.StatusGatewayTimeout, "E_FIGMA_TIMEOUT", "figma request timed out", nil)
+	default:
+		return kit.NewAPIError(http.StatusBadGateway, "E_FIGMA_UPSTREAM", "figma request failed", err.Error())
+	}
+}
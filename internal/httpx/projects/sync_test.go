@@ -0,0 +1,94 @@
+package projects
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/figmax/figmatest"
+	"fiber-ent-apollo-pg/internal/httpx/kit"
+	"fiber-ent-apollo-pg/internal/httpx/kit/testutil"
+	"fiber-ent-apollo-pg/internal/httpx/mw"
+)
+
+func TestSyncProject(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	owner, p := newProject(t, client)
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	srv.SetFile(p.FileKey, figmatest.File{File: figmax.File{
+		Name:         "Design System",
+		LastModified: modified,
+		ThumbnailURL: "https://thumb.example/1.png",
+		Version:      "42",
+	}})
+	app := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Post("/projects/:id/sync", mw.RequireUser(), SyncProjectHandler(client, srv.FigmaClient()))
+	})
+	target := "/projects/" + p.ID.String() + "/sync"
+
+	var env struct {
+		Data ent.Project `json:"data"`
+	}
+	res := doJSON(t, app, http.MethodPost, target, nil, &env)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("sync: expected 200, got %d", res.StatusCode)
+	}
+	got := env.Data
+	if got.FileName != "Design System" || got.FigmaVersion != "42" || got.ThumbnailURL != "https://thumb.example/1.png" ||
+		got.LastModified == nil || !got.LastModified.Equal(modified) || got.SyncedAt == nil || got.Version != p.Version+1 {
+		t.Fatalf("unexpected synced project: %+v", got)
+	}
+	if res.Header.Get("ETag") != kit.VersionETag(got.Version) {
+		t.Fatalf("etag: %q", res.Header.Get("ETag"))
+	}
+
+	// Branch projects read the branch
+	branch := client.Project.Create().SetName("branch").SetURL("https://www.figma.com/design/" + p.FileKey + "/branch/BR1").
+		SetFileKey(p.FileKey).SetBranchKey("BR1").SetOwnerID(owner.ID).SaveX(ctx)
+	srv.SetFile("BR1", figmatest.File{File: figmax.File{Name: "Design System (branch)", Version: "43"}})
+	env.Data = ent.Project{}
+	if res := doJSON(t, app, http.MethodPost, "/projects/"+branch.ID.String()+"/sync", nil, &env); res.StatusCode != http.StatusOK || env.Data.FileName != "Design System (branch)" {
+		t.Fatalf("branch sync: got %d %q", res.StatusCode, env.Data.FileName)
+	}
+
+	// Rate limits are passed through with Figma's Retry-After
+	srv.FailNext(http.StatusTooManyRequests, 30)
+	var errBody struct {
+		Code    string         `json:"code"`
+		Details map[string]any `json:"details"`
+	}
+	res = doJSON(t, app, http.MethodPost, target, nil, &errBody)
+	if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") != "30" {
+		t.Fatalf("rate limited: got %d Retry-After=%q", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+	if errBody.Code != "E_FIGMA_RATE_LIMITED" || errBody.Details["retry_after"] != float64(30) {
+		t.Fatalf("rate limited body: %+v", errBody)
+	}
+	if v := client.Project.GetX(ctx, p.ID).Version; v != got.Version {
+		t.Fatalf("failed sync changed the project: version %d", v)
+	}
+
+	// A project created before file keys were stored cannot be synced
+	legacy := client.Project.Create().SetName("legacy").SetURL("https://example.com/legacy").SetOwnerID(owner.ID).SaveX(ctx)
+	if res := doJSON(t, app, http.MethodPost, "/projects/"+legacy.ID.String()+"/sync", nil, nil); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("no file key: expected 400, got %d", res.StatusCode)
+	}
+
+	// Without a Figma token the endpoint is unavailable
+	disabled := testutil.NewApp(asUser(owner.ID), func(app *fiber.App) {
+		app.Post("/projects/:id/sync", mw.RequireUser(), SyncProjectHandler(client, nil))
+	})
+	res = doJSON(t, disabled, http.MethodPost, target, nil, &errBody)
+	if res.StatusCode != http.StatusServiceUnavailable || errBody.Code != "E_FIGMA_DISABLED" {
+		t.Fatalf("disabled: got %d %q", res.StatusCode, errBody.Code)
+	}
+}
@@ -5,12 +5,16 @@ import (
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 
 	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/blobx"
 	"fiber-ent-apollo-pg/internal/config"
 	"fiber-ent-apollo-pg/internal/esx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
 	"fiber-ent-apollo-pg/internal/httpx/admin"
 	"fiber-ent-apollo-pg/internal/httpx/auth"
 	"fiber-ent-apollo-pg/internal/httpx/configs"
 	"fiber-ent-apollo-pg/internal/httpx/groups"
+	"fiber-ent-apollo-pg/internal/httpx/integrations"
 	"fiber-ent-apollo-pg/internal/httpx/mw"
 	"fiber-ent-apollo-pg/internal/httpx/projects"
 	"fiber-ent-apollo-pg/internal/httpx/users"
@@ -20,9 +24,14 @@ import (
 
 // Providers 外部依赖提供者
 type Providers struct {
-	MQ  mqx.Publisher
-	ES  *esx.Client
-	RDB *redisx.Client
+	MQ      mqx.Publisher
+	ES      *esx.Client
+	RDB     *redisx.Client
+	Figma   *figmax.Client
+	Exports *exportx.Service
+	Blobs   blobx.Store
+	// AutoExport schedules exports for Figma webhook events
+	AutoExport *exportx.AutoExporter
 }
 
 // Register 注册所有 HTTP 路由
@@ -43,9 +52,19 @@ func Register(app *fiber.App, client *ent.Client, providers ...*Providers) {
 		}
 		return claims.Subject, claims.Kind, claims.Roles, claims.DeviceID, nil
 	}))
-	var rdb *redisx.Client
+	var (
+		rdb     *redisx.Client
+		fg      *figmax.Client
+		exports *exportx.Service
+		blobs   blobx.Store
+		auto    *exportx.AutoExporter
+	)
 	if len(providers) > 0 && providers[0] != nil {
 		rdb = providers[0].RDB
+		fg = providers[0].Figma
+		exports = providers[0].Exports
+		blobs = providers[0].Blobs
+		auto = providers[0].AutoExport
 	}
 
 	// �������
@@ -67,21 +86,47 @@ func Register(app *fiber.App, client *ent.Client, providers ...*Providers) {
 	// Protected admin example (requires admin role)
 	v1.Get("/admin/ping", mw.RequireUser(), mw.RequireRoles("admin"), admin.PingHandler())
 	v1.Post("/admin/users/:id/promote", mw.RequireUser(), mw.RequireRoles("admin"), admin.PromoteUserHandler(client))
+	v1.Post("/admin/config-schemas", mw.RequireUser(), mw.RequireRoles("admin"), configs.RegisterSchemaHandler(client))
+	v1.Get("/admin/config-schemas", mw.RequireUser(), mw.RequireRoles("admin"), configs.ListSchemasHandler(client))
 
 	// Configs & Groups
 	v1.Get("/configs", mw.RequireUser(), configs.ListConfigsHandler(client))
 	v1.Get("/configs/visible", mw.RequireUser(), configs.VisibleConfigsHandler(client))
 	v1.Post("/configs", mw.RequireUser(), configs.CreateConfigHandler(client))
+	v1.Get("/configs/:id", mw.RequireUser(), configs.GetConfigHandler(client))
 	v1.Put("/configs/:id", mw.RequireUser(), configs.UpdateConfigHandler(client))
+	v1.Patch("/configs/:id", mw.RequireUser(), configs.PatchConfigHandler(client))
 	v1.Delete("/configs/:id", mw.RequireUser(), configs.DeleteConfigHandler(client))
+	v1.Get("/configs/:id/diff", mw.RequireUser(), configs.DiffConfigHandler(client))
+	v1.Get("/configs/:id/resolved", mw.RequireUser(), configs.ResolvedConfigHandler(client))
+	v1.Post("/configs/:id/naming/preview", mw.RequireUser(), configs.NamingPreviewHandler(client))
+	v1.Post("/configs/:id/fork", mw.RequireUser(), configs.ForkConfigHandler(client))
+	v1.Get("/configs/:id/forks", mw.RequireUser(), configs.ListForksHandler(client))
+	v1.Get("/configs/:id/revisions", mw.RequireUser(), configs.ListRevisionsHandler(client))
+	v1.Get("/configs/:id/revisions/:rev", mw.RequireUser(), configs.GetRevisionHandler(client))
+	v1.Post("/configs/:id/revisions/:rev/restore", mw.RequireUser(), configs.RestoreRevisionHandler(client))
 	v1.Post("/configs/:id/share/groups", mw.RequireUser(), configs.ShareToGroupsHandler(client))
 	v1.Post("/configs/:id/unshare/groups", mw.RequireUser(), configs.UnshareFromGroupsHandler(client))
 	v1.Post("/configs/:id/share/user/:user_id", mw.RequireUser(), configs.ShareToUserHandler(client))
 	v1.Post("/configs/:id/unshare/user/:user_id", mw.RequireUser(), configs.UnshareFromUserHandler(client))
+	v1.Post("/configs/:id/share", mw.RequireUser(), configs.ShareHandler(client))
+	v1.Get("/configs/:id/pending-shares", mw.RequireUser(), configs.ListPendingSharesHandler(client))
+	v1.Delete("/configs/:id/pending-shares/:pid", mw.RequireUser(), configs.RevokePendingShareHandler(client))
+	v1.Post("/configs/:id/pending-shares/:pid/confirm", mw.RequireUser(), configs.ConfirmPendingShareHandler(client))
 
 	v1.Get("/groups", mw.RequireUser(), groups.ListMyGroupsHandler(client))
 	v1.Post("/groups", mw.RequireUser(), groups.CreateGroupHandler(client))
 	v1.Delete("/groups/:id", mw.RequireUser(), groups.DeleteGroupHandler(client))
+	v1.Get("/groups/:id/members", mw.RequireUser(), groups.ListMembersHandler(client))
+	v1.Post("/groups/:id/members", mw.RequireUser(), groups.AddMembersHandler(client))
+	v1.Put("/groups/:id/members/:user_id", mw.RequireUser(), groups.UpdateMemberRoleHandler(client))
+	v1.Delete("/groups/:id/members/:user_id", mw.RequireUser(), groups.RemoveMemberHandler(client))
+	v1.Post("/groups/:id/leave", mw.RequireUser(), groups.LeaveGroupHandler(client))
+	v1.Post("/groups/:id/transfer", mw.RequireUser(), groups.TransferOwnershipHandler(client))
+	v1.Get("/groups/:id/invitations", mw.RequireUser(), groups.ListInvitationsHandler(client))
+	v1.Post("/groups/:id/invitations", mw.RequireUser(), groups.CreateInvitationHandler(client))
+	v1.Delete("/groups/:id/invitations/:iid", mw.RequireUser(), groups.RevokeInvitationHandler(client))
+	v1.Post("/invitations/accept", mw.RequireUser(), groups.AcceptInvitationHandler(client))
 
 	// Projects
 	v1.Get("/projects", mw.RequireUser(), projects.ListProjectsHandler(client))
@@ -89,10 +134,28 @@ func Register(app *fiber.App, client *ent.Client, providers ...*Providers) {
 	v1.Get("/projects/:id", mw.RequireUser(), projects.GetProjectHandler(client))
 	v1.Put("/projects/:id", mw.RequireUser(), projects.UpdateProjectHandler(client))
 	v1.Delete("/projects/:id", mw.RequireUser(), projects.DeleteProjectHandler(client))
+	v1.Post("/projects/:id/sync", mw.RequireUser(), projects.SyncProjectHandler(client, fg))
+	v1.Get("/projects/:id/changes", mw.RequireUser(), projects.ProjectChangesHandler(client, fg))
 
 	// Project Configs
 	v1.Get("/projects/:id/configs", mw.RequireUser(), projects.ListProjectConfigsHandler(client))
 	v1.Post("/projects/:id/configs", mw.RequireUser(), projects.AddConfigToProjectHandler(client))
 	v1.Delete("/projects/:id/configs/:config_id", mw.RequireUser(), projects.RemoveConfigFromProjectHandler(client))
 	v1.Put("/projects/:id/active-config", mw.RequireUser(), projects.SetActiveConfigHandler(client))
+	v1.Get("/projects/:id/active-config", mw.RequireUser(), projects.GetActiveConfigHandler(client))
+	v1.Get("/projects/:id/active-config/raw", mw.RequireUser(), projects.GetActiveConfigRawHandler(client))
+	v1.Get("/projects/:id/activations", mw.RequireUser(), projects.ListActivationsHandler(client))
+	v1.Post("/projects/:id/activations/:aid/rollback", mw.RequireUser(), projects.RollbackActivationHandler(client))
+
+	// Exports
+	v1.Post("/projects/:id/exports", mw.RequireUser(), projects.CreateExportHandler(client, exports))
+	v1.Get("/projects/:id/exports", mw.RequireUser(), projects.ListExportsHandler(client))
+	v1.Get("/projects/:id/exports/:eid", mw.RequireUser(), projects.GetExportHandler(client))
+	v1.Post("/projects/:id/exports/:eid/cancel", mw.RequireUser(), projects.CancelExportHandler(client))
+	v1.Get("/projects/:id/exports/:eid/artifacts", mw.RequireUser(), projects.ListArtifactsHandler(client))
+	v1.Get("/projects/:id/exports/:eid/artifacts/:aid/download", mw.RequireUser(), projects.DownloadArtifactHandler(client, blobs))
+	v1.Get("/projects/:id/exports/:eid/bundle.zip", mw.RequireUser(), projects.BundleExportHandler(client, blobs))
+
+	// Integrations (authenticated by webhook passcode)
+	v1.Post("/integrations/figma/webhook", integrations.FigmaWebhookHandler(client, auto, cfg.Figma.WebhookPasscode))
 }
@@ -0,0 +1,131 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change is a single difference at a JSON pointer. Old is nil for additions
+// and New is nil for removals.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// Diff is the structured difference between two documents.
+type Diff struct {
+	Added   []Change `json:"added"`
+	Removed []Change `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// Empty reports whether the documents were equal.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the differences that turn from into to. Objects are compared
+// member by member and arrays index by index; any other mismatch (including a
+// change of type) is reported as a change of the whole value. Changes are
+// ordered by path.
+func Compare(from, to any) Diff {
+	d := Diff{Added: []Change{}, Removed: []Change{}, Changed: []Change{}}
+	compare(from, to, "", &d)
+	return d
+}
+
+func compare(a, b any, path string, d *Diff) {
+	switch ta := a.(type) {
+	case map[string]any:
+		if tb, ok := b.(map[string]any); ok {
+			keys := make([]string, 0, len(ta)+len(tb))
+			for k := range ta {
+				keys = append(keys, k)
+			}
+			for k := range tb {
+				if _, ok := ta[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				p := JoinPointer(path, k)
+				va, inA := ta[k]
+				vb, inB := tb[k]
+				switch {
+				case !inB:
+					d.Removed = append(d.Removed, Change{Path: p, Old: va})
+				case !inA:
+					d.Added = append(d.Added, Change{Path: p, New: vb})
+				default:
+					compare(va, vb, p, d)
+				}
+			}
+			return
+		}
+	case []any:
+		if tb, ok := b.([]any); ok {
+			for i := 0; i < len(ta) || i < len(tb); i++ {
+				p := fmt.Sprintf("%s/%d", path, i)
+				switch {
+				case i >= len(tb):
+					d.Removed = append(d.Removed, Change{Path: p, Old: ta[i]})
+				case i >= len(ta):
+					d.Added = append(d.Added, Change{Path: p, New: tb[i]})
+				default:
+					compare(ta[i], tb[i], p, d)
+				}
+			}
+			return
+		}
+	}
+	if !Equal(a, b) {
+		d.Changed = append(d.Changed, Change{Path: path, Old: a, New: b})
+	}
+}
+
+// RenderText renders d as a unified-style listing: removed and old values are
+// prefixed with "-", added and new values with "+", ordered by path.
+func RenderText(d Diff, fromLabel, toLabel string) string {
+	type line struct {
+		path string
+		text string
+	}
+	var lines []line
+	for _, c := range d.Removed {
+		lines = append(lines, line{c.Path, "- " + location(c.Path) + ": " + compact(c.Old)})
+	}
+	for _, c := range d.Added {
+		lines = append(lines, line{c.Path, "+ " + location(c.Path) + ": " + compact(c.New)})
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, line{c.Path, "- " + location(c.Path) + ": " + compact(c.Old) + "\n+ " + location(c.Path) + ": " + compact(c.New)})
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].path < lines[j].path })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	for _, l := range lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func location(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+func compact(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,47 @@
+package jsonx
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	from := decode(t, `{"a":1,"b":{"c":"x","d":[1,2,3]},"e":true,"f":{"g":1}}`)
+	to := decode(t, `{"a":1,"b":{"c":"y","d":[1,5]},"f":"flat","h":null}`)
+	d := Compare(from, to)
+
+	paths := func(cs []Change) []string {
+		out := []string{}
+		for _, c := range cs {
+			out = append(out, c.Path)
+		}
+		return out
+	}
+	check := func(name string, got, want []string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s = %v, want %v", name, got, want)
+			}
+		}
+	}
+	check("added", paths(d.Added), []string{"/h"})
+	check("removed", paths(d.Removed), []string{"/b/d/2", "/e"})
+	check("changed", paths(d.Changed), []string{"/b/c", "/b/d/1", "/f"})
+	if d.Changed[0].Old != "x" || d.Changed[0].New != "y" {
+		t.Fatalf("unexpected change: %+v", d.Changed[0])
+	}
+	if !Compare(from, Clone(from)).Empty() {
+		t.Fatalf("equal documents produced a diff")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	d := Compare(decode(t, `{"a":1,"b":"x"}`), decode(t, `{"a":2,"c":[1]}`))
+	want := "--- old\n+++ new\n- /a: 1\n+ /a: 2\n- /b: \"x\"\n+ /c: [1]\n"
+	if got := RenderText(d, "old", "new"); got != want {
+		t.Fatalf("RenderText =\n%s\nwant\n%s", got, want)
+	}
+}
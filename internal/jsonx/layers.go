@@ -0,0 +1,72 @@
+package jsonx
+
+import "strings"
+
+// MergeLayers deep-merges objects in order, later layers taking precedence.
+// Objects merge member by member, arrays and scalars replace the lower value
+// wholesale and a null member deletes the key. Inputs are not modified.
+//
+// The returned sources map every leaf pointer of the result (a non-object
+// value or an empty object) to the index of the layer that supplied it.
+func MergeLayers(layers ...map[string]any) (map[string]any, map[string]int) {
+	out := map[string]any{}
+	prov := map[string]int{}
+	for i, l := range layers {
+		mergeLayer(out, l, "", i, prov)
+	}
+	sources := map[string]int{}
+	collectLeaves(out, "", func(p string) {
+		if i, ok := prov[p]; ok {
+			sources[p] = i
+		}
+	})
+	return out, sources
+}
+
+func mergeLayer(dst, src map[string]any, path string, layer int, prov map[string]int) {
+	for k, v := range src {
+		p := JoinPointer(path, k)
+		if v == nil {
+			delete(dst, k)
+			dropPrefix(prov, p)
+			continue
+		}
+		if sm, ok := v.(map[string]any); ok {
+			dm, ok := dst[k].(map[string]any)
+			if !ok {
+				dropPrefix(prov, p)
+				dm = map[string]any{}
+				dst[k] = dm
+			}
+			if len(sm) == 0 {
+				prov[p] = layer
+			}
+			mergeLayer(dm, sm, p, layer, prov)
+			continue
+		}
+		dropPrefix(prov, p)
+		dst[k] = Clone(v)
+		prov[p] = layer
+	}
+}
+
+// dropPrefix forgets the provenance of p and everything below it.
+func dropPrefix(prov map[string]int, p string) {
+	delete(prov, p)
+	for k := range prov {
+		if strings.HasPrefix(k, p+"/") {
+			delete(prov, k)
+		}
+	}
+}
+
+func collectLeaves(m map[string]any, path string, visit func(string)) {
+	for k, v := range m {
+		p := JoinPointer(path, k)
+		if sub, ok := v.(map[string]any); ok && len(sub) > 0 {
+			collectLeaves(sub, p, visit)
+			continue
+		}
+		visit(p)
+	}
+}
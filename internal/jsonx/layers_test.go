@@ -0,0 +1,27 @@
+package jsonx
+
+import "testing"
+
+func TestMergeLayers(t *testing.T) {
+	base := decode(t, `{"format":"png","scale":1,"tags":["a","b"],"naming":{"case":"kebab","prefix":"ic"},"legacy":{"x":1}}`).(map[string]any)
+	team := decode(t, `{"scale":2,"tags":["c"],"naming":{"prefix":null,"suffix":"-v2"}}`).(map[string]any)
+	own := decode(t, `{"legacy":null,"extra":{}}`).(map[string]any)
+
+	got, sources := MergeLayers(base, team, own)
+	want := decode(t, `{"format":"png","scale":2,"tags":["c"],"naming":{"case":"kebab","suffix":"-v2"},"extra":{}}`)
+	if !Equal(got, want) {
+		t.Fatalf("MergeLayers = %v, want %v", got, want)
+	}
+	wantSources := map[string]int{"/format": 0, "/scale": 1, "/tags": 1, "/naming/case": 0, "/naming/suffix": 1, "/extra": 2}
+	if len(sources) != len(wantSources) {
+		t.Fatalf("sources = %v, want %v", sources, wantSources)
+	}
+	for p, i := range wantSources {
+		if sources[p] != i {
+			t.Fatalf("sources[%s] = %d, want %d (all: %v)", p, sources[p], i, sources)
+		}
+	}
+	if !Equal(base, decode(t, `{"format":"png","scale":1,"tags":["a","b"],"naming":{"case":"kebab","prefix":"ic"},"legacy":{"x":1}}`)) {
+		t.Fatalf("MergeLayers mutated input: %v", base)
+	}
+}
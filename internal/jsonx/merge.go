@@ -0,0 +1,47 @@
+package jsonx
+
+// MergePatch applies an RFC 7396 JSON merge patch to doc and returns the result.
+// Objects are merged recursively, null removes a member and any other value
+// (including arrays) replaces the target. doc is not modified.
+func MergePatch(doc any, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return Clone(patch)
+	}
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+	out := make(map[string]any, len(target))
+	for k, v := range target {
+		out[k] = v
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		out[k] = MergePatch(out[k], v)
+	}
+	return out
+}
+
+// Clone returns a deep copy of a decoded JSON value.
+func Clone(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, vv := range t {
+			out[k] = Clone(vv)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, vv := range t {
+			out[i] = Clone(vv)
+		}
+		return out
+	default:
+		return v
+	}
+}
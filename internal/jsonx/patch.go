@@ -0,0 +1,307 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// OpError reports the patch operation that could not be applied.
+type OpError struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Path   string `json:"path"`
+	From   string `json:"from,omitempty"`
+	Reason string `json:"reason"`
+	// TestFailed is true when a "test" operation did not match the document.
+	TestFailed bool `json:"-"`
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("patch op %d (%s %s): %s", e.Index, e.Op, e.Path, e.Reason)
+}
+
+// DecodePatch decodes a JSON Patch document and checks each operation is well formed.
+func DecodePatch(b []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(b, &ops); err != nil {
+		return nil, errors.New("patch must be a JSON array of operations")
+	}
+	for i, op := range ops {
+		fail := func(reason string) error {
+			return &OpError{Index: i, Op: op.Op, Path: op.Path, From: op.From, Reason: reason}
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fail("missing value")
+			}
+		case "remove":
+		case "move", "copy":
+			if _, err := ParsePointer(op.From); err != nil {
+				return nil, fail("invalid from: " + err.Error())
+			}
+		default:
+			return nil, fail("unknown op")
+		}
+		if _, err := ParsePointer(op.Path); err != nil {
+			return nil, fail("invalid path: " + err.Error())
+		}
+	}
+	return ops, nil
+}
+
+// ApplyPatch applies ops in order to a copy of doc. The patch is atomic: on the
+// first failing operation an *OpError is returned and doc is left untouched.
+func ApplyPatch(doc any, ops []Operation) (any, error) {
+	out := Clone(doc)
+	for i, op := range ops {
+		var err error
+		out, err = applyOp(out, op)
+		if err != nil {
+			var oe *OpError
+			if errors.As(err, &oe) {
+				oe.Index, oe.Op, oe.Path, oe.From = i, op.Op, op.Path, op.From
+				return nil, oe
+			}
+			return nil, &OpError{Index: i, Op: op.Op, Path: op.Path, From: op.From, Reason: err.Error()}
+		}
+	}
+	return out, nil
+}
+
+func applyOp(doc any, op Operation) (any, error) {
+	switch op.Op {
+	case "add":
+		v, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, v)
+	case "remove":
+		out, _, err := remove(doc, op.Path)
+		return out, err
+	case "replace":
+		v, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if op.Path == "" {
+			return v, nil
+		}
+		out, _, err := remove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(out, op.Path, v)
+	case "move":
+		if op.From == op.Path {
+			_, err := Get(doc, op.From)
+			return doc, err
+		}
+		if len(op.Path) > len(op.From) && op.Path[:len(op.From)+1] == op.From+"/" {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		out, v, err := remove(doc, op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		return add(out, op.Path, v)
+	case "copy":
+		v, err := Get(doc, op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		return add(doc, op.Path, Clone(v))
+	case "test":
+		want, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		got, err := Get(doc, op.Path)
+		if err != nil {
+			return nil, &OpError{Reason: err.Error(), TestFailed: true}
+		}
+		if !Equal(got, want) {
+			return nil, &OpError{Reason: "test failed: value does not match", TestFailed: true}
+		}
+		return doc, nil
+	default:
+		return nil, errors.New("unknown op")
+	}
+}
+
+func decodeValue(raw json.RawMessage) (any, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.New("invalid value")
+	}
+	return v, nil
+}
+
+// add inserts v at pointer, creating or replacing object members and
+// inserting into arrays.
+func add(doc any, pointer string, v any) (any, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return v, nil
+	}
+	parent, err := Get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, errors.New("parent path not found")
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = v
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := make([]any, 0, len(node)+1)
+		grown = append(grown, node[:i]...)
+		grown = append(grown, v)
+		grown = append(grown, node[i:]...)
+		return setAt(doc, tokens[:len(tokens)-1], grown)
+	default:
+		return nil, errors.New("parent is not a container")
+	}
+}
+
+// remove deletes the value at pointer and returns it.
+func remove(doc any, pointer string) (any, any, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the document root")
+	}
+	parent, err := Get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, nil, errors.New("path not found")
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, errors.New("path not found")
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []any:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		shrunk := make([]any, 0, len(node)-1)
+		shrunk = append(shrunk, node[:i]...)
+		shrunk = append(shrunk, node[i+1:]...)
+		out, err := setAt(doc, tokens[:len(tokens)-1], shrunk)
+		return out, v, err
+	default:
+		return nil, nil, errors.New("path not found")
+	}
+}
+
+// setAt replaces the value addressed by tokens (slices are reallocated on resize,
+// so their parent must be updated).
+func setAt(doc any, tokens []string, v any) (any, error) {
+	if len(tokens) == 0 {
+		return v, nil
+	}
+	parent, err := Get(doc, pointerOf(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = v
+	case []any:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = v
+	}
+	return doc, nil
+}
+
+func pointerOf(tokens []string) string {
+	p := ""
+	for _, t := range tokens {
+		p = JoinPointer(p, t)
+	}
+	return p
+}
+
+// Equal reports whether two decoded JSON values are equal, treating all
+// numeric types by value.
+func Equal(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch ta := a.(type) {
+	case map[string]any:
+		tb, ok := b.(map[string]any)
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for k, va := range ta {
+			vb, ok := tb[k]
+			if !ok || !Equal(va, vb) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		tb, ok := b.([]any)
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for i := range ta {
+			if !Equal(ta[i], tb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
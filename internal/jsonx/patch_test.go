@@ -0,0 +1,94 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestMergePatch_RFC7396(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		doc := decode(t, tc.doc)
+		got := MergePatch(doc, decode(t, tc.patch))
+		if !Equal(got, decode(t, tc.want)) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", tc.doc, tc.patch, got, tc.want)
+		}
+		if !Equal(doc, decode(t, tc.doc)) {
+			t.Errorf("MergePatch mutated input %s", tc.doc)
+		}
+	}
+}
+
+func TestApplyPatch_RFC6902(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"x"}]`, `{"foo":["bar","x"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`},
+	}
+	for _, tc := range cases {
+		ops, err := DecodePatch([]byte(tc.patch))
+		if err != nil {
+			t.Fatalf("decode %s: %v", tc.patch, err)
+		}
+		got, err := ApplyPatch(decode(t, tc.doc), ops)
+		if err != nil {
+			t.Fatalf("apply %s: %v", tc.patch, err)
+		}
+		if !Equal(got, decode(t, tc.want)) {
+			t.Errorf("ApplyPatch(%s, %s) = %v, want %s", tc.doc, tc.patch, got, tc.want)
+		}
+	}
+}
+
+func TestApplyPatch_ReportsFailingOp(t *testing.T) {
+	doc := decode(t, `{"baz":"qux","foo":"bar"}`)
+	ops, err := DecodePatch([]byte(`[{"op":"replace","path":"/foo","value":1},{"op":"test","path":"/baz","value":"bar"}]`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	_, err = ApplyPatch(doc, ops)
+	var oe *OpError
+	if !errors.As(err, &oe) || oe.Index != 1 || oe.Op != "test" || oe.Path != "/baz" || !oe.TestFailed {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if !Equal(doc, decode(t, `{"baz":"qux","foo":"bar"}`)) {
+		t.Fatalf("failed patch mutated input: %v", doc)
+	}
+
+	ops, _ = DecodePatch([]byte(`[{"op":"remove","path":"/missing"}]`))
+	if _, err := ApplyPatch(doc, ops); !errors.As(err, &oe) || oe.TestFailed || oe.Index != 0 {
+		t.Fatalf("unexpected remove error: %#v", err)
+	}
+
+	if _, err := DecodePatch([]byte(`[{"op":"frobnicate","path":"/a"}]`)); !errors.As(err, &oe) || oe.Reason != "unknown op" {
+		t.Fatalf("unexpected decode error: %#v", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Package jsonx implements operations on decoded JSON documents
+// (map[string]any / []any trees): RFC 6901 pointers, RFC 7396 merge
+// patches, RFC 6902 patches, structural diffs and layered deep merges.
+package jsonx
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ParsePointer splits an RFC 6901 JSON pointer into unescaped reference tokens.
+// The empty pointer "" refers to the whole document and yields no tokens.
+func ParsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, errors.New("pointer must start with '/'")
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, s := range parts {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(s, "~0", ""), "~1", ""), "~") {
+			return nil, errors.New("invalid escape in pointer")
+		}
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+// EscapeToken escapes a single reference token for use in a JSON pointer.
+func EscapeToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// JoinPointer appends a reference token to a JSON pointer.
+func JoinPointer(base, token string) string {
+	return base + "/" + EscapeToken(token)
+}
+
+// Get resolves a JSON pointer against doc.
+func Get(doc any, pointer string) (any, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, tok := range tokens {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[tok]
+			if !ok {
+				return nil, errors.New("path not found")
+			}
+			cur = v
+		case []any:
+			i, err := arrayIndex(tok, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[i]
+		default:
+			return nil, errors.New("path not found")
+		}
+	}
+	return cur, nil
+}
+
+// arrayIndex parses an array reference token. When forInsert is true the
+// index may equal length and "-" addresses the position past the last element.
+func arrayIndex(tok string, length int, forInsert bool) (int, error) {
+	if tok == "-" {
+		if forInsert {
+			return length, nil
+		}
+		return 0, errors.New("index '-' not allowed here")
+	}
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, errors.New("invalid array index")
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, errors.New("invalid array index")
+	}
+	limit := length - 1
+	if forInsert {
+		limit = length
+	}
+	if i > limit {
+		return 0, errors.New("array index out of range")
+	}
+	return i, nil
+}
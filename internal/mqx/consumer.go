@@ -0,0 +1,159 @@
+package mqx
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/samber/lo"
+)
+
+// Handler processes one message body. A returned error hands the message to
+// the subscriber's retry policy, so handlers should only fail on errors that
+// may go away, and record permanent failures themselves.
+type Handler func(ctx context.Context, body []byte) error
+
+// MaxAttempts bounds how often RabbitConsumer handles a failing message before
+// moving it to the dead-letter queue.
+const MaxAttempts = 5
+
+// RetryDelay is the pause before a failed message is retried, multiplied by
+// the number of attempts so far.
+const RetryDelay = time.Second
+
+// attemptHeader counts the failed attempts of a republished message.
+const attemptHeader = "x-mqx-attempts"
+
+// Subscriber delivers messages published with a routing key to a handler.
+type Subscriber interface {
+	Subscribe(routingKey string, h Handler) error
+	Close() error
+}
+
+// RabbitConsumer implements Subscriber with a durable RabbitMQ queue. Messages
+// whose handler fails are retried up to MaxAttempts times, then parked in the
+// durable "<queue>.dead" queue for inspection.
+type RabbitConsumer struct {
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	exchange string
+	queue    string
+}
+
+// NewRabbitConsumer connects to RabbitMQ and declares a durable queue on
+// exchange, along with its dead-letter queue
+func NewRabbitConsumer(url, exchange, queue string) (*RabbitConsumer, error) {
+	exchange = lo.Ternary(exchange != "", exchange, "events")
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+		_ = ch.Close()
+		_ = conn.Close()
+		return nil, err
+	}
+	for _, q := range []string{queue, deadQueue(queue)} {
+		if _, err := ch.QueueDeclare(q, true, false, false, false, nil); err != nil {
+			_ = ch.Close()
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return &RabbitConsumer{conn: conn, ch: ch, exchange: exchange, queue: queue}, nil
+}
+
+// Subscribe binds routingKey to the queue and handles deliveries one at a time
+// until the consumer is closed.
+func (c *RabbitConsumer) Subscribe(routingKey string, h Handler) error {
+	if err := c.ch.QueueBind(c.queue, routingKey, c.exchange, false, nil); err != nil {
+		return err
+	}
+	if err := c.ch.Qos(1, 0, false); err != nil {
+		return err
+	}
+	deliveries, err := c.ch.Consume(c.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for d := range deliveries {
+			if err := h(context.Background(), d.Body); err != nil {
+				c.retry(d)
+				continue
+			}
+			_ = d.Ack(false)
+		}
+	}()
+	return nil
+}
+
+// retry republishes a failed delivery straight to the queue, or to the
+// dead-letter queue once it used up its attempts, and acks the original. If
+// republishing fails the delivery is requeued as is.
+func (c *RabbitConsumer) retry(d amqp.Delivery) {
+	attempts := failedAttempts(d.Headers) + 1
+	target, delay := retryRoute(c.queue, attempts)
+	time.Sleep(delay)
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptHeader] = int32(attempts)
+	err := c.ch.PublishWithContext(context.Background(), "", target, false, false, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		Body:         d.Body,
+		Timestamp:    d.Timestamp,
+		DeliveryMode: amqp.Persistent,
+	})
+	if err != nil {
+		_ = d.Nack(false, true)
+		return
+	}
+	_ = d.Ack(false)
+}
+
+// retryRoute picks the queue a message failed attempts times goes to next,
+// and how long to wait before republishing it.
+func retryRoute(queue string, attempts int) (string, time.Duration) {
+	if attempts >= MaxAttempts {
+		return deadQueue(queue), 0
+	}
+	return queue, time.Duration(attempts) * RetryDelay
+}
+
+// failedAttempts reads the attempt counter of a republished message.
+func failedAttempts(h amqp.Table) int {
+	switch n := h[attemptHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
+	}
+}
+
+// deadQueue names the queue holding messages that failed MaxAttempts times.
+func deadQueue(queue string) string {
+	return queue + ".dead"
+}
+
+// Close closes the RabbitMQ connection and channel
+func (c *RabbitConsumer) Close() error {
+	if c.ch != nil {
+		_ = c.ch.Close()
+	}
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
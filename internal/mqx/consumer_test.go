@@ -0,0 +1,38 @@
+package mqx
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestFailedAttempts(t *testing.T) {
+	cases := []struct {
+		headers amqp.Table
+		want    int
+	}{
+		{nil, 0},
+		{amqp.Table{"other": "x"}, 0},
+		{amqp.Table{attemptHeader: int32(2)}, 2},
+		{amqp.Table{attemptHeader: int64(3)}, 3},
+		{amqp.Table{attemptHeader: "4"}, 0},
+	}
+	for _, tc := range cases {
+		if got := failedAttempts(tc.headers); got != tc.want {
+			t.Fatalf("failedAttempts(%v) = %d, want %d", tc.headers, got, tc.want)
+		}
+	}
+}
+
+func TestRetryRoute(t *testing.T) {
+	for attempts := 1; attempts < MaxAttempts; attempts++ {
+		q, delay := retryRoute("exports", attempts)
+		if q != "exports" || delay != time.Duration(attempts)*RetryDelay {
+			t.Fatalf("attempt %d: %s after %v", attempts, q, delay)
+		}
+	}
+	if q, delay := retryRoute("exports", MaxAttempts); q != "exports.dead" || delay != 0 {
+		t.Fatalf("last attempt: %s after %v", q, delay)
+	}
+}
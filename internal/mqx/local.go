@@ -0,0 +1,66 @@
+package mqx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBusClosed is returned when publishing to a closed LocalBus.
+var ErrBusClosed = errors.New("mqx: bus closed")
+
+// LocalBus is an in-process Publisher and Subscriber, used when RabbitMQ is
+// not configured. Messages are handled on background goroutines, at most
+// workers at a time, and are lost if the process exits.
+type LocalBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	closed   bool
+	sem      chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewLocalBus creates a bus running up to workers handlers concurrently
+func NewLocalBus(workers int) *LocalBus {
+	if workers < 1 {
+		workers = 1
+	}
+	return &LocalBus{handlers: map[string][]Handler{}, sem: make(chan struct{}, workers)}
+}
+
+// Subscribe registers h for messages published with routingKey
+func (b *LocalBus) Subscribe(routingKey string, h Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[routingKey] = append(b.handlers[routingKey], h)
+	return nil
+}
+
+// Publish hands body to every handler of routingKey without waiting for them
+func (b *LocalBus) Publish(_ context.Context, routingKey string, body []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	msg := append([]byte(nil), body...)
+	for _, h := range b.handlers[routingKey] {
+		b.wg.Add(1)
+		go func(h Handler) {
+			defer b.wg.Done()
+			b.sem <- struct{}{}
+			defer func() { <-b.sem }()
+			_ = h(context.Background(), msg)
+		}(h)
+	}
+	return nil
+}
+
+// Close stops accepting messages and waits for running handlers to finish
+func (b *LocalBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.wg.Wait()
+	return nil
+}
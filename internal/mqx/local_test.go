@@ -0,0 +1,69 @@
+package mqx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLocalBus_DeliversToSubscribers(t *testing.T) {
+	bus := NewLocalBus(2)
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	for i := 0; i < 2; i++ {
+		_ = bus.Subscribe("a", func(_ context.Context, body []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, string(body))
+			return nil
+		})
+	}
+	_ = bus.Subscribe("b", func(context.Context, []byte) error {
+		t.Error("handler of another routing key called")
+		return nil
+	})
+
+	body := []byte("hello")
+	if err := bus.Publish(context.Background(), "a", body); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	body[0] = 'j' // the bus keeps its own copy
+	if err := bus.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if len(got) != 2 || got[0] != "hello" || got[1] != "hello" {
+		t.Fatalf("deliveries: %v", got)
+	}
+	if err := bus.Publish(context.Background(), "a", body); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("publish after close: %v", err)
+	}
+}
+
+func TestLocalBus_BoundsWorkers(t *testing.T) {
+	bus := NewLocalBus(2)
+	var running, peak int32
+	_ = bus.Subscribe("a", func(context.Context, []byte) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	for i := 0; i < 8; i++ {
+		_ = bus.Publish(context.Background(), "a", nil)
+	}
+	_ = bus.Close()
+	if peak != 2 {
+		t.Fatalf("peak concurrency %d, want 2", peak)
+	}
+}
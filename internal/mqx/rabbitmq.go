@@ -1,4 +1,5 @@
-// Package mqx provides message queue functionality using RabbitMQ
+// Package mqx provides message queue functionality using RabbitMQ, with an
+// in-process bus for setups without a broker
 package mqx
 
 import (
@@ -0,0 +1,79 @@
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Case is a naming convention for identifiers and file names.
+type Case string
+
+// Supported cases.
+const (
+	Kebab    Case = "kebab"    // color-brand-primary
+	Snake    Case = "snake"    // color_brand_primary
+	Camel    Case = "camel"    // colorBrandPrimary
+	Pascal   Case = "pascal"   // ColorBrandPrimary
+	Constant Case = "constant" // COLOR_BRAND_PRIMARY
+)
+
+// Valid reports whether c is a supported case.
+func (c Case) Valid() bool {
+	switch c {
+	case Kebab, Snake, Camel, Pascal, Constant:
+		return true
+	}
+	return false
+}
+
+// Words splits name parts into lowercase words at non-alphanumerics and
+// lower-to-upper case changes: ["Text", "Heading 1"] -> [text heading 1].
+func Words(parts ...string) []string {
+	var out []string
+	for _, p := range parts {
+		var cur []rune
+		flush := func() {
+			if len(cur) > 0 {
+				out = append(out, strings.ToLower(string(cur)))
+				cur = cur[:0]
+			}
+		}
+		prev := rune(0)
+		for _, r := range p {
+			switch {
+			case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+				flush()
+			case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+				flush()
+				cur = append(cur, r)
+			default:
+				cur = append(cur, r)
+			}
+			prev = r
+		}
+		flush()
+	}
+	return out
+}
+
+// Format joins words in case c; an unknown case joins them kebab style.
+func Format(c Case, ws []string) string {
+	switch c {
+	case Snake:
+		return strings.Join(ws, "_")
+	case Constant:
+		return strings.ToUpper(strings.Join(ws, "_"))
+	case Camel, Pascal:
+		var b strings.Builder
+		for i, w := range ws {
+			if i == 0 && c == Camel {
+				b.WriteString(w)
+				continue
+			}
+			r := []rune(w)
+			b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+		}
+		return b.String()
+	}
+	return strings.Join(ws, "-")
+}
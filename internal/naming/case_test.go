@@ -0,0 +1,35 @@
+package naming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	for in, want := range map[string][]string{
+		"Heading 1":     {"heading", "1"},
+		"brandPrimary":  {"brand", "primary"},
+		"space-0-5":     {"space", "0", "5"},
+		"H1Title":       {"h1", "title"},
+		"  Brand/Blue ": {"brand", "blue"},
+	} {
+		if got := Words(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("Words(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ws := []string{"color", "brand", "primary"}
+	for c, want := range map[Case]string{
+		Kebab:    "color-brand-primary",
+		Snake:    "color_brand_primary",
+		Camel:    "colorBrandPrimary",
+		Pascal:   "ColorBrandPrimary",
+		Constant: "COLOR_BRAND_PRIMARY",
+	} {
+		if got := Format(c, ws); got != want {
+			t.Errorf("Format(%s) = %q, want %q", c, got, want)
+		}
+	}
+}
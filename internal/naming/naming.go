@@ -0,0 +1,276 @@
+// Package naming interprets the export naming rules of a config: a template
+// over node properties, a case, a prefix and suffix, and how colliding file
+// names are resolved. It turns exported Figma nodes into relative file paths.
+package naming
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is an exported Figma node with the properties templates can use.
+type Node struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Page      string            `json:"page,omitempty"`
+	Frame     string            `json:"frame,omitempty"`
+	Component string            `json:"component,omitempty"`
+	Variants  map[string]string `json:"variants,omitempty"`
+	// Scale and Format default to the config's export settings.
+	Scale  float64 `json:"scale,omitempty"`
+	Format string  `json:"format,omitempty"`
+}
+
+// Collision is how a path already taken by another node is resolved.
+type Collision string
+
+const (
+	// CollisionSuffix appends -2, -3, ... to the file name.
+	CollisionSuffix Collision = "suffix"
+	// CollisionError fails the export.
+	CollisionError Collision = "error"
+)
+
+// Formats lists the export formats Figma can render.
+var Formats = []string{"png", "jpg", "svg", "pdf"}
+
+// Scale bounds of Figma image exports.
+const (
+	MinScale = 0.01
+	MaxScale = 4
+)
+
+// DefaultTemplate names a node after its component, with a density suffix.
+const DefaultTemplate = "{component}{scale_suffix}"
+
+// Options is read from the "naming" section of a config's data, with the
+// export format and scale defaults taken from the top level:
+//
+//	{"format": "png", "scale": 2,
+//	 "naming": {"template": "{page}/{component}{scale_suffix}", "case": "kebab",
+//	            "prefix": "ic-", "suffix": "", "collision": "suffix"}}
+//
+// The file extension is always the node's format and is not part of the
+// template.
+type Options struct {
+	Template  string    `json:"template,omitempty"`
+	Case      Case      `json:"case,omitempty"`
+	Prefix    string    `json:"prefix,omitempty"`
+	Suffix    string    `json:"suffix,omitempty"`
+	Collision Collision `json:"collision,omitempty"`
+
+	Format string  `json:"-"`
+	Scale  float64 `json:"-"`
+}
+
+// ConfigKey is the config data key holding Options.
+const ConfigKey = "naming"
+
+// OptionsFromConfig reads Options from resolved config data, filling in
+// defaults for everything left out.
+func OptionsFromConfig(data map[string]any) (Options, error) {
+	var opts Options
+	if raw, ok := data[ConfigKey]; ok && raw != nil {
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return Options{}, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&opts); err != nil {
+			return Options{}, fmt.Errorf("naming: invalid config: %w", err)
+		}
+	}
+	if f, ok := data["format"].(string); ok {
+		opts.Format = f
+	}
+	if s, ok := data["scale"].(float64); ok {
+		opts.Scale = s
+	}
+	opts.defaults()
+	return opts, opts.Validate()
+}
+
+func (o *Options) defaults() {
+	if o.Template == "" {
+		o.Template = DefaultTemplate
+	}
+	if o.Collision == "" {
+		o.Collision = CollisionSuffix
+	}
+	if o.Format == "" {
+		o.Format = "png"
+	}
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+}
+
+// Validate checks the template, case, collision mode and export defaults.
+func (o *Options) Validate() error {
+	if _, err := Parse(o.Template); err != nil {
+		return err
+	}
+	if o.Case != "" && !o.Case.Valid() {
+		return fmt.Errorf("naming: unknown case %q", o.Case)
+	}
+	if o.Collision != CollisionSuffix && o.Collision != CollisionError {
+		return fmt.Errorf("naming: unknown collision mode %q", o.Collision)
+	}
+	if strings.ContainsAny(o.Prefix+o.Suffix, "/\\") {
+		return errors.New("naming: prefix and suffix cannot contain path separators")
+	}
+	if err := checkFormat(o.Format); err != nil {
+		return err
+	}
+	return checkScale(o.Scale)
+}
+
+func checkFormat(f string) error {
+	for _, v := range Formats {
+		if v == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("naming: unknown format %q", f)
+}
+
+func checkScale(s float64) error {
+	if s < MinScale || s > MaxScale {
+		return fmt.Errorf("naming: scale %s out of range", strconv.FormatFloat(s, 'f', -1, 64))
+	}
+	return nil
+}
+
+// ConflictError reports two nodes mapping to the same path. Other is empty
+// when the path was reserved.
+type ConflictError struct {
+	Path   string `json:"path"`
+	NodeID string `json:"node_id"`
+	Other  string `json:"other_node_id,omitempty"`
+}
+
+func (e *ConflictError) Error() string {
+	if e.Other == "" {
+		return fmt.Sprintf("naming: node %s maps to reserved path %s", e.NodeID, e.Path)
+	}
+	return fmt.Sprintf("naming: nodes %s and %s both map to %s", e.Other, e.NodeID, e.Path)
+}
+
+// Result is the path assigned to a node.
+type Result struct {
+	NodeID string `json:"node_id"`
+	Path   string `json:"path"`
+	// Renamed is set when the templated path was taken and a suffix added.
+	Renamed bool `json:"renamed,omitempty"`
+}
+
+// Namer assigns unique paths to nodes. Paths are compared case-insensitively
+// since exports are often unpacked on case-insensitive file systems.
+type Namer struct {
+	opts  Options
+	tmpl  *Template
+	taken map[string]string
+}
+
+// New returns a Namer for opts, which should come from OptionsFromConfig.
+func New(opts Options) (*Namer, error) {
+	opts.defaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	tmpl, _ := Parse(opts.Template)
+	return &Namer{opts: opts, tmpl: tmpl, taken: map[string]string{}}, nil
+}
+
+// Reserve marks path as taken, e.g. by a file the export writes itself.
+func (m *Namer) Reserve(path string) {
+	m.taken[strings.ToLower(path)] = ""
+}
+
+// Name returns the path of n.
+func (m *Namer) Name(n Node) (Result, error) {
+	if n.Format == "" {
+		n.Format = m.opts.Format
+	}
+	if n.Scale == 0 {
+		n.Scale = m.opts.Scale
+	}
+	n.Format = strings.ToLower(n.Format)
+	if err := checkFormat(n.Format); err != nil {
+		return Result{}, err
+	}
+	if err := checkScale(n.Scale); err != nil {
+		return Result{}, err
+	}
+
+	dir, base := split(m.tmpl.Execute(&n, m.opts.Case))
+	if base == "" {
+		base = sanitize(n.ID)
+	}
+	if base == "" {
+		base = "unnamed"
+	}
+	base = sanitize(m.opts.Prefix + base + m.opts.Suffix)
+	res := Result{NodeID: n.ID, Path: dir + base + "." + n.Format}
+	for i := 2; ; i++ {
+		key := strings.ToLower(res.Path)
+		other, ok := m.taken[key]
+		if !ok {
+			m.taken[key] = n.ID
+			return res, nil
+		}
+		if m.opts.Collision == CollisionError {
+			return Result{}, &ConflictError{Path: res.Path, NodeID: n.ID, Other: other}
+		}
+		res.Path = dir + base + "-" + strconv.Itoa(i) + "." + n.Format
+		res.Renamed = true
+	}
+}
+
+// Paths names every node in order.
+func Paths(opts Options, nodes []Node) ([]Result, error) {
+	m, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Result, 0, len(nodes))
+	for _, n := range nodes {
+		r, err := m.Name(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// split turns a rendered template into a directory, empty or ending in "/",
+// and a file name. Segments are sanitized; empty, "." and ".." directories
+// are dropped.
+func split(p string) (dir, base string) {
+	segs := strings.Split(p, "/")
+	var b strings.Builder
+	for _, s := range segs[:len(segs)-1] {
+		if s = sanitize(s); s != "" && s != "." && s != ".." {
+			b.WriteString(s + "/")
+		}
+	}
+	return b.String(), sanitize(segs[len(segs)-1])
+}
+
+// sanitize drops characters that are not portable in file names and trims
+// surrounding spaces and dots, and separators an empty field left at the end.
+func sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`\:*?"<>|`, r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimRight(strings.Trim(s, " ."), "-_ .")
+}
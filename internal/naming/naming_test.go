@@ -0,0 +1,70 @@
+package naming
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(map[string]any{"format": "svg", "scale": 2.0, "naming": map[string]any{"case": "kebab", "prefix": "ic-"}})
+	if err != nil {
+		t.Fatalf("options: %v", err)
+	}
+	want := Options{Template: DefaultTemplate, Case: Kebab, Prefix: "ic-", Collision: CollisionSuffix, Format: "svg", Scale: 2}
+	if !reflect.DeepEqual(opts, want) {
+		t.Fatalf("options = %+v, want %+v", opts, want)
+	}
+	if opts, err = OptionsFromConfig(map[string]any{}); err != nil || opts.Format != "png" || opts.Scale != 1 {
+		t.Fatalf("defaults = %+v, %v", opts, err)
+	}
+
+	for _, data := range []map[string]any{
+		{"naming": map[string]any{"template": "{bogus}"}},
+		{"naming": map[string]any{"case": "title"}},
+		{"naming": map[string]any{"collision": "ignore"}},
+		{"naming": map[string]any{"prefix": "icons/"}},
+		{"naming": map[string]any{"unknown": 1}},
+		{"format": "gif"},
+		{"scale": 8.0},
+	} {
+		if _, err := OptionsFromConfig(data); err == nil {
+			t.Errorf("%v: expected an error", data)
+		}
+	}
+}
+
+func TestPaths(t *testing.T) {
+	opts, _ := OptionsFromConfig(map[string]any{"naming": map[string]any{"template": "{page}/{component|flat}", "case": "kebab", "prefix": "ic-"}})
+	nodes := []Node{
+		{ID: "1", Name: "Arrow", Page: "Icons", Component: "Nav/Arrow"},
+		{ID: "2", Name: "Arrow", Page: "Icons", Component: "nav arrow"},
+		{ID: "3", Name: "Arrow", Page: "Icons", Component: "Nav/Arrow", Format: "SVG", Scale: 2},
+		{ID: "4", Name: "..", Page: "Bad: <chars>"},
+		{ID: "5", Name: "NAV ARROW", Page: "icons"},
+	}
+	got, err := Paths(opts, nodes)
+	if err != nil {
+		t.Fatalf("paths: %v", err)
+	}
+	want := []Result{
+		{NodeID: "1", Path: "icons/ic-nav-arrow.png"},
+		{NodeID: "2", Path: "icons/ic-nav-arrow-2.png", Renamed: true},
+		{NodeID: "3", Path: "icons/ic-nav-arrow.svg"},
+		{NodeID: "4", Path: "bad-chars/ic-4.png"},
+		{NodeID: "5", Path: "icons/ic-nav-arrow-3.png", Renamed: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %+v\nwant %+v", got, want)
+	}
+
+	opts.Collision = CollisionError
+	_, err = Paths(opts, nodes[:2])
+	var ce *ConflictError
+	if !errors.As(err, &ce) || ce.NodeID != "2" || ce.Other != "1" {
+		t.Fatalf("err = %v, want a conflict", err)
+	}
+	if _, err := Paths(opts, []Node{{ID: "1", Name: "x", Scale: 10}}); err == nil {
+		t.Fatal("expected a scale error")
+	}
+}
@@ -0,0 +1,194 @@
+package naming
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Template is a parsed naming template. Text is copied literally and
+// {field|transform|...} is replaced by a node property:
+//
+//	id            node id
+//	name          node name
+//	page          page the node is on
+//	frame         top level frame containing the node
+//	component     component (or component set) name, else the node name
+//	variant       all variant properties, "Size=Large, State=Hover"
+//	variant.Prop  one variant property
+//	scale         export scale, "2"
+//	scale_suffix  "@2x", empty at 1x
+//	format        export format, "png"
+//
+// Transforms apply left to right: kebab, snake, camel, pascal and constant
+// change case, lower and upper change letters only, and flat drops the "/"
+// grouping of a Figma name so it ends up in one path segment. Case is applied
+// to each "/" separated segment. {{ and }} are literal braces.
+type Template struct {
+	src   string
+	parts []part
+}
+
+type part struct {
+	text       string // literal when field is empty
+	field      string
+	transforms []string
+	cased      bool // the default case does not apply
+}
+
+// fields maps the field names to whether the default case applies to them;
+// ids, scales and formats are left alone.
+var fields = map[string]bool{
+	"id": false, "name": true, "page": true, "frame": true, "component": true,
+	"variant": true, "scale": false, "scale_suffix": false, "format": false,
+}
+
+// Parse parses a template.
+func Parse(src string) (*Template, error) {
+	t := &Template{src: src}
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			t.parts = append(t.parts, part{text: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(src); i++ {
+		switch ch := src[i]; {
+		case ch == '{' && strings.HasPrefix(src[i:], "{{"), ch == '}' && strings.HasPrefix(src[i:], "}}"):
+			lit.WriteByte(ch)
+			i++
+		case ch == '}':
+			return nil, fmt.Errorf("naming: unmatched } at %d in %q", i, src)
+		case ch == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("naming: unclosed { at %d in %q", i, src)
+			}
+			p, err := parseField(src[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			t.parts = append(t.parts, p)
+			i += end
+		default:
+			lit.WriteByte(ch)
+		}
+	}
+	flush()
+	return t, nil
+}
+
+func parseField(s string) (part, error) {
+	items := strings.Split(s, "|")
+	p := part{field: strings.TrimSpace(items[0])}
+	named, ok := fields[p.field]
+	if strings.HasPrefix(p.field, "variant.") && len(p.field) > len("variant.") {
+		named, ok = true, true
+	}
+	if !ok {
+		return p, fmt.Errorf("naming: unknown field %q", p.field)
+	}
+	// Fields the default case does not apply to count as already cased
+	p.cased = !named
+	for _, tr := range items[1:] {
+		tr = strings.TrimSpace(tr)
+		switch {
+		case Case(tr).Valid(), tr == "lower", tr == "upper":
+			p.cased = true
+		case tr == "flat":
+		default:
+			return p, fmt.Errorf("naming: unknown transform %q in {%s}", tr, s)
+		}
+		p.transforms = append(p.transforms, tr)
+	}
+	return p, nil
+}
+
+// String returns the template source.
+func (t *Template) String() string { return t.src }
+
+// Execute renders the template for n. def is the case of name fields without
+// a case, lower or upper transform; empty keeps them as they are.
+func (t *Template) Execute(n *Node, def Case) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.field == "" {
+			b.WriteString(p.text)
+			continue
+		}
+		segs := strings.Split(value(n, p.field), "/")
+		trs := p.transforms
+		if !p.cased && def != "" {
+			trs = append(append([]string(nil), trs...), string(def))
+		}
+		for _, tr := range trs {
+			segs = transform(segs, tr)
+		}
+		b.WriteString(strings.Join(segs, "/"))
+	}
+	return b.String()
+}
+
+func transform(segs []string, tr string) []string {
+	switch tr {
+	case "flat":
+		return []string{strings.Join(segs, " ")}
+	case "lower", "upper":
+		out := make([]string, len(segs))
+		for i, s := range segs {
+			if tr == "lower" {
+				out[i] = strings.ToLower(s)
+			} else {
+				out[i] = strings.ToUpper(s)
+			}
+		}
+		return out
+	}
+	out := make([]string, len(segs))
+	for i, s := range segs {
+		out[i] = Format(Case(tr), Words(s))
+	}
+	return out
+}
+
+func value(n *Node, field string) string {
+	switch field {
+	case "id":
+		return n.ID
+	case "name":
+		return n.Name
+	case "page":
+		return n.Page
+	case "frame":
+		return n.Frame
+	case "component":
+		if n.Component != "" {
+			return n.Component
+		}
+		return n.Name
+	case "variant":
+		keys := make([]string, 0, len(n.Variants))
+		for k := range n.Variants {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		props := make([]string, len(keys))
+		for i, k := range keys {
+			props[i] = k + "=" + n.Variants[k]
+		}
+		return strings.Join(props, ", ")
+	case "scale":
+		return strconv.FormatFloat(n.Scale, 'f', -1, 64)
+	case "scale_suffix":
+		if n.Scale == 1 {
+			return ""
+		}
+		return "@" + strconv.FormatFloat(n.Scale, 'f', -1, 64) + "x"
+	case "format":
+		return n.Format
+	}
+	return n.Variants[strings.TrimPrefix(field, "variant.")]
+}
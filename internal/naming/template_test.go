@@ -0,0 +1,48 @@
+package naming
+
+import "testing"
+
+func TestTemplate_Execute(t *testing.T) {
+	n := &Node{
+		ID: "1:2", Name: "Size=Large, State=Hover", Page: "Icons", Frame: "Navigation",
+		Component: "Arrow/Chevron Left", Variants: map[string]string{"State": "Hover", "Size": "Large"},
+		Scale: 2, Format: "png",
+	}
+	for _, tc := range []struct {
+		src  string
+		def  Case
+		want string
+	}{
+		{"{component}", "", "Arrow/Chevron Left"},
+		{"{component}", Kebab, "arrow/chevron-left"},
+		{"{component|flat|snake}", "", "arrow_chevron_left"},
+		{"{component|flat}", Pascal, "ArrowChevronLeft"},
+		{"{page|lower}/{component|kebab}{scale_suffix}", Snake, "icons/arrow/chevron-left@2x"},
+		{"{variant}", "", "Size=Large, State=Hover"},
+		{"{component|flat}-{variant.Size}-{variant.Missing}", Kebab, "arrow-chevron-left-large-"},
+		{"{frame|upper}_{id}@{scale}x.{format}", Camel, "NAVIGATION_1:2@2x.png"},
+		{"{{literal}}", "", "{literal}"},
+	} {
+		tmpl, err := Parse(tc.src)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.src, err)
+		}
+		if got := tmpl.Execute(n, tc.def); got != tc.want {
+			t.Errorf("%q (%s) = %q, want %q", tc.src, tc.def, got, tc.want)
+		}
+	}
+
+	n.Scale = 1
+	tmpl, _ := Parse("{name}{scale_suffix}")
+	if got := tmpl.Execute(&Node{Name: "Logo", Scale: 1}, ""); got != "Logo" {
+		t.Fatalf("1x suffix: %q", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{"{component", "component}", "{nope}", "{variant.}", "{name|title}"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q): expected an error", src)
+		}
+	}
+}
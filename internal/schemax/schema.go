@@ -0,0 +1,473 @@
+// Package schemax validates decoded JSON values against JSON Schema documents.
+//
+// Only the subset of draft 2020-12 needed for config payloads is supported:
+// type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, uniqueItems, minLength, maxLength, pattern, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, allOf, anyOf, oneOf, not and
+// local $ref into $defs (or definitions). Other keywords are ignored.
+package schemax
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"fiber-ent-apollo-pg/internal/jsonx"
+)
+
+// maxDepth bounds $ref recursion during validation.
+const maxDepth = 64
+
+var knownTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root *node
+	defs map[string]*node // keyed by schema pointer ("" is the root)
+}
+
+type node struct {
+	always   *bool // boolean schema
+	types    []string
+	enum     []any
+	hasConst bool
+	constVal any
+
+	properties map[string]*node
+	required   []string
+	additional *node
+
+	items       *node
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum *float64
+	maximum *float64
+	exclMin *float64
+	exclMax *float64
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ref string
+}
+
+// Violation is a single validation failure at an instance location.
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every violation found in an instance.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, location(v.Path)+": "+v.Message)
+	}
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Compile checks a decoded schema document and prepares it for validation.
+func Compile(doc any) (*Schema, error) {
+	s := &Schema{defs: map[string]*node{}}
+	var refs []string
+	root, err := s.compile(doc, "", &refs)
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+	s.defs[""] = root
+	for _, r := range refs {
+		if _, ok := s.defs[strings.TrimPrefix(r, "#")]; !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", r)
+		}
+	}
+	return s, nil
+}
+
+// Validate checks v against the schema and returns a *ValidationError listing
+// every violation, or nil when v is valid.
+func (s *Schema) Validate(v any) error {
+	var out []Violation
+	s.validate(s.root, v, "", 0, &out)
+	if len(out) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: out}
+}
+
+func (s *Schema) compile(v any, ptr string, refs *[]string) (*node, error) {
+	if b, ok := v.(bool); ok {
+		return &node{always: &b}, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", location(ptr))
+	}
+	n := &node{}
+	fail := func(kw, msg string) error {
+		return fmt.Errorf("%s: %s", location(jsonx.JoinPointer(ptr, kw)), msg)
+	}
+
+	for _, kw := range []string{"$defs", "definitions"} {
+		defs, ok := m[kw]
+		if !ok {
+			continue
+		}
+		dm, ok := defs.(map[string]any)
+		if !ok {
+			return nil, fail(kw, "must be an object")
+		}
+		for _, name := range sortedKeys(dm) {
+			p := jsonx.JoinPointer(jsonx.JoinPointer(ptr, kw), name)
+			d, err := s.compile(dm[name], p, refs)
+			if err != nil {
+				return nil, err
+			}
+			s.defs[p] = d
+		}
+	}
+
+	if r, ok := m["$ref"]; ok {
+		rs, ok := r.(string)
+		if !ok || !strings.HasPrefix(rs, "#") {
+			return nil, fail("$ref", "only local references are supported")
+		}
+		n.ref = rs
+		*refs = append(*refs, rs)
+	}
+
+	if t, ok := m["type"]; ok {
+		switch tv := t.(type) {
+		case string:
+			n.types = []string{tv}
+		case []any:
+			for _, e := range tv {
+				es, ok := e.(string)
+				if !ok {
+					return nil, fail("type", "must be a string or an array of strings")
+				}
+				n.types = append(n.types, es)
+			}
+		default:
+			return nil, fail("type", "must be a string or an array of strings")
+		}
+		for _, tn := range n.types {
+			if !knownTypes[tn] {
+				return nil, fail("type", fmt.Sprintf("unknown type %q", tn))
+			}
+		}
+	}
+	if e, ok := m["enum"]; ok {
+		ev, ok := e.([]any)
+		if !ok {
+			return nil, fail("enum", "must be an array")
+		}
+		n.enum = ev
+	}
+	if cv, ok := m["const"]; ok {
+		n.hasConst, n.constVal = true, cv
+	}
+
+	if p, ok := m["properties"]; ok {
+		pm, ok := p.(map[string]any)
+		if !ok {
+			return nil, fail("properties", "must be an object")
+		}
+		n.properties = make(map[string]*node, len(pm))
+		for _, k := range sortedKeys(pm) {
+			sub, err := s.compile(pm[k], jsonx.JoinPointer(jsonx.JoinPointer(ptr, "properties"), k), refs)
+			if err != nil {
+				return nil, err
+			}
+			n.properties[k] = sub
+		}
+	}
+	if r, ok := m["required"]; ok {
+		rv, ok := r.([]any)
+		if !ok {
+			return nil, fail("required", "must be an array of strings")
+		}
+		for _, e := range rv {
+			es, ok := e.(string)
+			if !ok {
+				return nil, fail("required", "must be an array of strings")
+			}
+			n.required = append(n.required, es)
+		}
+	}
+	var err error
+	if a, ok := m["additionalProperties"]; ok {
+		if n.additional, err = s.compile(a, jsonx.JoinPointer(ptr, "additionalProperties"), refs); err != nil {
+			return nil, err
+		}
+	}
+	if it, ok := m["items"]; ok {
+		if n.items, err = s.compile(it, jsonx.JoinPointer(ptr, "items"), refs); err != nil {
+			return nil, err
+		}
+	}
+	if nt, ok := m["not"]; ok {
+		if n.not, err = s.compile(nt, jsonx.JoinPointer(ptr, "not"), refs); err != nil {
+			return nil, err
+		}
+	}
+
+	for kw, dst := range map[string]**int{"minItems": &n.minItems, "maxItems": &n.maxItems, "minLength": &n.minLength, "maxLength": &n.maxLength} {
+		raw, ok := m[kw]
+		if !ok {
+			continue
+		}
+		f, ok := raw.(float64)
+		if !ok || f < 0 || f != math.Trunc(f) {
+			return nil, fail(kw, "must be a non-negative integer")
+		}
+		i := int(f)
+		*dst = &i
+	}
+	for kw, dst := range map[string]**float64{"minimum": &n.minimum, "maximum": &n.maximum, "exclusiveMinimum": &n.exclMin, "exclusiveMaximum": &n.exclMax} {
+		raw, ok := m[kw]
+		if !ok {
+			continue
+		}
+		f, ok := raw.(float64)
+		if !ok {
+			return nil, fail(kw, "must be a number")
+		}
+		*dst = &f
+	}
+	if u, ok := m["uniqueItems"]; ok {
+		b, ok := u.(bool)
+		if !ok {
+			return nil, fail("uniqueItems", "must be a boolean")
+		}
+		n.uniqueItems = b
+	}
+	if p, ok := m["pattern"]; ok {
+		ps, ok := p.(string)
+		if !ok {
+			return nil, fail("pattern", "must be a string")
+		}
+		re, err := regexp.Compile(ps)
+		if err != nil {
+			return nil, fail("pattern", err.Error())
+		}
+		n.pattern = re
+	}
+
+	for kw, dst := range map[string]*[]*node{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf} {
+		raw, ok := m[kw]
+		if !ok {
+			continue
+		}
+		list, ok := raw.([]any)
+		if !ok || len(list) == 0 {
+			return nil, fail(kw, "must be a non-empty array of schemas")
+		}
+		for i, e := range list {
+			sub, err := s.compile(e, fmt.Sprintf("%s/%s/%d", ptr, kw, i), refs)
+			if err != nil {
+				return nil, err
+			}
+			*dst = append(*dst, sub)
+		}
+	}
+	return n, nil
+}
+
+func (s *Schema) validate(n *node, v any, path string, depth int, out *[]Violation) {
+	add := func(p, msg string) { *out = append(*out, Violation{Path: p, Message: msg}) }
+
+	if n.always != nil {
+		if !*n.always {
+			add(path, "no value is allowed here")
+		}
+		return
+	}
+	if n.ref != "" {
+		if depth >= maxDepth {
+			add(path, "schema nesting too deep")
+			return
+		}
+		s.validate(s.defs[strings.TrimPrefix(n.ref, "#")], v, path, depth+1, out)
+	}
+
+	if len(n.types) > 0 && !matchesType(v, n.types) {
+		add(path, fmt.Sprintf("expected %s, got %s", strings.Join(n.types, " or "), typeOf(v)))
+		return
+	}
+	if n.enum != nil {
+		found := false
+		for _, e := range n.enum {
+			if jsonx.Equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add(path, "value is not one of the allowed values")
+		}
+	}
+	if n.hasConst && !jsonx.Equal(v, n.constVal) {
+		add(path, "value does not match the expected constant")
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		for _, r := range n.required {
+			if _, ok := t[r]; !ok {
+				add(jsonx.JoinPointer(path, r), "is required")
+			}
+		}
+		for _, k := range sortedKeys(t) {
+			p := jsonx.JoinPointer(path, k)
+			if sub, ok := n.properties[k]; ok {
+				s.validate(sub, t[k], p, depth, out)
+			} else if n.additional != nil {
+				if n.additional.always != nil && !*n.additional.always {
+					add(p, "is not allowed")
+					continue
+				}
+				s.validate(n.additional, t[k], p, depth, out)
+			}
+		}
+	case []any:
+		if n.minItems != nil && len(t) < *n.minItems {
+			add(path, fmt.Sprintf("must have at least %d items", *n.minItems))
+		}
+		if n.maxItems != nil && len(t) > *n.maxItems {
+			add(path, fmt.Sprintf("must have at most %d items", *n.maxItems))
+		}
+		if n.uniqueItems {
+			for i := 1; i < len(t); i++ {
+				for j := 0; j < i; j++ {
+					if jsonx.Equal(t[i], t[j]) {
+						add(fmt.Sprintf("%s/%d", path, i), fmt.Sprintf("duplicates item %d", j))
+					}
+				}
+			}
+		}
+		if n.items != nil {
+			for i, e := range t {
+				s.validate(n.items, e, fmt.Sprintf("%s/%d", path, i), depth, out)
+			}
+		}
+	case string:
+		l := utf8.RuneCountInString(t)
+		if n.minLength != nil && l < *n.minLength {
+			add(path, fmt.Sprintf("must be at least %d characters", *n.minLength))
+		}
+		if n.maxLength != nil && l > *n.maxLength {
+			add(path, fmt.Sprintf("must be at most %d characters", *n.maxLength))
+		}
+		if n.pattern != nil && !n.pattern.MatchString(t) {
+			add(path, fmt.Sprintf("must match pattern %q", n.pattern.String()))
+		}
+	case float64:
+		if n.minimum != nil && t < *n.minimum {
+			add(path, fmt.Sprintf("must be >= %v", *n.minimum))
+		}
+		if n.maximum != nil && t > *n.maximum {
+			add(path, fmt.Sprintf("must be <= %v", *n.maximum))
+		}
+		if n.exclMin != nil && t <= *n.exclMin {
+			add(path, fmt.Sprintf("must be > %v", *n.exclMin))
+		}
+		if n.exclMax != nil && t >= *n.exclMax {
+			add(path, fmt.Sprintf("must be < %v", *n.exclMax))
+		}
+	}
+
+	for _, sub := range n.allOf {
+		s.validate(sub, v, path, depth, out)
+	}
+	if len(n.anyOf) > 0 && s.countMatches(n.anyOf, v, path, depth) == 0 {
+		add(path, "does not match any schema in anyOf")
+	}
+	if len(n.oneOf) > 0 {
+		if c := s.countMatches(n.oneOf, v, path, depth); c != 1 {
+			add(path, fmt.Sprintf("matches %d schemas in oneOf, want exactly 1", c))
+		}
+	}
+	if n.not != nil && s.countMatches([]*node{n.not}, v, path, depth) == 1 {
+		add(path, "must not match the schema in not")
+	}
+}
+
+func (s *Schema) countMatches(list []*node, v any, path string, depth int) int {
+	c := 0
+	for _, sub := range list {
+		var tmp []Violation
+		s.validate(sub, v, path, depth, &tmp)
+		if len(tmp) == 0 {
+			c++
+		}
+	}
+	return c
+}
+
+func matchesType(v any, types []string) bool {
+	got := typeOf(v)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value; integral numbers report "integer".
+func typeOf(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if t == math.Trunc(t) && !math.IsInf(t, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func location(ptr string) string {
+	if ptr == "" {
+		return "(root)"
+	}
+	return ptr
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
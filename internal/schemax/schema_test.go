@@ -0,0 +1,88 @@
+package schemax
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+const exportSchema = `{
+	"type": "object",
+	"required": ["format", "scale"],
+	"additionalProperties": false,
+	"properties": {
+		"format": {"enum": ["png", "svg", "pdf"]},
+		"scale": {"type": "number", "exclusiveMinimum": 0, "maximum": 4},
+		"prefix": {"type": "string", "pattern": "^[a-z-]*$", "maxLength": 8},
+		"nodes": {"type": "array", "items": {"$ref": "#/$defs/node"}, "uniqueItems": true}
+	},
+	"$defs": {
+		"node": {"type": "string", "minLength": 1}
+	}
+}`
+
+func TestValidate_ReportsEveryPath(t *testing.T) {
+	s, err := Compile(decode(t, exportSchema))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if err := s.Validate(decode(t, `{"format":"png","scale":2,"nodes":["1:2"]}`)); err != nil {
+		t.Fatalf("valid instance rejected: %v", err)
+	}
+
+	err = s.Validate(decode(t, `{"format":"gif","scale":0,"prefix":"Bad_Prefix","nodes":["a","a",""],"extra":1}`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	got := map[string]bool{}
+	for _, v := range ve.Violations {
+		got[v.Path] = true
+	}
+	for _, p := range []string{"/format", "/scale", "/prefix", "/nodes/1", "/nodes/2", "/extra"} {
+		if !got[p] {
+			t.Errorf("missing violation at %s: %+v", p, ve.Violations)
+		}
+	}
+
+	err = s.Validate(decode(t, `{"scale":"2"}`))
+	if !errors.As(err, &ve) || len(ve.Violations) != 2 || ve.Violations[0].Path != "/format" || ve.Violations[1].Path != "/scale" {
+		t.Fatalf("unexpected violations: %v", err)
+	}
+}
+
+func TestValidate_Combinators(t *testing.T) {
+	s, err := Compile(decode(t, `{"oneOf":[{"type":"integer"},{"type":"number","minimum":10}],"not":{"const":3}}`))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	for doc, ok := range map[string]bool{`1`: true, `10.5`: true, `12`: false, `3`: false, `"x"`: false} {
+		if err := s.Validate(decode(t, doc)); (err == nil) != ok {
+			t.Errorf("Validate(%s) = %v, want ok=%v", doc, err, ok)
+		}
+	}
+}
+
+func TestCompile_RejectsBadSchemas(t *testing.T) {
+	for _, doc := range []string{
+		`{"type":"text"}`,
+		`{"pattern":"("}`,
+		`{"minLength":-1}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"$ref":"https://example.com/schema.json"}`,
+		`{"properties":{"a":1}}`,
+	} {
+		if _, err := Compile(decode(t, doc)); err == nil {
+			t.Errorf("Compile(%s) succeeded", doc)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import "sort"
+
+// Renamed is an entry whose name changed, or that was recreated under a
+// new id.
+type Renamed struct {
+	Entry
+	PreviousName string `json:"previous_name"`
+	// PreviousID is set when the entry was matched by content after being
+	// deleted and recreated under a new id.
+	PreviousID string `json:"previous_id,omitempty"`
+}
+
+// Diff lists the differences between two summaries. An entry that was
+// renamed and changed is listed in both Renamed and Changed.
+type Diff struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Added   []Entry   `json:"added"`
+	Removed []Entry   `json:"removed"`
+	Renamed []Renamed `json:"renamed"`
+	Changed []Entry   `json:"changed"`
+}
+
+// Empty reports whether nothing changed.
+func (d *Diff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Renamed)+len(d.Changed) == 0
+}
+
+type ident struct {
+	kind Kind
+	id   string
+}
+
+// Compare diffs from against to. Entries are matched by kind and id; an
+// entry removed and one added with the same kind and hash, when that pairing
+// is unambiguous, count as a rename.
+func Compare(from, to *Summary) *Diff {
+	d := &Diff{From: from.Version, To: to.Version, Added: []Entry{}, Removed: []Entry{}, Renamed: []Renamed{}, Changed: []Entry{}}
+	old := make(map[ident]Entry, len(from.Entries))
+	for _, e := range from.Entries {
+		old[ident{e.Kind, e.ID}] = e
+	}
+	seen := map[ident]bool{}
+	for _, e := range to.Entries {
+		k := ident{e.Kind, e.ID}
+		prev, ok := old[k]
+		if !ok {
+			d.Added = append(d.Added, e)
+			continue
+		}
+		seen[k] = true
+		if prev.Name != e.Name {
+			d.Renamed = append(d.Renamed, Renamed{Entry: e, PreviousName: prev.Name})
+		}
+		if prev.Hash != e.Hash {
+			d.Changed = append(d.Changed, e)
+		}
+	}
+	for _, e := range from.Entries {
+		if !seen[ident{e.Kind, e.ID}] {
+			d.Removed = append(d.Removed, e)
+		}
+	}
+	d.matchMoves()
+	sort.SliceStable(d.Renamed, func(i, j int) bool {
+		a, b := d.Renamed[i], d.Renamed[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	return d
+}
+
+// matchMoves pairs removed and added entries of the same kind and hash that
+// match one to one, as happens when a component is cut and pasted.
+func (d *Diff) matchMoves() {
+	type content struct {
+		kind Kind
+		hash string
+	}
+	removed := map[content][]int{}
+	for i, e := range d.Removed {
+		removed[content{e.Kind, e.Hash}] = append(removed[content{e.Kind, e.Hash}], i)
+	}
+	added := map[content][]int{}
+	for i, e := range d.Added {
+		added[content{e.Kind, e.Hash}] = append(added[content{e.Kind, e.Hash}], i)
+	}
+	dropR, dropA := map[int]bool{}, map[int]bool{}
+	for c, ai := range added {
+		ri := removed[c]
+		if len(ai) != 1 || len(ri) != 1 {
+			continue
+		}
+		a, r := d.Added[ai[0]], d.Removed[ri[0]]
+		dropA[ai[0]], dropR[ri[0]] = true, true
+		d.Renamed = append(d.Renamed, Renamed{Entry: a, PreviousName: r.Name, PreviousID: r.ID})
+	}
+	d.Added = filter(d.Added, dropA)
+	d.Removed = filter(d.Removed, dropR)
+}
+
+func filter(es []Entry, drop map[int]bool) []Entry {
+	out := make([]Entry, 0, len(es))
+	for i, e := range es {
+		if !drop[i] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
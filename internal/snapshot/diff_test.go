@@ -0,0 +1,62 @@
+package snapshot
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	from := &Summary{Version: "v1", Entries: []Entry{
+		{ID: "1:1", Kind: Component, Name: "Button", Hash: "a"},
+		{ID: "1:2", Kind: Component, Name: "Card", Hash: "b"},
+		{ID: "1:3", Kind: Component, Name: "Chip", Hash: "c"},
+		{ID: "S:1", Kind: Style, Name: "Brand", Hash: "d"},
+	}}
+	to := &Summary{Version: "v2", Entries: []Entry{
+		{ID: "1:1", Kind: Component, Name: "Button/Primary", Hash: "a"},
+		{ID: "1:2", Kind: Component, Name: "Card", Hash: "b2"},
+		{ID: "1:4", Kind: Component, Name: "Badge", Hash: "e"},
+		{ID: "S:1", Kind: Style, Name: "Brand/Primary", Hash: "d2"},
+	}}
+	d := Compare(from, to)
+	if d.From != "v1" || d.To != "v2" || d.Empty() {
+		t.Fatalf("diff: %+v", d)
+	}
+	if len(d.Added) != 1 || d.Added[0].ID != "1:4" {
+		t.Errorf("added: %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ID != "1:3" {
+		t.Errorf("removed: %+v", d.Removed)
+	}
+	if len(d.Renamed) != 2 || d.Renamed[0].ID != "1:1" || d.Renamed[0].PreviousName != "Button" || d.Renamed[1].ID != "S:1" {
+		t.Errorf("renamed: %+v", d.Renamed)
+	}
+	if len(d.Changed) != 2 || d.Changed[0].ID != "1:2" || d.Changed[1].ID != "S:1" {
+		t.Errorf("changed: %+v", d.Changed)
+	}
+}
+
+func TestCompareMoves(t *testing.T) {
+	from := &Summary{Entries: []Entry{
+		{ID: "1:1", Kind: Component, Name: "Icon/Add", Hash: "a"},
+		{ID: "1:2", Kind: Component, Name: "Icon/Dot", Hash: "same"},
+		{ID: "1:3", Kind: Component, Name: "Icon/Pin", Hash: "same"},
+	}}
+	to := &Summary{Entries: []Entry{
+		{ID: "9:1", Kind: Component, Name: "Icons/Add", Hash: "a"},
+		{ID: "9:2", Kind: Component, Name: "Icons/Dot", Hash: "same"},
+		{ID: "9:3", Kind: Component, Name: "Icons/Pin", Hash: "same"},
+	}}
+	d := Compare(from, to)
+	if len(d.Renamed) != 1 || d.Renamed[0].ID != "9:1" || d.Renamed[0].PreviousID != "1:1" || d.Renamed[0].PreviousName != "Icon/Add" {
+		t.Errorf("renamed: %+v", d.Renamed)
+	}
+	// Ambiguous pairings stay added and removed
+	if len(d.Added) != 2 || len(d.Removed) != 2 {
+		t.Errorf("added %+v removed %+v", d.Added, d.Removed)
+	}
+}
+
+func TestCompareEqual(t *testing.T) {
+	s := &Summary{Version: "v1", Entries: []Entry{{ID: "1:1", Kind: Component, Name: "Button", Hash: "a"}}}
+	if d := Compare(s, s); !d.Empty() {
+		t.Errorf("diff: %+v", d)
+	}
+}
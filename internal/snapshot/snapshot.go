@@ -0,0 +1,219 @@
+// Package snapshot summarizes a Figma file version as the components and
+// styles it defines, each with a hash of its visual properties, and diffs two
+// summaries into added, removed, renamed and changed entries. Summaries are
+// small enough to store per version, so later diffs need no Figma calls.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+// Kind is the kind of a summary entry.
+type Kind string
+
+// Entry kinds.
+const (
+	Component    Kind = "component"
+	ComponentSet Kind = "component_set"
+	Style        Kind = "style"
+)
+
+// Entry is a component, component set or style of a file version.
+type Entry struct {
+	// ID is the node id of a component or the id of a style; both survive
+	// renames and moves.
+	ID   string `json:"id"`
+	Kind Kind   `json:"kind"`
+	// Key is the library key of a published component or style.
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name"`
+	// Page is the page a component is on.
+	Page string `json:"page,omitempty"`
+	// StyleType is FILL, TEXT, EFFECT or GRID for styles.
+	StyleType string `json:"style_type,omitempty"`
+	// Hash covers the visual properties: a component's subtree without
+	// names, ids and canvas position, or the value of a style as used in
+	// the document. Styles not used anywhere hash their type only.
+	Hash string `json:"hash"`
+}
+
+// Summary is the summary of one file version, entries sorted by kind and id.
+type Summary struct {
+	Version string  `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// style is an entry of a file's styles map.
+type style struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	StyleType string `json:"styleType"`
+}
+
+// component is an entry of a file's components map.
+type component struct {
+	Key string `json:"key"`
+}
+
+// Build summarizes a file fetched with its full document.
+func Build(file *figmax.File) (*Summary, error) {
+	s := &Summary{Version: file.Version, Entries: []Entry{}}
+	var doc map[string]any
+	if len(file.Document) > 0 {
+		if err := json.Unmarshal(file.Document, &doc); err != nil {
+			return nil, fmt.Errorf("snapshot: decode document: %w", err)
+		}
+	}
+	keys := map[string]string{}
+	for id, raw := range file.Components {
+		var c component
+		if json.Unmarshal(raw, &c) == nil {
+			keys[id] = c.Key
+		}
+	}
+	styles := map[string]style{}
+	for id, raw := range file.Styles {
+		var st style
+		if err := json.Unmarshal(raw, &st); err != nil {
+			return nil, fmt.Errorf("snapshot: decode style %s: %w", id, err)
+		}
+		styles[id] = st
+	}
+	// first use of each style, as "styleType value" hashed below
+	used := map[string]string{}
+
+	var walk func(n map[string]any, page string)
+	walk = func(n map[string]any, page string) {
+		typ, _ := n["type"].(string)
+		id, _ := n["id"].(string)
+		name, _ := n["name"].(string)
+		if typ == "CANVAS" {
+			page = name
+		}
+		switch typ {
+		case "COMPONENT":
+			s.Entries = append(s.Entries, Entry{ID: id, Kind: Component, Key: keys[id], Name: name, Page: page, Hash: hashNode(n)})
+		case "COMPONENT_SET":
+			s.Entries = append(s.Entries, Entry{ID: id, Kind: ComponentSet, Key: keys[id], Name: name, Page: page, Hash: hashNode(n)})
+		}
+		if refs, ok := n["styles"].(map[string]any); ok {
+			props := make([]string, 0, len(refs))
+			for prop := range refs {
+				props = append(props, prop)
+			}
+			sort.Strings(props)
+			for _, prop := range props {
+				sid, _ := refs[prop].(string)
+				if _, ok := styles[sid]; ok && used[sid] == "" {
+					if v, ok := styleValue(n, prop); ok {
+						used[sid] = hash(v)
+					}
+				}
+			}
+		}
+		children, _ := n["children"].([]any)
+		for _, c := range children {
+			if m, ok := c.(map[string]any); ok {
+				walk(m, page)
+			}
+		}
+	}
+	if doc != nil {
+		walk(doc, "")
+	}
+
+	for id, st := range styles {
+		h := used[id]
+		if h == "" {
+			h = hash(map[string]any{"styleType": st.StyleType})
+		}
+		s.Entries = append(s.Entries, Entry{ID: id, Kind: Style, Key: st.Key, Name: st.Name, StyleType: st.StyleType, Hash: h})
+	}
+	sort.Slice(s.Entries, func(i, j int) bool {
+		a, b := s.Entries[i], s.Entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	return s, nil
+}
+
+// styleValue returns the property of n a style applies to.
+func styleValue(n map[string]any, prop string) (any, bool) {
+	field := map[string]string{"fill": "fills", "fills": "fills", "stroke": "strokes", "strokes": "strokes",
+		"text": "style", "effect": "effects", "effects": "effects", "grid": "layoutGrids"}[prop]
+	if field == "" {
+		return nil, false
+	}
+	v, ok := n[field]
+	return map[string]any{"prop": field, "value": v}, ok
+}
+
+// ignored lists node keys that do not change how a node looks.
+var ignored = map[string]bool{
+	"id": true, "name": true, "key": true, "description": true, "descriptionMarkdown": true,
+	"documentationLinks": true, "pluginData": true, "sharedPluginData": true,
+	"interactions": true, "reactions": true, "transitionNodeID": true, "transitionDuration": true,
+	"transitionEasing": true, "prototypeStartNodeID": true, "flowStartingPoints": true,
+}
+
+// hashNode hashes the visual properties of a node subtree. Bounding boxes
+// are made relative to the node, so moving it on the canvas is no change.
+func hashNode(n map[string]any) string {
+	var ox, oy float64
+	if bb, ok := n["absoluteBoundingBox"].(map[string]any); ok {
+		ox, _ = bb["x"].(float64)
+		oy, _ = bb["y"].(float64)
+	}
+	var strip func(v any) any
+	strip = func(v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			out := make(map[string]any, len(t))
+			for k, val := range t {
+				if ignored[k] {
+					continue
+				}
+				if box, ok := val.(map[string]any); ok && (k == "absoluteBoundingBox" || k == "absoluteRenderBounds") {
+					rel := make(map[string]any, len(box))
+					for bk, bv := range box {
+						rel[bk] = bv
+					}
+					if x, ok := box["x"].(float64); ok {
+						rel["x"] = x - ox
+					}
+					if y, ok := box["y"].(float64); ok {
+						rel["y"] = y - oy
+					}
+					out[k] = rel
+					continue
+				}
+				out[k] = strip(val)
+			}
+			return out
+		case []any:
+			out := make([]any, len(t))
+			for i, val := range t {
+				out[i] = strip(val)
+			}
+			return out
+		}
+		return v
+	}
+	return hash(strip(n))
+}
+
+// hash returns the first 128 bits of the SHA-256 of the JSON encoding of v,
+// whose map keys encoding/json sorts, in hex.
+func hash(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16])
+}
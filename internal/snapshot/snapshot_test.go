@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+func testFile(t *testing.T, version string, doc map[string]any, styles map[string]any) *figmax.File {
+	t.Helper()
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &figmax.File{Name: "Design", Version: version, Document: raw, Styles: map[string]json.RawMessage{}}
+	for id, s := range styles {
+		b, _ := json.Marshal(s)
+		f.Styles[id] = b
+	}
+	return f
+}
+
+func button(id, name string, x float64, color float64) map[string]any {
+	return map[string]any{
+		"id": id, "name": name, "type": "COMPONENT",
+		"absoluteBoundingBox": map[string]any{"x": x, "y": 0, "width": 80, "height": 32},
+		"fills":               []any{map[string]any{"type": "SOLID", "color": map[string]any{"r": color, "g": 0, "b": 0, "a": 1}}},
+		"styles":              map[string]any{"fill": "S:brand"},
+		"children": []any{map[string]any{
+			"id": id + ":label", "name": "Label", "type": "TEXT", "characters": "OK",
+			"absoluteBoundingBox": map[string]any{"x": x + 8, "y": 8, "width": 20, "height": 16},
+		}},
+	}
+}
+
+func doc(pages ...map[string]any) map[string]any {
+	children := make([]any, len(pages))
+	for i, p := range pages {
+		children[i] = p
+	}
+	return map[string]any{"id": "0:0", "type": "DOCUMENT", "children": children}
+}
+
+func page(name string, nodes ...map[string]any) map[string]any {
+	children := make([]any, len(nodes))
+	for i, n := range nodes {
+		children[i] = n
+	}
+	return map[string]any{"id": "0:" + name, "name": name, "type": "CANVAS", "children": children}
+}
+
+var brand = map[string]any{"S:brand": map[string]any{"key": "k1", "name": "Brand/Primary", "styleType": "FILL"}}
+
+func TestBuild(t *testing.T) {
+	set := map[string]any{"id": "2:0", "name": "Toggle", "type": "COMPONENT_SET", "children": []any{
+		map[string]any{"id": "2:1", "name": "State=On", "type": "COMPONENT"},
+	}}
+	styles := map[string]any{
+		"S:brand":  brand["S:brand"],
+		"S:unused": map[string]any{"key": "k2", "name": "Shadow/Low", "styleType": "EFFECT"},
+	}
+	s, err := Build(testFile(t, "v1", doc(page("Icons", button("1:1", "Button", 0, 1), set)), styles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != "v1" || len(s.Entries) != 5 {
+		t.Fatalf("summary: %+v", s)
+	}
+	want := []struct {
+		kind Kind
+		id   string
+	}{{Component, "1:1"}, {Component, "2:1"}, {ComponentSet, "2:0"}, {Style, "S:brand"}, {Style, "S:unused"}}
+	for i, w := range want {
+		if e := s.Entries[i]; e.Kind != w.kind || e.ID != w.id || e.Hash == "" {
+			t.Errorf("entry %d: %+v, want %s %s", i, e, w.kind, w.id)
+		}
+	}
+	if e := s.Entries[0]; e.Page != "Icons" || e.Name != "Button" {
+		t.Errorf("component: %+v", e)
+	}
+	if e := s.Entries[3]; e.Key != "k1" || e.StyleType != "FILL" || e.Name != "Brand/Primary" {
+		t.Errorf("style: %+v", e)
+	}
+}
+
+func TestBuildHash(t *testing.T) {
+	build := func(n map[string]any) Entry {
+		t.Helper()
+		s, err := Build(testFile(t, "v", doc(page("P", n)), brand))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Entries[0]
+	}
+	base := build(button("1:1", "Button", 0, 1))
+	if got := build(button("1:1", "Button/Primary", 500, 1)); got.Hash != base.Hash {
+		t.Error("renaming or moving changed the hash")
+	}
+	if got := build(button("1:1", "Button", 0, 0.5)); got.Hash == base.Hash {
+		t.Error("a fill change kept the hash")
+	}
+}
+
+func TestBuildStyleHash(t *testing.T) {
+	style := func(color float64) string {
+		t.Helper()
+		s, err := Build(testFile(t, "v", doc(page("P", button("1:1", "Button", 0, color))), brand))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Entries[1].Hash
+	}
+	if style(1) == style(0.5) {
+		t.Error("a style value change kept the hash")
+	}
+}
@@ -0,0 +1,81 @@
+package svgx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/naming"
+)
+
+// docNode is the part of a Figma document node needed to find components.
+type docNode struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Children []docNode `json:"children,omitempty"`
+}
+
+// Components lists the components of a file selected by opts, in document
+// order. Each variant of a component set is an icon of its own, named after
+// the set with its variant properties.
+func Components(file *figmax.File, opts Options) ([]naming.Node, error) {
+	if len(file.Document) == 0 {
+		return nil, nil
+	}
+	var doc docNode
+	if err := json.Unmarshal(file.Document, &doc); err != nil {
+		return nil, fmt.Errorf("svgx: decode document: %w", err)
+	}
+	var out []naming.Node
+	for _, page := range doc.Children {
+		if page.Type != "CANVAS" || !opts.matchPage(page.Name) {
+			continue
+		}
+		for _, top := range page.Children {
+			var walk func(n *docNode)
+			walk = func(n *docNode) {
+				frame := top.Name
+				if n.ID == top.ID {
+					frame = ""
+				}
+				switch n.Type {
+				case "COMPONENT":
+					if opts.matchComponent(n.Name) {
+						out = append(out, naming.Node{ID: n.ID, Name: n.Name, Page: page.Name, Frame: frame, Component: n.Name})
+					}
+					return
+				case "COMPONENT_SET":
+					if !opts.matchComponent(n.Name) {
+						return
+					}
+					for _, v := range n.Children {
+						if v.Type == "COMPONENT" {
+							out = append(out, naming.Node{ID: v.ID, Name: v.Name, Page: page.Name, Frame: frame, Component: n.Name, Variants: variants(v.Name)})
+						}
+					}
+					return
+				case "INSTANCE":
+					return
+				}
+				for i := range n.Children {
+					walk(&n.Children[i])
+				}
+			}
+			walk(&top)
+		}
+	}
+	return out, nil
+}
+
+// variants parses a variant name, "Size=Large, State=Hover".
+func variants(name string) map[string]string {
+	out := map[string]string{}
+	for _, p := range strings.Split(name, ",") {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
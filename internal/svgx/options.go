@@ -0,0 +1,172 @@
+package svgx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Pass is a cleanup pass.
+type Pass string
+
+// Cleanup passes, in the order they run.
+const (
+	StripMetadataPass  Pass = "strip-metadata"
+	CollapseGroupsPass Pass = "collapse-groups"
+	CurrentColorPass   Pass = "current-color"
+	RoundPass          Pass = "round"
+)
+
+// Passes lists all passes in run order.
+var Passes = []Pass{StripMetadataPass, CollapseGroupsPass, CurrentColorPass, RoundPass}
+
+// DefaultPasses run when a config does not list passes. Mapping fills to
+// currentColor changes how icons look, so it is opt-in.
+var DefaultPasses = []Pass{StripMetadataPass, CollapseGroupsPass, RoundPass}
+
+// DefaultPrecision is the number of decimals kept by the round pass.
+const DefaultPrecision = 3
+
+// Options is read from the "svg" section of a config's data:
+//
+//	{"svg": {"pages": ["Icons"], "components": ["Nav", "Action/*"],
+//	         "passes": ["strip-metadata", "collapse-groups", "current-color", "round"],
+//	         "precision": 2, "sprite": true, "manifest": true}}
+//
+// Components are exported as icons; file names follow the naming section.
+type Options struct {
+	// Pages limits icons to components on these pages; empty means all.
+	Pages []string `json:"pages,omitempty"`
+	// Components filters components and component sets by name. A pattern
+	// selects a name it equals, names below it as a group and names it
+	// matches as a path.Match glob.
+	Components []string `json:"components,omitempty"`
+	// Passes selects the cleanup passes; an empty list disables cleanup and
+	// leaving it out runs DefaultPasses. Passes always run in Passes order.
+	Passes    []Pass `json:"passes"`
+	Precision *int   `json:"precision,omitempty"`
+	// Sprite adds sprite.svg with a <symbol> per icon.
+	Sprite bool `json:"sprite,omitempty"`
+	// Manifest adds manifest.json describing every icon.
+	Manifest bool `json:"manifest,omitempty"`
+}
+
+// ConfigKey is the config data key holding Options.
+const ConfigKey = "svg"
+
+// OptionsFromConfig reads Options from resolved config data. ok is false when
+// the config has no svg section, meaning no icons are exported.
+func OptionsFromConfig(data map[string]any) (opts Options, ok bool, err error) {
+	raw, ok := data[ConfigKey]
+	if !ok || raw == nil {
+		return Options{}, false, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return Options{}, false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		return Options{}, false, fmt.Errorf("svgx: invalid config: %w", err)
+	}
+	return opts, true, opts.Validate()
+}
+
+// Validate checks passes, precision and patterns.
+func (o *Options) Validate() error {
+	for _, p := range o.Passes {
+		if !validPass(p) {
+			return fmt.Errorf("svgx: unknown pass %q", p)
+		}
+	}
+	if o.Precision != nil && (*o.Precision < 0 || *o.Precision > 8) {
+		return fmt.Errorf("svgx: precision %d out of range 0-8", *o.Precision)
+	}
+	for _, p := range o.Components {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("svgx: invalid component pattern %q", p)
+		}
+	}
+	return nil
+}
+
+func validPass(p Pass) bool {
+	for _, v := range Passes {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) runs(p Pass) bool {
+	list := o.Passes
+	if list == nil {
+		list = DefaultPasses
+	}
+	for _, v := range list {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) precision() int {
+	if o.Precision == nil {
+		return DefaultPrecision
+	}
+	return *o.Precision
+}
+
+func (o *Options) matchPage(name string) bool {
+	if len(o.Pages) == 0 {
+		return true
+	}
+	for _, p := range o.Pages {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) matchComponent(name string) bool {
+	if len(o.Components) == 0 {
+		return true
+	}
+	for _, p := range o.Components {
+		p = strings.Trim(p, "/")
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Clean parses an SVG and runs the selected passes on it.
+func Clean(data []byte, opts Options) (*Node, error) {
+	root, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if opts.runs(StripMetadataPass) {
+		StripMetadata(root)
+	}
+	if opts.runs(CollapseGroupsPass) {
+		CollapseGroups(root)
+	}
+	if opts.runs(CurrentColorPass) {
+		CurrentColor(root)
+	}
+	if opts.runs(RoundPass) {
+		Round(root, opts.precision())
+	}
+	return root, nil
+}
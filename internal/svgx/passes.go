@@ -0,0 +1,290 @@
+package svgx
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// keepPrefixes are the namespace prefixes SVG renderers understand; every
+// other prefix belongs to an editor (sketch, inkscape, sodipodi, figma, ...).
+var keepPrefixes = map[string]bool{"xlink": true, "xml": true}
+
+// StripMetadata removes <metadata>, comments, elements and attributes in
+// editor namespaces and ids nothing refers to.
+func StripMetadata(root *Node) {
+	var strip func(n *Node)
+	strip = func(n *Node) {
+		out := n.Children[:0]
+		for _, c := range n.Children {
+			if c.Comment || c.Name == "metadata" || editorName(c.Name) {
+				continue
+			}
+			strip(c)
+			out = append(out, c)
+		}
+		n.Children = out
+		attrs := n.Attrs[:0]
+		for _, a := range n.Attrs {
+			if !editorName(a.Name) && !(strings.HasPrefix(a.Name, "xmlns:") && !keepPrefixes[strings.TrimPrefix(a.Name, "xmlns:")]) {
+				attrs = append(attrs, a)
+			}
+		}
+		n.Attrs = attrs
+	}
+	strip(root)
+	root.DelAttr("version")
+
+	refs := references(root)
+	root.walk(func(n *Node) {
+		if id, ok := n.Attr("id"); ok && !refs[id] {
+			n.DelAttr("id")
+		}
+	})
+	if !usesPrefix(root, "xlink") {
+		root.DelAttr("xmlns:xlink")
+	}
+}
+
+func editorName(name string) bool {
+	prefix, _, ok := strings.Cut(name, ":")
+	return ok && prefix != "xmlns" && !keepPrefixes[prefix]
+}
+
+var (
+	urlRef     = regexp.MustCompile(`url\(\s*['"]?#([^'")\s]+)`)
+	idSelector = regexp.MustCompile(`#([A-Za-z_][\w-]*)`)
+)
+
+// references collects the ids used by url(#id) values, href="#id" links and
+// style sheets.
+func references(root *Node) map[string]bool {
+	refs := map[string]bool{}
+	add := func(s string) {
+		for _, m := range urlRef.FindAllStringSubmatch(s, -1) {
+			refs[m[1]] = true
+		}
+	}
+	root.walk(func(n *Node) {
+		for _, a := range n.Attrs {
+			if (a.Name == "href" || a.Name == "xlink:href") && strings.HasPrefix(a.Value, "#") {
+				refs[a.Value[1:]] = true
+			}
+			add(a.Value)
+		}
+		if n.Name == "style" {
+			for _, c := range n.Children {
+				add(c.Text)
+				// #id selectors keep their target too
+				for _, m := range idSelector.FindAllStringSubmatch(c.Text, -1) {
+					refs[m[1]] = true
+				}
+			}
+		}
+	})
+	return refs
+}
+
+func usesPrefix(root *Node, prefix string) bool {
+	found := false
+	root.walk(func(n *Node) {
+		if strings.HasPrefix(n.Name, prefix+":") {
+			found = true
+		}
+		for _, a := range n.Attrs {
+			if strings.HasPrefix(a.Name, prefix+":") {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// inherited lists presentation attributes a group passes on to its children.
+var inherited = map[string]bool{
+	"fill": true, "fill-rule": true, "fill-opacity": true, "clip-rule": true, "color": true,
+	"stroke": true, "stroke-width": true, "stroke-linecap": true, "stroke-linejoin": true,
+	"stroke-miterlimit": true, "stroke-dasharray": true, "stroke-dashoffset": true, "stroke-opacity": true,
+}
+
+// CollapseGroups removes empty groups, unwraps groups without attributes and
+// merges a group with a single element child into that child when the
+// group's attributes are inherited ones the child does not set, or a
+// transform the child does not have.
+func CollapseGroups(root *Node) {
+	var collapse func(n *Node)
+	collapse = func(n *Node) {
+		var out []*Node
+		for _, c := range n.Children {
+			if c.Name == "" {
+				out = append(out, c)
+				continue
+			}
+			collapse(c)
+			if c.Name != "g" {
+				out = append(out, c)
+				continue
+			}
+			switch {
+			case len(c.Children) == 0:
+				// drawn nothing
+			case len(c.Attrs) == 0:
+				out = append(out, c.Children...)
+			case len(c.Children) == 1 && mergeable(c, c.Children[0]):
+				child := c.Children[0]
+				child.Attrs = append(child.Attrs, c.Attrs...)
+				out = append(out, child)
+			default:
+				out = append(out, c)
+			}
+		}
+		n.Children = out
+	}
+	collapse(root)
+}
+
+func mergeable(g, child *Node) bool {
+	if child.Name == "" {
+		return false
+	}
+	for _, a := range g.Attrs {
+		if !inherited[a.Name] && a.Name != "transform" {
+			return false
+		}
+		if _, ok := child.Attr(a.Name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrentColor replaces solid fill colors with currentColor, so icons follow
+// the surrounding text color. none and paint server references are kept.
+func CurrentColor(root *Node) {
+	root.walk(func(n *Node) {
+		if v, ok := n.Attr("fill"); ok && solidPaint(v) {
+			n.SetAttr("fill", "currentColor")
+		}
+		if v, ok := n.Attr("style"); ok {
+			decls := strings.Split(v, ";")
+			for i, d := range decls {
+				if prop, val, ok := strings.Cut(d, ":"); ok && strings.TrimSpace(prop) == "fill" && solidPaint(val) {
+					decls[i] = "fill:currentColor"
+				}
+			}
+			n.SetAttr("style", strings.Join(decls, ";"))
+		}
+	})
+}
+
+func solidPaint(v string) bool {
+	v = strings.TrimSpace(v)
+	switch strings.ToLower(v) {
+	case "", "none", "transparent", "currentcolor", "inherit":
+		return false
+	}
+	return !strings.HasPrefix(v, "url(")
+}
+
+// geometry lists attributes whose numbers are rounded.
+var geometry = map[string]bool{
+	"x": true, "y": true, "width": true, "height": true, "cx": true, "cy": true, "r": true,
+	"rx": true, "ry": true, "x1": true, "y1": true, "x2": true, "y2": true, "fx": true, "fy": true,
+	"dx": true, "dy": true, "d": true, "points": true, "viewBox": true, "transform": true,
+	"gradientTransform": true, "patternTransform": true, "offset": true, "opacity": true,
+	"fill-opacity": true, "stroke-opacity": true, "stop-opacity": true, "stroke-width": true,
+	"stroke-dasharray": true, "stroke-dashoffset": true, "stroke-miterlimit": true, "font-size": true,
+}
+
+var number = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// Round rounds the numbers of geometry attributes to precision decimals.
+func Round(root *Node, precision int) {
+	root.walk(func(n *Node) {
+		for i, a := range n.Attrs {
+			switch {
+			case a.Name == "d":
+				n.Attrs[i].Value = roundPath(a.Value, precision)
+			case geometry[a.Name]:
+				n.Attrs[i].Value = roundNumbers(a.Value, precision)
+			}
+		}
+	})
+}
+
+func roundNumbers(s string, precision int) string {
+	scale := math.Pow(10, float64(precision))
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, m := range number.FindAllStringIndex(s, -1) {
+		b.WriteString(s[last:m[0]])
+		writeRounded(&b, s[m[0]:m[1]], scale, m[0] > 0 && m[0] == last)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// roundPath rounds the numbers of path data. The large-arc and sweep flags of
+// arcs are single characters that may be packed with the numbers around them
+// ("a6 6 0 011.5 1"), so they are copied as they are rather than read as
+// numbers.
+func roundPath(d string, precision int) string {
+	scale := math.Pow(10, float64(precision))
+	var (
+		b       strings.Builder
+		cmd     byte
+		arg     int
+		lastNum = -1
+	)
+	for i := 0; i < len(d); {
+		c := d[i]
+		if (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e' && c != 'E' {
+			cmd, arg = c, 0
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if (cmd == 'a' || cmd == 'A') && (arg%7 == 3 || arg%7 == 4) && (c == '0' || c == '1') {
+			b.WriteByte(c)
+			arg++
+			i++
+			continue
+		}
+		m := number.FindStringIndex(d[i:])
+		if m == nil || m[0] != 0 {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		writeRounded(&b, d[i:i+m[1]], scale, i == lastNum)
+		arg++
+		i += m[1]
+		lastNum = i
+	}
+	return b.String()
+}
+
+// writeRounded writes the number tok rounded by scale. adjacent tells that it
+// directly follows another number.
+func writeRounded(b *strings.Builder, tok string, scale float64, adjacent bool) {
+	v, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		b.WriteString(tok)
+		return
+	}
+	v = math.Round(v*scale) / scale
+	if v == 0 {
+		v = 0 // drops the sign of -0
+	}
+	out := strconv.FormatFloat(v, 'f', -1, 64)
+	// Path data may run numbers together ("1.5.5"); a separator keeps
+	// them apart once their digits change
+	if adjacent && !strings.HasPrefix(out, "-") {
+		b.WriteByte(' ')
+	}
+	b.WriteString(out)
+}
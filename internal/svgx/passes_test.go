@@ -0,0 +1,100 @@
+package svgx
+
+import (
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, src string) *Node {
+	t.Helper()
+	root, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return root
+}
+
+func TestStripMetadata(t *testing.T) {
+	root := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"
+		xmlns:sketch="http://www.bohemiancoding.com/sketch/ns" version="1.1" sketch:type="MSPage">
+		<metadata>created by</metadata><!-- Generator: Figma -->
+		<sketch:page/>
+		<defs><linearGradient id="g1"/><clipPath id="c1"/></defs>
+		<path id="unused" fill="url(#g1)" clip-path="url(#c1)" sketch:type="MSShapeGroup" d="M0 0"/>
+	</svg>`)
+	StripMetadata(root)
+	want := `<svg xmlns="http://www.w3.org/2000/svg"><defs><linearGradient id="g1"/><clipPath id="c1"/></defs><path fill="url(#g1)" clip-path="url(#c1)" d="M0 0"/></svg>`
+	if got := string(root.Bytes()); got != want {
+		t.Fatalf("strip:\n got %s\nwant %s", got, want)
+	}
+
+	linked := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><path id="p"/><use xlink:href="#p"/></svg>`)
+	StripMetadata(linked)
+	if got := string(linked.Bytes()); !strings.Contains(got, `xmlns:xlink=`) || !strings.Contains(got, `id="p"`) {
+		t.Fatalf("xlink reference dropped: %s", got)
+	}
+}
+
+func TestCollapseGroups(t *testing.T) {
+	root := parse(t, `<svg xmlns="http://www.w3.org/2000/svg">
+		<g><g></g><g fill="red"><path d="M0 0"/></g></g>
+		<g opacity="0.5"><path d="M1 1"/></g>
+		<g fill="red"><path fill="blue" d="M2 2"/></g>
+		<g transform="translate(1 1)"><rect/><rect/></g>
+	</svg>`)
+	CollapseGroups(root)
+	want := `<svg xmlns="http://www.w3.org/2000/svg"><path d="M0 0" fill="red"/><g opacity="0.5"><path d="M1 1"/></g>` +
+		`<g fill="red"><path fill="blue" d="M2 2"/></g><g transform="translate(1 1)"><rect/><rect/></g></svg>`
+	if got := string(root.Bytes()); got != want {
+		t.Fatalf("collapse:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestCurrentColor(t *testing.T) {
+	root := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" fill="none"><path fill="#1E1E1E"/><path fill="url(#g)"/><path style="fill: red;stroke:blue"/><path stroke="#000"/></svg>`)
+	CurrentColor(root)
+	want := `<svg xmlns="http://www.w3.org/2000/svg" fill="none"><path fill="currentColor"/><path fill="url(#g)"/><path style="fill:currentColor;stroke:blue"/><path stroke="#000"/></svg>`
+	if got := string(root.Bytes()); got != want {
+		t.Fatalf("current color:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestRound(t *testing.T) {
+	root := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24.0000 24"><path d="M1.23456-0.0001L.5.25l3e-1 1.0.5" fill="#123456" transform="rotate(45.12345 12 12)"/><rect width="10.5555px"/></svg>`)
+	Round(root, 2)
+	want := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path d="M1.23 0L0.5 0.25l0.3 1 0.5" fill="#123456" transform="rotate(45.12 12 12)"/><rect width="10.56px"/></svg>`
+	if got := string(root.Bytes()); got != want {
+		t.Fatalf("round:\n got %s\nwant %s", got, want)
+	}
+	// Arc flags are packed with the numbers next to them and kept as they are
+	for d, want := range map[string]string{
+		"M2 2a6 6 0 011.5 1":                 "M2 2a6 6 0 011.5 1",
+		"M0 0A1.234 1.234 0 1 0 3.456 7.891": "M0 0A1.23 1.23 0 1 0 3.46 7.89",
+		"M0 0a1 1 0 101 1 1 1 0 00-1-1z":     "M0 0a1 1 0 101 1 1 1 0 00-1-1z",
+		"M0 0a1 1 45.678 00.5.5l1.004 2":     "M0 0a1 1 45.68 000.5 0.5l1 2",
+	} {
+		if got := roundPath(d, 2); got != want {
+			t.Errorf("roundPath(%q) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestClean_Passes(t *testing.T) {
+	src := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><!-- c --><g><path fill="#000" d="M0.123456 0"/></g></svg>`)
+	root, err := Clean(src, Options{})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if got := string(root.Bytes()); got != `<svg xmlns="http://www.w3.org/2000/svg"><path fill="#000" d="M0.123 0"/></svg>` {
+		t.Fatalf("default passes: %s", got)
+	}
+	one := 1
+	root, _ = Clean(src, Options{Passes: []Pass{CurrentColorPass, RoundPass}, Precision: &one})
+	if got := string(root.Bytes()); got != `<svg xmlns="http://www.w3.org/2000/svg"><!-- c --><g><path fill="currentColor" d="M0.1 0"/></g></svg>` {
+		t.Fatalf("selected passes: %s", got)
+	}
+	root, _ = Clean(src, Options{Passes: []Pass{}})
+	if got := string(root.Bytes()); got != string(src) {
+		t.Fatalf("no passes: %s", got)
+	}
+}
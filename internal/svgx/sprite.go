@@ -0,0 +1,142 @@
+package svgx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Icon is a cleaned icon ready to be packed.
+type Icon struct {
+	// ID is the symbol id in a sprite; it must be unique among the icons.
+	ID   string
+	Root *Node
+}
+
+// ManifestEntry describes one icon of an export.
+type ManifestEntry struct {
+	ID      string  `json:"id"`
+	NodeID  string  `json:"node_id"`
+	Name    string  `json:"name"`
+	Path    string  `json:"path"`
+	Width   float64 `json:"width,omitempty"`
+	Height  float64 `json:"height,omitempty"`
+	ViewBox string  `json:"viewBox,omitempty"`
+	Size    int     `json:"size"`
+	Digest  string  `json:"digest"`
+}
+
+// Manifest lists the icons of an export and where the sprite is.
+type Manifest struct {
+	Sprite string          `json:"sprite,omitempty"`
+	Icons  []ManifestEntry `json:"icons"`
+}
+
+// ViewBox returns the viewBox of an icon, derived from its width and height
+// when the attribute is missing.
+func ViewBox(root *Node) string {
+	if vb, ok := root.Attr("viewBox"); ok {
+		return vb
+	}
+	w, h := Size(root)
+	if w > 0 && h > 0 {
+		return "0 0 " + strconv.FormatFloat(w, 'f', -1, 64) + " " + strconv.FormatFloat(h, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Size returns the width and height attributes of an icon in user units,
+// falling back to the viewBox size.
+func Size(root *Node) (w, h float64) {
+	parse := func(name string) float64 {
+		v, _ := root.Attr(name)
+		f, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "px"), 64)
+		return f
+	}
+	w, h = parse("width"), parse("height")
+	if w == 0 || h == 0 {
+		if vb := strings.Fields(strings.ReplaceAll(attr(root, "viewBox"), ",", " ")); len(vb) == 4 {
+			w, _ = strconv.ParseFloat(vb[2], 64)
+			h, _ = strconv.ParseFloat(vb[3], 64)
+		}
+	}
+	return w, h
+}
+
+func attr(n *Node, name string) string {
+	v, _ := n.Attr(name)
+	return v
+}
+
+// symbolSkip lists root attributes that do not carry over to a <symbol>.
+var symbolSkip = map[string]bool{"xmlns": true, "width": true, "height": true, "x": true, "y": true, "viewBox": true, "version": true}
+
+// Sprite packs icons into one SVG of <symbol> elements, used with
+// <svg><use href="sprite.svg#id"/></svg>. Ids inside an icon are prefixed
+// with the icon id so icons cannot clash.
+func Sprite(icons []Icon) ([]byte, error) {
+	root := &Node{Name: "svg", Attrs: []Attr{{"xmlns", "http://www.w3.org/2000/svg"}}}
+	seen := map[string]bool{}
+	for _, ic := range icons {
+		if ic.ID == "" || seen[ic.ID] {
+			return nil, fmt.Errorf("svgx: duplicate or empty symbol id %q", ic.ID)
+		}
+		seen[ic.ID] = true
+		icon := ic.Root.Clone()
+		prefixIDs(icon, ic.ID+"-")
+		sym := &Node{Name: "symbol", Attrs: []Attr{{"id", ic.ID}}, Children: icon.Children}
+		if vb := ViewBox(icon); vb != "" {
+			sym.SetAttr("viewBox", vb)
+		}
+		for _, a := range icon.Attrs {
+			if !symbolSkip[a.Name] && !strings.HasPrefix(a.Name, "xmlns:") {
+				sym.SetAttr(a.Name, a.Value)
+			}
+		}
+		if usesPrefix(icon, "xlink") {
+			root.SetAttr("xmlns:xlink", "http://www.w3.org/1999/xlink")
+		}
+		root.Children = append(root.Children, sym)
+	}
+	return root.Bytes(), nil
+}
+
+// prefixIDs renames the ids below root and the references to them.
+func prefixIDs(root *Node, prefix string) {
+	ids := map[string]bool{}
+	for _, c := range root.Children {
+		c.walk(func(n *Node) {
+			if id, ok := n.Attr("id"); ok {
+				ids[id] = true
+				n.SetAttr("id", prefix+id)
+			}
+		})
+	}
+	if len(ids) == 0 {
+		return
+	}
+	rename := func(s string) string {
+		return urlRef.ReplaceAllStringFunc(s, func(m string) string {
+			id := urlRef.FindStringSubmatch(m)[1]
+			if !ids[id] {
+				return m
+			}
+			return strings.TrimSuffix(m, id) + prefix + id
+		})
+	}
+	root.walk(func(n *Node) {
+		for i, a := range n.Attrs {
+			switch {
+			case (a.Name == "href" || a.Name == "xlink:href") && strings.HasPrefix(a.Value, "#") && ids[a.Value[1:]]:
+				n.Attrs[i].Value = "#" + prefix + a.Value[1:]
+			case a.Name != "id":
+				n.Attrs[i].Value = rename(a.Value)
+			}
+		}
+		if n.Name == "style" {
+			for _, c := range n.Children {
+				c.Text = rename(c.Text)
+			}
+		}
+	})
+}
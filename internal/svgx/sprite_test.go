@@ -0,0 +1,29 @@
+package svgx
+
+import "testing"
+
+func TestSprite(t *testing.T) {
+	a := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" fill="none"><defs><clipPath id="c"><rect/></clipPath></defs><path clip-path="url(#c)" d="M0 0"/></svg>`)
+	b := parse(t, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 16 16"><path id="c" d="M1 1"/><use xlink:href="#c"/></svg>`)
+	got, err := Sprite([]Icon{{ID: "a", Root: a}, {ID: "b", Root: b}})
+	if err != nil {
+		t.Fatalf("sprite: %v", err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">` +
+		`<symbol id="a" viewBox="0 0 24 24" fill="none"><defs><clipPath id="a-c"><rect/></clipPath></defs><path clip-path="url(#a-c)" d="M0 0"/></symbol>` +
+		`<symbol id="b" viewBox="0 0 16 16"><path id="b-c" d="M1 1"/><use xlink:href="#b-c"/></symbol></svg>`
+	if string(got) != want {
+		t.Fatalf("sprite:\n got %s\nwant %s", got, want)
+	}
+	// The icons themselves are left untouched
+	if v, _ := a.Children[1].Attr("clip-path"); v != "url(#c)" {
+		t.Fatalf("icon modified: %s", a.Bytes())
+	}
+	if _, err := Sprite([]Icon{{ID: "a", Root: a}, {ID: "a", Root: b}}); err == nil {
+		t.Fatal("expected an error for duplicate ids")
+	}
+
+	if w, h := Size(b); w != 16 || h != 16 || ViewBox(a) != "0 0 24 24" {
+		t.Fatalf("size = %v x %v, viewBox %q", w, h, ViewBox(a))
+	}
+}
@@ -0,0 +1,177 @@
+package svgx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/logx"
+	"fiber-ent-apollo-pg/internal/naming"
+)
+
+var svgLogger = logx.GetScope("svgx")
+
+// Artifact names. Icons are written below ArtifactDir at their naming path.
+const (
+	ArtifactDir      = "icons/"
+	SpriteArtifact   = ArtifactDir + "sprite.svg"
+	ManifestArtifact = ArtifactDir + "manifest.json"
+)
+
+// MaxIcons bounds the icons of one export.
+const MaxIcons = 2000
+
+// imageBatch is the number of nodes rendered per images request.
+const imageBatch = 100
+
+// Step returns the export step rendering the project's components as
+// cleaned SVG icons. Jobs whose config has no svg section are left alone.
+func Step(client *ent.Client, fg *figmax.Client) exportx.Step {
+	return exportx.Step{Name: "svg", Run: func(ctx context.Context, job *ent.ExportJob, out *exportx.Output) error {
+		opts, ok, err := OptionsFromConfig(job.Config)
+		if err != nil || !ok {
+			return err
+		}
+		nopts, err := naming.OptionsFromConfig(job.Config)
+		if err != nil {
+			return err
+		}
+		if fg == nil {
+			return errors.New("figma is not configured")
+		}
+		proj, err := client.Project.Get(ctx, job.ProjectID)
+		if err != nil {
+			return fmt.Errorf("load project: %w", err)
+		}
+		key := proj.FileKey
+		if proj.BranchKey != "" {
+			key = proj.BranchKey
+		}
+		if key == "" {
+			return errors.New("project has no figma file")
+		}
+
+		file, err := fg.GetFile(ctx, key, figmax.FileOptions{})
+		if err != nil {
+			return fmt.Errorf("fetch file: %w", err)
+		}
+		nodes, err := Components(file, opts)
+		if err != nil {
+			return err
+		}
+		if len(nodes) > MaxIcons {
+			return fmt.Errorf("%d icons selected, at most %d are exported", len(nodes), MaxIcons)
+		}
+
+		// Name every icon before rendering, so collisions fail fast
+		namer, err := naming.New(nopts)
+		if err != nil {
+			return err
+		}
+		if opts.Sprite {
+			namer.Reserve(strings.TrimPrefix(SpriteArtifact, ArtifactDir))
+		}
+		paths := make([]string, len(nodes))
+		for i := range nodes {
+			nodes[i].Format, nodes[i].Scale = "svg", 1
+			res, err := namer.Name(nodes[i])
+			if err != nil {
+				return err
+			}
+			paths[i] = ArtifactDir + res.Path
+		}
+
+		var (
+			icons    []Icon
+			manifest = Manifest{Icons: []ManifestEntry{}}
+			symbols  = map[string]bool{}
+		)
+		for start := 0; start < len(nodes); start += imageBatch {
+			batch := nodes[start:min(start+imageBatch, len(nodes))]
+			ids := make([]string, len(batch))
+			for i, n := range batch {
+				ids[i] = n.ID
+			}
+			urls, err := fg.GetImages(ctx, key, ids, figmax.ImageOptions{Format: "svg"})
+			if err != nil {
+				return fmt.Errorf("render icons: %w", err)
+			}
+			for i, n := range batch {
+				name := paths[start+i]
+				if urls[n.ID] == "" {
+					svgLogger.Warn("icon not rendered", zap.String("job", job.ID.String()), zap.String("node", n.ID))
+					continue
+				}
+				raw, err := fg.Download(ctx, urls[n.ID])
+				if err != nil {
+					return fmt.Errorf("download %s: %w", n.ID, err)
+				}
+				root, err := Clean(raw, opts)
+				if err != nil {
+					return fmt.Errorf("icon %s: %w", n.ID, err)
+				}
+				data := root.Bytes()
+				if _, err := out.Put(ctx, name, "image/svg+xml", bytes.NewReader(data)); err != nil {
+					return err
+				}
+
+				id := symbolID(name, symbols)
+				icons = append(icons, Icon{ID: id, Root: root})
+				w, h := Size(root)
+				manifest.Icons = append(manifest.Icons, ManifestEntry{
+					ID: id, NodeID: n.ID, Name: n.Name, Path: name,
+					Width: w, Height: h, ViewBox: ViewBox(root),
+					Size: len(data), Digest: blobx.Digest(data),
+				})
+			}
+		}
+
+		if opts.Sprite {
+			sprite, err := Sprite(icons)
+			if err != nil {
+				return err
+			}
+			if _, err := out.Put(ctx, SpriteArtifact, "image/svg+xml", bytes.NewReader(sprite)); err != nil {
+				return err
+			}
+			manifest.Sprite = SpriteArtifact
+		}
+		if opts.Manifest {
+			b, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				return err
+			}
+			if _, err := out.Put(ctx, ManifestArtifact, "application/json", bytes.NewReader(b)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+}
+
+// symbolID derives a unique sprite symbol id from an icon path:
+// icons/nav/arrow-left.svg becomes nav-arrow-left.
+func symbolID(name string, taken map[string]bool) string {
+	base := strings.TrimSuffix(strings.TrimPrefix(name, ArtifactDir), ".svg")
+	base = strings.Map(func(r rune) rune {
+		if r == '/' || r == ' ' || r == '#' {
+			return '-'
+		}
+		return r
+	}, base)
+	id := base
+	for i := 2; taken[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	taken[id] = true
+	return id
+}
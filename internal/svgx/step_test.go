@@ -0,0 +1,177 @@
+package svgx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/figmax/figmatest"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:svgx?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+const document = `{"id": "0:0", "type": "DOCUMENT", "children": [
+  {"id": "0:1", "name": "Icons", "type": "CANVAS", "children": [
+    {"id": "1:1", "name": "Navigation", "type": "FRAME", "children": [
+      {"id": "1:2", "name": "Nav/Arrow", "type": "COMPONENT"},
+      {"id": "1:3", "name": "Toggle", "type": "COMPONENT_SET", "children": [
+        {"id": "1:4", "name": "State=On", "type": "COMPONENT"},
+        {"id": "1:5", "name": "State=Off", "type": "COMPONENT"}
+      ]},
+      {"id": "1:6", "name": "Nav/Arrow", "type": "INSTANCE"}
+    ]},
+    {"id": "1:7", "name": "Logo", "type": "COMPONENT"}
+  ]},
+  {"id": "0:2", "name": "Drafts", "type": "CANVAS", "children": [
+    {"id": "2:1", "name": "Scratch", "type": "COMPONENT"}
+  ]}
+]}`
+
+func TestComponents(t *testing.T) {
+	file := &figmax.File{Document: json.RawMessage(document)}
+	nodes, err := Components(file, Options{Pages: []string{"Icons"}})
+	if err != nil {
+		t.Fatalf("components: %v", err)
+	}
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.ID+":"+n.Frame+":"+n.Component+":"+n.Variants["State"])
+	}
+	want := "1:2:Navigation:Nav/Arrow: 1:4:Navigation:Toggle:On 1:5:Navigation:Toggle:Off 1:7::Logo:"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("components = %v", got)
+	}
+	if nodes, _ := Components(file, Options{Components: []string{"Nav"}}); len(nodes) != 1 || nodes[0].ID != "1:2" {
+		t.Fatalf("filtered = %+v", nodes)
+	}
+}
+
+func TestStep(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	icon := func(name, color string) string {
+		return srv.SetAsset(name, []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24" fill="none">`+
+			`<g><path d="M1.23456 2h20" fill="`+color+`"/></g></svg>`))
+	}
+	srv.SetFile("FILE1", figmatest.File{
+		File: figmax.File{Name: "Icons", Document: json.RawMessage(document)},
+		Images: map[string]string{
+			"1:2": icon("arrow.svg", "#000000"),
+			"1:4": icon("on.svg", "#00FF00"),
+			"1:5": icon("off.svg", "#FF0000"),
+		},
+	})
+	store, _ := blobx.NewFSStore(t.TempDir())
+	svc := exportx.NewService(client, nil, store, Step(client, srv.FigmaClient()))
+
+	u := client.User.Create().SetDisplayName("Designer").SaveX(ctx)
+	p := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/FILE1").SetFileKey("FILE1").SetOwnerID(u.ID).SaveX(ctx)
+	newJob := func(cfg map[string]any) *ent.ExportJob {
+		return client.ExportJob.Create().SetProjectID(p.ID).SetRequestedByID(u.ID).
+			SetConfigID(uuid.New()).SetConfigRevision(1).SetConfig(cfg).SaveX(ctx)
+	}
+	read := func(a *ent.Artifact) string {
+		blob, err := store.Open(ctx, a.Digest)
+		if err != nil {
+			t.Fatalf("open %s: %v", a.Name, err)
+		}
+		defer blob.Close()
+		b, _ := io.ReadAll(blob)
+		return string(b)
+	}
+
+	job := newJob(map[string]any{
+		"naming": map[string]any{"template": "{component|flat}-{variant.State}", "case": "kebab"},
+		"svg": map[string]any{
+			"pages":     []any{"Icons"},
+			"passes":    []any{"strip-metadata", "collapse-groups", "current-color", "round"},
+			"precision": 1, "sprite": true, "manifest": true,
+		},
+	})
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := client.ExportJob.GetX(ctx, job.ID); got.Status != exportjob.StatusSucceeded {
+		t.Fatalf("job %s: %s", got.Status, got.Error)
+	}
+	arts := map[string]*ent.Artifact{}
+	for _, a := range client.Artifact.Query().Where(artifact.JobIDEQ(job.ID)).AllX(ctx) {
+		arts[a.Name] = a
+	}
+	// Logo has no rendered image and is skipped
+	for _, name := range []string{"icons/nav-arrow.svg", "icons/toggle-on.svg", "icons/toggle-off.svg", SpriteArtifact, ManifestArtifact} {
+		if arts[name] == nil {
+			t.Fatalf("missing artifact %s in %v", name, arts)
+		}
+	}
+	if len(arts) != 5 {
+		t.Fatalf("unexpected artifacts: %v", arts)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24" fill="none"><path d="M1.2 2h20" fill="currentColor"/></svg>`
+	if got := read(arts["icons/nav-arrow.svg"]); got != want || arts["icons/nav-arrow.svg"].ContentType != "image/svg+xml" {
+		t.Fatalf("icon = %s", got)
+	}
+	if sprite := read(arts[SpriteArtifact]); strings.Count(sprite, "<symbol") != 3 || !strings.Contains(sprite, `<symbol id="toggle-on" viewBox="0 0 24 24"`) {
+		t.Fatalf("sprite = %s", sprite)
+	}
+	var m Manifest
+	if err := json.Unmarshal([]byte(read(arts[ManifestArtifact])), &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if m.Sprite != SpriteArtifact || len(m.Icons) != 3 || m.Icons[1].NodeID != "1:4" || m.Icons[1].Path != "icons/toggle-on.svg" ||
+		m.Icons[1].Width != 24 || m.Icons[1].Digest != arts["icons/toggle-on.svg"].Digest {
+		t.Fatalf("manifest = %+v", m)
+	}
+
+	// Configs without an svg section produce nothing
+	plain := newJob(map[string]any{"format": "svg"})
+	if err := svc.Run(ctx, plain.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if n := client.Artifact.Query().Where(artifact.JobIDEQ(plain.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("plain job wrote %d artifacts", n)
+	}
+
+	// Colliding names fail the job when the naming rules say so
+	strict := newJob(map[string]any{
+		"naming": map[string]any{"template": "{component|flat}", "collision": "error"},
+		"svg":    map[string]any{"components": []any{"Toggle"}},
+	})
+	if err := svc.Run(ctx, strict.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := client.ExportJob.GetX(ctx, strict.ID); got.Status != exportjob.StatusFailed || !strings.Contains(got.Error, "both map to") {
+		t.Fatalf("strict job %s: %s", got.Status, got.Error)
+	}
+}
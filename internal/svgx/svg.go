@@ -0,0 +1,201 @@
+// Package svgx cleans up SVG icons exported from Figma: it strips editor
+// metadata, collapses redundant groups, maps fills to currentColor and rounds
+// coordinates, and packs icons into a sprite with a JSON manifest. Which
+// passes run is read from config data.
+package svgx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Node is an element, text or comment of an SVG document. Names keep their
+// namespace prefix, e.g. "xlink:href".
+type Node struct {
+	// Name is the element name; it is empty for text and comments.
+	Name     string
+	Attrs    []Attr
+	Children []*Node
+	// Text is the content of a text or comment node.
+	Text    string
+	Comment bool
+}
+
+// Attr is an attribute of an element.
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Attr returns the value of attribute name.
+func (n *Node) Attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttr sets attribute name, appending it when missing.
+func (n *Node) SetAttr(name, value string) {
+	for i := range n.Attrs {
+		if n.Attrs[i].Name == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, Attr{Name: name, Value: value})
+}
+
+// DelAttr removes attribute name.
+func (n *Node) DelAttr(name string) {
+	out := n.Attrs[:0]
+	for _, a := range n.Attrs {
+		if a.Name != name {
+			out = append(out, a)
+		}
+	}
+	n.Attrs = out
+}
+
+// Clone returns a deep copy of n.
+func (n *Node) Clone() *Node {
+	c := *n
+	c.Attrs = append([]Attr(nil), n.Attrs...)
+	c.Children = make([]*Node, len(n.Children))
+	for i, ch := range n.Children {
+		c.Children[i] = ch.Clone()
+	}
+	return &c
+}
+
+// walk calls fn on n and every element below it, parents first.
+func (n *Node) walk(fn func(*Node)) {
+	if n.Name == "" {
+		return
+	}
+	fn(n)
+	for _, c := range n.Children {
+		c.walk(fn)
+	}
+}
+
+// preserveSpace lists elements whose whitespace is content.
+var preserveSpace = map[string]bool{"text": true, "tspan": true, "textPath": true, "style": true}
+
+// MaxDepth bounds element nesting, so hostile input cannot exhaust the stack.
+const MaxDepth = 256
+
+// Parse reads an SVG document. The XML declaration, doctype and processing
+// instructions are dropped, as is whitespace between elements.
+func Parse(r io.Reader) (*Node, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = true
+	var (
+		root  *Node
+		stack []*Node
+	)
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svgx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &Node{Name: qname(t.Name)}
+			for _, a := range t.Attr {
+				n.Attrs = append(n.Attrs, Attr{Name: qname(a.Name), Value: a.Value})
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errors.New("svgx: more than one root element")
+				}
+				root = n
+			} else {
+				p := stack[len(stack)-1]
+				p.Children = append(p.Children, n)
+			}
+			if len(stack) >= MaxDepth {
+				return nil, errors.New("svgx: elements nested too deep")
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].Name != qname(t.Name) {
+				return nil, fmt.Errorf("svgx: unexpected </%s>", qname(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			p := stack[len(stack)-1]
+			if !preserveSpace[p.Name] && len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			p.Children = append(p.Children, &Node{Text: string(t)})
+		case xml.Comment:
+			if len(stack) > 0 {
+				p := stack[len(stack)-1]
+				p.Children = append(p.Children, &Node{Text: string(t), Comment: true})
+			}
+		}
+	}
+	if root == nil || len(stack) > 0 {
+		return nil, errors.New("svgx: incomplete document")
+	}
+	if root.Name != "svg" {
+		return nil, fmt.Errorf("svgx: root element is <%s>, not <svg>", root.Name)
+	}
+	return root, nil
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\n", "&#xA;", "\t", "&#x9;", "\r", "&#xD;")
+)
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// Bytes renders n as compact XML.
+func (n *Node) Bytes() []byte {
+	var b bytes.Buffer
+	n.render(&b)
+	return b.Bytes()
+}
+
+func (n *Node) render(b *bytes.Buffer) {
+	switch {
+	case n.Comment:
+		b.WriteString("<!--" + strings.ReplaceAll(n.Text, "--", "- -") + "-->")
+		return
+	case n.Name == "":
+		b.WriteString(textEscaper.Replace(n.Text))
+		return
+	}
+	b.WriteString("<" + n.Name)
+	for _, a := range n.Attrs {
+		b.WriteString(" " + a.Name + `="` + attrEscaper.Replace(a.Value) + `"`)
+	}
+	if len(n.Children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteByte('>')
+	for _, c := range n.Children {
+		c.render(b)
+	}
+	b.WriteString("</" + n.Name + ">")
+}
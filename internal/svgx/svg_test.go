@@ -0,0 +1,43 @@
+package svgx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE svg>
+<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24">
+  <!-- note -->
+  <text x="1"> a &amp; b </text>
+  <path d="M0 0h24" data-x="a&quot;b"/>
+</svg>`
+	root, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24"><!-- note --><text x="1"> a &amp; b </text><path d="M0 0h24" data-x="a&quot;b"/></svg>`
+	if got := string(root.Bytes()); got != want {
+		t.Fatalf("render:\n got %s\nwant %s", got, want)
+	}
+	again, err := Parse(strings.NewReader(want))
+	if err != nil || string(again.Bytes()) != want {
+		t.Fatalf("second round trip: %v", err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`<svg>`,
+		`<svg></g>`,
+		`<html/>`,
+		`<svg/><svg/>`,
+		strings.Repeat("<g>", MaxDepth+1),
+	} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("Parse(%.20q): expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package tokens
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+// maxAliasDepth bounds alias chains, which also stops alias cycles.
+const maxAliasDepth = 16
+
+// style is an entry of a file's styles map.
+type style struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	StyleType   string `json:"styleType"`
+	Description string `json:"description"`
+}
+
+// Extract builds the token tree of a file. Style values are read from the
+// first document node using each style; variables are optional since not
+// every Figma plan exposes them. Styles and variables that cannot be
+// represented are skipped with a warning rather than failing the export.
+func Extract(file *figmax.File, vars *figmax.Variables, opts Options) (*Tree, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	b := &builder{opts: opts, tree: &Tree{Modes: map[string][]string{}}, leaves: map[string]bool{}, groups: map[string]bool{}}
+	if err := b.styles(file); err != nil {
+		return nil, err
+	}
+	if vars != nil && opts.wantsVariables() {
+		b.variables(vars)
+	}
+	b.tree.sort()
+	return b.tree, nil
+}
+
+type builder struct {
+	opts   Options
+	tree   *Tree
+	leaves map[string]bool
+	groups map[string]bool
+}
+
+func (b *builder) warn(format string, args ...any) {
+	b.tree.Warnings = append(b.tree.Warnings, fmt.Sprintf(format, args...))
+}
+
+// splitName turns a Figma name into token path segments. Dots and braces are
+// reserved by alias syntax, so "space/0.5" becomes ["space", "0-5"].
+func splitName(name string) []string {
+	var out []string
+	for _, seg := range strings.Split(name, "/") {
+		seg = strings.Join(strings.Fields(seg), " ")
+		seg = strings.NewReplacer(".", "-", "{", "", "}", "").Replace(seg)
+		if seg != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+// reserve claims the path of a token, refusing duplicates and paths that would
+// be both a token and a group.
+func (b *builder) reserve(typ Type, p []string, what string) bool {
+	if len(p) == 0 {
+		b.warn("%s: empty name", what)
+		return false
+	}
+	key := string(typ) + "/" + strings.Join(p, "/")
+	if b.leaves[key] {
+		b.warn("%s: duplicate token %s", what, key)
+		return false
+	}
+	if b.groups[key] {
+		b.warn("%s: %s is already a group", what, key)
+		return false
+	}
+	for i := 1; i < len(p); i++ {
+		if prefix := string(typ) + "/" + strings.Join(p[:i], "/"); b.leaves[prefix] {
+			b.warn("%s: %s is already a token", what, prefix)
+			return false
+		}
+	}
+	b.leaves[key] = true
+	for i := 1; i < len(p); i++ {
+		b.groups[string(typ)+"/"+strings.Join(p[:i], "/")] = true
+	}
+	return true
+}
+
+func (b *builder) styles(file *figmax.File) error {
+	if len(file.Styles) == 0 {
+		return nil
+	}
+	styles := make(map[string]style, len(file.Styles))
+	for id, raw := range file.Styles {
+		var s style
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("tokens: decode style %s: %w", id, err)
+		}
+		styles[id] = s
+	}
+	var doc node
+	if len(file.Document) > 0 {
+		if err := json.Unmarshal(file.Document, &doc); err != nil {
+			return fmt.Errorf("tokens: decode document: %w", err)
+		}
+	}
+
+	done := map[string]bool{}
+	var walk func(n *node)
+	walk = func(n *node) {
+		keys := make([]string, 0, len(n.Styles))
+		for k := range n.Styles {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, prop := range keys {
+			id := n.Styles[prop]
+			s, ok := styles[id]
+			if !ok || done[id] {
+				continue
+			}
+			done[id] = true
+			b.style(id, s, prop, n)
+		}
+		for i := range n.Children {
+			walk(&n.Children[i])
+		}
+	}
+	walk(&doc)
+
+	ids := make([]string, 0, len(styles))
+	for id := range styles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		s := styles[id]
+		if typ := styleType(s.StyleType); !done[id] && typ != "" && b.opts.wants(typ) && b.opts.matchName(s.Name) {
+			b.warn("style %q: not used in the document, value unknown", s.Name)
+		}
+	}
+	return nil
+}
+
+func styleType(t string) Type {
+	switch t {
+	case "FILL":
+		return Color
+	case "TEXT":
+		return Typography
+	case "EFFECT":
+		return Effect
+	}
+	return ""
+}
+
+// style adds the token of a style applied to n as property prop.
+func (b *builder) style(id string, s style, prop string, n *node) {
+	typ := styleType(s.StyleType)
+	if typ == "" || !b.opts.wants(typ) || !b.opts.matchName(s.Name) {
+		return
+	}
+	var (
+		value any
+		err   error
+	)
+	switch typ {
+	case Color:
+		paints := n.Fills
+		if strings.HasPrefix(prop, "stroke") {
+			paints = n.Strokes
+		}
+		value, err = paintColor(paints)
+	case Typography:
+		if n.Style == nil {
+			err = errors.New("node has no text style")
+		} else {
+			value = typographyValue(n.Style)
+		}
+	case Effect:
+		value, err = effectValue(n.Effects)
+	}
+	what := fmt.Sprintf("style %q", s.Name)
+	if err != nil {
+		b.warn("%s: %v", what, err)
+		return
+	}
+	p := splitName(s.Name)
+	if !b.reserve(typ, p, what) {
+		return
+	}
+	b.tree.Tokens = append(b.tree.Tokens, Token{Path: p, Type: typ, Value: value, Description: s.Description, Source: id})
+}
+
+// variableType classifies a variable. Number variables become spacing or
+// radius tokens by their scopes, or by their top group name when they apply
+// to all scopes.
+func variableType(v *figmax.Variable) Type {
+	switch v.ResolvedType {
+	case "COLOR":
+		return Color
+	case "FLOAT":
+	default:
+		return ""
+	}
+	scoped := false
+	for _, s := range v.Scopes {
+		switch s {
+		case "CORNER_RADIUS":
+			return Radius
+		case "GAP", "WIDTH_HEIGHT":
+			return Spacing
+		case "ALL_SCOPES":
+		default:
+			scoped = true
+		}
+	}
+	if scoped {
+		return ""
+	}
+	top := strings.ToLower(strings.TrimSpace(strings.SplitN(v.Name, "/", 2)[0]))
+	switch top {
+	case "space", "spacing", "spacings", "gap", "gaps":
+		return Spacing
+	case "radius", "radii", "corner", "corners", "rounded":
+		return Radius
+	}
+	return ""
+}
+
+type varToken struct {
+	v     *figmax.Variable
+	tok   Token
+	modes []figmax.VariableMode
+	def   string
+}
+
+func (b *builder) variables(vars *figmax.Variables) {
+	ids := make([]string, 0, len(vars.Variables))
+	for id := range vars.Variables {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, c := vars.Variables[ids[i]], vars.Variables[ids[j]]
+		if a.Name != c.Name {
+			return a.Name < c.Name
+		}
+		return ids[i] < ids[j]
+	})
+
+	// First claim the paths of every selected variable, so aliases between
+	// them can be kept as references
+	var selected []*varToken
+	names := map[string]string{}
+	for _, id := range ids {
+		v := vars.Variables[id]
+		coll, ok := vars.Collections[v.VariableCollectionID]
+		if !ok || !b.opts.matchCollection(coll.Name) || !b.opts.matchName(v.Name) {
+			continue
+		}
+		typ := variableType(&v)
+		if typ == "" || !b.opts.wants(typ) {
+			continue
+		}
+		var modes []figmax.VariableMode
+		for _, m := range coll.Modes {
+			if b.opts.matchMode(m.Name) {
+				modes = append(modes, m)
+			}
+		}
+		if len(modes) == 0 {
+			continue
+		}
+		what := fmt.Sprintf("variable %q", v.Name)
+		p := splitName(v.Name)
+		if !b.reserve(typ, p, what) {
+			continue
+		}
+		vt := &varToken{v: &v, tok: Token{Path: p, Type: typ, Description: v.Description, Source: id}, modes: modes, def: defaultMode(coll, modes, b.opts.Modes)}
+		selected = append(selected, vt)
+		names[id] = vt.tok.Name()
+		if _, ok := b.tree.Modes[coll.Name]; !ok {
+			for _, m := range modes {
+				b.tree.Modes[coll.Name] = append(b.tree.Modes[coll.Name], m.Name)
+			}
+		}
+	}
+
+	for _, vt := range selected {
+		what := fmt.Sprintf("variable %q", vt.v.Name)
+		value, err := b.variableValue(vars, names, vt.v, vt.def, vt.tok.Type, 0)
+		if err != nil {
+			b.warn("%s: %v", what, err)
+			continue
+		}
+		vt.tok.Value = value
+		if len(vt.modes) > 1 {
+			vt.tok.Modes = map[string]any{}
+			for _, m := range vt.modes {
+				if vt.tok.Modes[m.Name], err = b.variableValue(vars, names, vt.v, m.ModeID, vt.tok.Type, 0); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				b.warn("%s: %v", what, err)
+				continue
+			}
+		}
+		b.tree.Tokens = append(b.tree.Tokens, vt.tok)
+	}
+}
+
+// defaultMode picks the first preferred mode of the collection, else its
+// default mode when selected, else the first selected mode.
+func defaultMode(coll figmax.VariableCollection, modes []figmax.VariableMode, preferred []string) string {
+	for _, name := range preferred {
+		for _, m := range modes {
+			if m.Name == name {
+				return m.ModeID
+			}
+		}
+	}
+	for _, m := range modes {
+		if m.ModeID == coll.DefaultModeID {
+			return m.ModeID
+		}
+	}
+	return modes[0].ModeID
+}
+
+// variableValue returns the value of v in mode. Aliases to exported variables
+// become references; other aliases are resolved, following the target's
+// default mode when it lives in another collection.
+func (b *builder) variableValue(vars *figmax.Variables, names map[string]string, v *figmax.Variable, mode string, typ Type, depth int) (any, error) {
+	if depth > maxAliasDepth {
+		return nil, errors.New("alias chain too deep")
+	}
+	raw, ok := v.ValuesByMode[mode]
+	if !ok {
+		return nil, fmt.Errorf("no value for mode %s", mode)
+	}
+	var alias struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	if json.Unmarshal(raw, &alias) == nil && alias.Type == "VARIABLE_ALIAS" {
+		if name, ok := names[alias.ID]; ok {
+			return "{" + name + "}", nil
+		}
+		target, ok := vars.Variables[alias.ID]
+		if !ok {
+			return nil, fmt.Errorf("alias to unknown variable %s", alias.ID)
+		}
+		if target.VariableCollectionID != v.VariableCollectionID {
+			mode = vars.Collections[target.VariableCollectionID].DefaultModeID
+		}
+		return b.variableValue(vars, names, &target, mode, typ, depth+1)
+	}
+	switch v.ResolvedType {
+	case "COLOR":
+		var c rgba
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("invalid color: %w", err)
+		}
+		if typ != Color {
+			return nil, errors.New("color value for a dimension token")
+		}
+		return hexColor(c, 1), nil
+	case "FLOAT":
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, fmt.Errorf("invalid number: %w", err)
+		}
+		if typ == Color {
+			return nil, errors.New("number value for a color token")
+		}
+		return px(n), nil
+	}
+	return nil, fmt.Errorf("unsupported variable type %s", v.ResolvedType)
+}
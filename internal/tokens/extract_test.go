@@ -0,0 +1,167 @@
+package tokens
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fiber-ent-apollo-pg/internal/figmax"
+)
+
+func loadFixtures(t *testing.T) (*figmax.File, *figmax.Variables) {
+	t.Helper()
+	var (
+		file figmax.File
+		vars figmax.Variables
+	)
+	for name, v := range map[string]any{"testdata/file.json": &file, "testdata/variables.json": &vars} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if err := json.Unmarshal(b, v); err != nil {
+			t.Fatalf("decode %s: %v", name, err)
+		}
+	}
+	return &file, &vars
+}
+
+func TestExtract_Styles(t *testing.T) {
+	file, _ := loadFixtures(t)
+	tree, err := Extract(file, nil, Options{})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := map[string]any{
+		"color.Brand.Primary": "#1064f0",
+		"color.Brand.Overlay": "#00000080",
+		"typography.Text.Heading 1": map[string]any{
+			"fontFamily": "Inter", "fontWeight": 700.0, "fontSize": "32px", "lineHeight": "40px",
+			"letterSpacing": "-0.5px", "textCase": "upper",
+		},
+		"typography.Text.Body": map[string]any{
+			"fontFamily": "Inter", "fontWeight": 400.0, "fontSize": "16px", "lineHeight": 1.5, "letterSpacing": "0px",
+		},
+		"effect.Elevation.1": []map[string]any{{
+			"type": "dropShadow", "offsetX": "0px", "offsetY": "2px", "blur": "8px", "spread": "0px", "color": "#00000040",
+		}},
+	}
+	if len(tree.Tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(tree.Tokens), len(want), tree.Tokens)
+	}
+	for name, value := range want {
+		tok := tree.Find(name)
+		if tok == nil {
+			t.Fatalf("missing token %s", name)
+		}
+		if !reflect.DeepEqual(tok.Value, value) {
+			t.Fatalf("%s = %#v, want %#v", name, tok.Value, value)
+		}
+	}
+	if d := tree.Find("color.Brand.Primary").Description; d != "Main brand color" {
+		t.Fatalf("description = %q", d)
+	}
+	warnings := strings.Join(tree.Warnings, "\n")
+	for _, w := range []string{`"Brand/Gradient": not a single solid paint`, `"Brand/Unused": not used`} {
+		if !strings.Contains(warnings, w) {
+			t.Fatalf("warnings %q lack %q", warnings, w)
+		}
+	}
+}
+
+func TestExtract_Variables(t *testing.T) {
+	file, vars := loadFixtures(t)
+	tree, err := Extract(file, vars, Options{Types: []Type{Color, Spacing, Radius}, Styles: []string{"blue", "gray/*", "space", "gutter", "radius", "surface"}})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	want := map[string]any{
+		"color.blue.500":       "#1064f0",
+		"color.gray.900":       "#111111",
+		"color.surface.accent": "{color.blue.500}",
+		"color.surface.text":   "{color.gray.900}",
+		"spacing.space.0-5":    "2px",
+		"spacing.gutter":       "16px",
+		"radius.radius.md":     "6px",
+	}
+	var got []string
+	for i := range tree.Tokens {
+		got = append(got, tree.Tokens[i].Name())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("tokens = %v", got)
+	}
+	for name, value := range want {
+		if tok := tree.Find(name); tok == nil || tok.Value != value {
+			t.Fatalf("%s = %+v, want %v", name, tok, value)
+		}
+	}
+	accent := tree.Find("color.surface.accent")
+	if !reflect.DeepEqual(accent.Modes, map[string]any{"Light": "{color.blue.500}", "Dark": "#3366ffcc"}) || accent.Description != "Accent surfaces" {
+		t.Fatalf("accent = %+v", accent)
+	}
+	if tree.Find("color.blue.500").Modes != nil {
+		t.Fatalf("single mode tokens have no modes")
+	}
+	if !reflect.DeepEqual(tree.Modes, map[string][]string{"Primitives": {"Value"}, "Theme": {"Light", "Dark"}}) {
+		t.Fatalf("modes = %v", tree.Modes)
+	}
+}
+
+func TestExtract_ModesAndCollections(t *testing.T) {
+	file, vars := loadFixtures(t)
+
+	// Aliases to variables outside the export are resolved
+	tree, err := Extract(file, vars, Options{Types: []Type{Color}, Collections: []string{"Theme"}, Modes: []string{"Dark", "Light"}})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	accent := tree.Find("color.surface.accent")
+	if accent == nil || accent.Value != "#3366ffcc" || accent.Modes["Light"] != "#1064f0" {
+		t.Fatalf("accent = %+v", accent)
+	}
+
+	// A single selected mode becomes the plain value
+	tree, _ = Extract(file, vars, Options{Types: []Type{Color}, Collections: []string{"Theme"}, Modes: []string{"Dark"}})
+	if text := tree.Find("color.surface.text"); text == nil || text.Value != "#ffffff" || text.Modes != nil {
+		t.Fatalf("text = %+v", text)
+	}
+}
+
+func TestExtract_Conflicts(t *testing.T) {
+	vars := &figmax.Variables{
+		Variables: map[string]figmax.Variable{
+			"a": {Name: "brand", VariableCollectionID: "c", ResolvedType: "COLOR", ValuesByMode: map[string]json.RawMessage{"m": json.RawMessage(`{"r":1,"g":0,"b":0,"a":1}`)}},
+			"b": {Name: "brand/dark", VariableCollectionID: "c", ResolvedType: "COLOR", ValuesByMode: map[string]json.RawMessage{"m": json.RawMessage(`{"r":0,"g":0,"b":0,"a":1}`)}},
+			"c": {Name: "loop", VariableCollectionID: "c", ResolvedType: "COLOR", ValuesByMode: map[string]json.RawMessage{"m": json.RawMessage(`{"type":"VARIABLE_ALIAS","id":"x"}`)}},
+		},
+		Collections: map[string]figmax.VariableCollection{"c": {Name: "C", Modes: []figmax.VariableMode{{ModeID: "m", Name: "M"}}, DefaultModeID: "m"}},
+	}
+	tree, err := Extract(&figmax.File{}, vars, Options{})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(tree.Tokens) != 1 || tree.Tokens[0].Name() != "color.brand" || len(tree.Warnings) != 2 {
+		t.Fatalf("tokens = %+v, warnings = %v", tree.Tokens, tree.Warnings)
+	}
+}
+
+func TestTree_MarshalJSON(t *testing.T) {
+	tree := &Tree{Tokens: []Token{
+		{Path: []string{"Brand", "Primary"}, Type: Color, Value: "#1064f0", Description: "Main"},
+		{Path: []string{"surface"}, Type: Color, Value: "{color.Brand.Primary}", Modes: map[string]any{"Light": "{color.Brand.Primary}", "Dark": "#000000"}},
+		{Path: []string{"md"}, Type: Spacing, Value: "8px"},
+	}}
+	b, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"color":{"Brand":{"Primary":{"$description":"Main","$type":"color","$value":"#1064f0"}},` +
+		`"surface":{"$extensions":{"modes":{"Dark":"#000000","Light":"{color.Brand.Primary}"}},"$type":"color","$value":"{color.Brand.Primary}"}},` +
+		`"spacing":{"md":{"$type":"spacing","$value":"8px"}}}`
+	if string(b) != want {
+		t.Fatalf("json = %s", b)
+	}
+}
@@ -0,0 +1,123 @@
+package tokens
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Options selects what is extracted. It is read from the "tokens" section of
+// a config's data:
+//
+//	{"tokens": {"types": ["color", "spacing"], "styles": ["Brand"],
+//	            "collections": ["Theme"], "modes": ["Light", "Dark"]}}
+//
+// Empty lists select everything.
+type Options struct {
+	Types []Type `json:"types,omitempty"`
+	// Styles filters styles and variables by name. A pattern selects a name
+	// it equals, names below it as a group ("Brand" selects "Brand/Primary")
+	// and names it matches as a path.Match glob ("Brand/*/500").
+	Styles []string `json:"styles,omitempty"`
+	// Collections filters variable collections by name.
+	Collections []string `json:"collections,omitempty"`
+	// Modes filters the exported modes by name; the first one present in a
+	// collection provides the default value instead of the collection default.
+	Modes []string `json:"modes,omitempty"`
+}
+
+// ConfigKey is the config data key holding Options.
+const ConfigKey = "tokens"
+
+// OptionsFromConfig reads Options from resolved config data. ok is false when
+// the config has no tokens section, meaning no tokens are wanted.
+func OptionsFromConfig(data map[string]any) (opts Options, ok bool, err error) {
+	raw, ok := data[ConfigKey]
+	if !ok || raw == nil {
+		return Options{}, false, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return Options{}, false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		return Options{}, false, fmt.Errorf("tokens: invalid config: %w", err)
+	}
+	return opts, true, opts.Validate()
+}
+
+// Validate checks the types and patterns.
+func (o *Options) Validate() error {
+	for _, t := range o.Types {
+		if !validType(t) {
+			return fmt.Errorf("tokens: unknown type %q", t)
+		}
+	}
+	for _, p := range o.Styles {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("tokens: invalid style pattern %q", p)
+		}
+	}
+	return nil
+}
+
+func (o *Options) wants(t Type) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, v := range o.Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsVariables reports whether any selected type can come from variables.
+func (o *Options) wantsVariables() bool {
+	return o.wants(Color) || o.wants(Spacing) || o.wants(Radius)
+}
+
+func (o *Options) matchName(name string) bool {
+	if len(o.Styles) == 0 {
+		return true
+	}
+	for _, p := range o.Styles {
+		p = strings.Trim(p, "/")
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) matchCollection(name string) bool {
+	if len(o.Collections) == 0 {
+		return true
+	}
+	for _, c := range o.Collections {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) matchMode(name string) bool {
+	if len(o.Modes) == 0 {
+		return true
+	}
+	for _, m := range o.Modes {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package tokens
+
+import "testing"
+
+func TestOptionsFromConfig(t *testing.T) {
+	if _, ok, err := OptionsFromConfig(map[string]any{"format": "svg"}); ok || err != nil {
+		t.Fatalf("config without tokens: ok=%v err=%v", ok, err)
+	}
+	opts, ok, err := OptionsFromConfig(map[string]any{"tokens": map[string]any{
+		"types": []any{"color", "spacing"}, "styles": []any{"Brand"}, "modes": []any{"Dark"},
+	}})
+	if !ok || err != nil || len(opts.Types) != 2 || opts.Modes[0] != "Dark" {
+		t.Fatalf("opts = %+v ok=%v err=%v", opts, ok, err)
+	}
+	if _, _, err := OptionsFromConfig(map[string]any{"tokens": map[string]any{"types": []any{"shadow"}}}); err == nil {
+		t.Fatalf("unknown type accepted")
+	}
+	if _, _, err := OptionsFromConfig(map[string]any{"tokens": map[string]any{"colour": true}}); err == nil {
+		t.Fatalf("unknown field accepted")
+	}
+}
+
+func TestOptions_MatchName(t *testing.T) {
+	o := Options{Styles: []string{"Brand", "Text/*", "/space/"}}
+	for name, want := range map[string]bool{
+		"Brand":          true,
+		"Brand/Primary":  true,
+		"Branding":       false,
+		"Text/Body":      true,
+		"Text/Body/Bold": false,
+		"space/0.5":      true,
+		"radius/md":      false,
+	} {
+		if got := o.matchName(name); got != want {
+			t.Fatalf("matchName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax"
+	"fiber-ent-apollo-pg/internal/logx"
+)
+
+var tokensLogger = logx.GetScope("tokens")
+
+// ArtifactName is the export artifact holding the token tree.
+const ArtifactName = "tokens.json"
+
+// Emitter writes further artifacts derived from the token tree of a job,
+// e.g. generated source code.
+type Emitter func(ctx context.Context, job *ent.ExportJob, tree *Tree, out *exportx.Output) error
+
+// Step returns the export step writing the project's design tokens, then
+// running emit on the tree. Jobs whose config has no tokens section are left
+// alone.
+func Step(client *ent.Client, fg *figmax.Client, emit ...Emitter) exportx.Step {
+	return exportx.Step{Name: "tokens", Run: func(ctx context.Context, job *ent.ExportJob, out *exportx.Output) error {
+		opts, ok, err := OptionsFromConfig(job.Config)
+		if err != nil || !ok {
+			return err
+		}
+		if fg == nil {
+			return errors.New("figma is not configured")
+		}
+		proj, err := client.Project.Get(ctx, job.ProjectID)
+		if err != nil {
+			return fmt.Errorf("load project: %w", err)
+		}
+		key := proj.FileKey
+		if proj.BranchKey != "" {
+			key = proj.BranchKey
+		}
+		if key == "" {
+			return errors.New("project has no figma file")
+		}
+
+		file, err := fg.GetFile(ctx, key, figmax.FileOptions{})
+		if err != nil {
+			return fmt.Errorf("fetch file: %w", err)
+		}
+		var vars *figmax.Variables
+		if opts.wantsVariables() {
+			// The file was readable, so a 403 here means the plan has no
+			// variables access; styles are still exported
+			vars, err = fg.GetLocalVariables(ctx, key)
+			if errors.Is(err, figmax.ErrUnauthorized) {
+				tokensLogger.Warn("figma variables unavailable", zap.String("job", job.ID.String()), zap.Error(err))
+			} else if err != nil {
+				return fmt.Errorf("fetch variables: %w", err)
+			}
+		}
+
+		tree, err := Extract(file, vars, opts)
+		if err != nil {
+			return err
+		}
+		if len(tree.Warnings) > 0 {
+			tokensLogger.Info("tokens skipped", zap.String("job", job.ID.String()), zap.Strings("warnings", tree.Warnings))
+		}
+		b, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := out.Put(ctx, ArtifactName, "application/json", bytes.NewReader(b)); err != nil {
+			return err
+		}
+		for _, e := range emit {
+			if err := e(ctx, job, tree, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+}
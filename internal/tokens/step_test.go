@@ -0,0 +1,85 @@
+package tokens
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"fiber-ent-apollo-pg/ent"
+	"fiber-ent-apollo-pg/ent/artifact"
+	"fiber-ent-apollo-pg/ent/exportjob"
+	"fiber-ent-apollo-pg/internal/blobx"
+	"fiber-ent-apollo-pg/internal/exportx"
+	"fiber-ent-apollo-pg/internal/figmax/figmatest"
+)
+
+func newTestClient(t *testing.T) *ent.Client {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:tokens?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	_, _ = db.Exec("PRAGMA foreign_keys = ON")
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Schema.Create(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return client
+}
+
+func TestStep(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	file, _ := loadFixtures(t)
+	srv := figmatest.NewServer("tok")
+	t.Cleanup(srv.Close)
+	// No variables: the stub answers 403 and only styles are exported
+	srv.SetFile("FILE1", figmatest.File{File: *file})
+	store, _ := blobx.NewFSStore(t.TempDir())
+	svc := exportx.NewService(client, nil, store, Step(client, srv.FigmaClient()))
+
+	u := client.User.Create().SetDisplayName("Designer").SaveX(ctx)
+	p := client.Project.Create().SetName("p").SetURL("https://www.figma.com/design/FILE1").SetFileKey("FILE1").SetOwnerID(u.ID).SaveX(ctx)
+	newJob := func(cfg map[string]any) *ent.ExportJob {
+		return client.ExportJob.Create().SetProjectID(p.ID).SetRequestedByID(u.ID).
+			SetConfigID(uuid.New()).SetConfigRevision(1).SetConfig(cfg).SaveX(ctx)
+	}
+
+	job := newJob(map[string]any{"tokens": map[string]any{"types": []any{"color", "spacing"}}})
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := client.ExportJob.GetX(ctx, job.ID); got.Status != exportjob.StatusSucceeded {
+		t.Fatalf("job %s: %s", got.Status, got.Error)
+	}
+	a := client.Artifact.Query().Where(artifact.JobIDEQ(job.ID)).OnlyX(ctx)
+	blob, err := store.Open(ctx, a.Digest)
+	if err != nil {
+		t.Fatalf("open artifact: %v", err)
+	}
+	defer blob.Close()
+	b, _ := io.ReadAll(blob)
+	var doc map[string]map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil || a.Name != ArtifactName || doc["color"]["Brand"] == nil || doc["typography"] != nil {
+		t.Fatalf("artifact %s: %s (%v)", a.Name, b, err)
+	}
+
+	// Configs without a tokens section produce nothing
+	plain := newJob(map[string]any{"format": "svg"})
+	if err := svc.Run(ctx, plain.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if n := client.Artifact.Query().Where(artifact.JobIDEQ(plain.ID)).CountX(ctx); n != 0 {
+		t.Fatalf("plain job wrote %d artifacts", n)
+	}
+}
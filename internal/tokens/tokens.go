@@ -0,0 +1,119 @@
+// Package tokens extracts design tokens from a Figma file. Styles and
+// variables selected by a config are normalized into a token tree that
+// renders as design tokens JSON ($type/$value groups), with per-mode values
+// for variables from multi-mode collections.
+package tokens
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Type is a token category. It is also the top level group of the tree.
+type Type string
+
+// Token types.
+const (
+	Color      Type = "color"
+	Typography Type = "typography"
+	Spacing    Type = "spacing"
+	Radius     Type = "radius"
+	Effect     Type = "effect"
+)
+
+// Types lists all token types in output order.
+var Types = []Type{Color, Typography, Spacing, Radius, Effect}
+
+func validType(t Type) bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Token is one design token.
+type Token struct {
+	// Path is the token name split into groups, without the type group,
+	// e.g. ["Brand", "Primary"] for the color style "Brand/Primary".
+	Path []string
+	Type Type
+	// Value is the value in the default mode. Values are colors as "#rrggbb"
+	// or "#rrggbbaa", dimensions as "8px", composite maps for typography and
+	// lists of maps for effects, or "{type.group.name}" aliases.
+	Value any
+	// Modes holds the value per mode name for tokens from multi-mode
+	// variable collections.
+	Modes       map[string]any
+	Description string
+	// Source is the Figma style or variable id the token came from.
+	Source string
+}
+
+// Name returns the dotted reference name of the token, e.g. "color.Brand.Primary".
+func (t *Token) Name() string {
+	return string(t.Type) + "." + strings.Join(t.Path, ".")
+}
+
+// Tree is the set of tokens extracted from a file.
+type Tree struct {
+	Tokens []Token
+	// Modes lists, per variable collection, the mode names exported.
+	Modes map[string][]string
+	// Warnings describes styles and variables that were skipped.
+	Warnings []string
+}
+
+func (t *Tree) sort() {
+	order := map[Type]int{}
+	for i, typ := range Types {
+		order[typ] = i
+	}
+	sort.SliceStable(t.Tokens, func(i, j int) bool {
+		a, b := &t.Tokens[i], &t.Tokens[j]
+		if a.Type != b.Type {
+			return order[a.Type] < order[b.Type]
+		}
+		return strings.Join(a.Path, "/") < strings.Join(b.Path, "/")
+	})
+}
+
+// Find returns the token with the dotted name, or nil.
+func (t *Tree) Find(name string) *Token {
+	for i := range t.Tokens {
+		if t.Tokens[i].Name() == name {
+			return &t.Tokens[i]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON renders the tree as nested groups keyed by type and path. Leaves
+// carry $type, $value, optional $description and, for multi-mode tokens,
+// $extensions.modes.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	root := map[string]any{}
+	for i := range t.Tokens {
+		tok := &t.Tokens[i]
+		group := root
+		for _, seg := range append([]string{string(tok.Type)}, tok.Path[:len(tok.Path)-1]...) {
+			next, ok := group[seg].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				group[seg] = next
+			}
+			group = next
+		}
+		leaf := map[string]any{"$type": string(tok.Type), "$value": tok.Value}
+		if tok.Description != "" {
+			leaf["$description"] = tok.Description
+		}
+		if len(tok.Modes) > 0 {
+			leaf["$extensions"] = map[string]any{"modes": tok.Modes}
+		}
+		group[tok.Path[len(tok.Path)-1]] = leaf
+	}
+	return json.Marshal(root)
+}
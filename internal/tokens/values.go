@@ -0,0 +1,170 @@
+package tokens
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// node is the part of a Figma document node that carries style values.
+type node struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Children []node            `json:"children,omitempty"`
+	Styles   map[string]string `json:"styles,omitempty"`
+	Fills    []paint           `json:"fills,omitempty"`
+	Strokes  []paint           `json:"strokes,omitempty"`
+	Effects  []effect          `json:"effects,omitempty"`
+	Style    *typeStyle        `json:"style,omitempty"`
+}
+
+type rgba struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+	A float64 `json:"a"`
+}
+
+type paint struct {
+	Type    string   `json:"type"`
+	Visible *bool    `json:"visible,omitempty"`
+	Opacity *float64 `json:"opacity,omitempty"`
+	Color   *rgba    `json:"color,omitempty"`
+}
+
+type effect struct {
+	Type    string   `json:"type"`
+	Visible *bool    `json:"visible,omitempty"`
+	Radius  float64  `json:"radius"`
+	Color   *rgba    `json:"color,omitempty"`
+	Offset  *vector  `json:"offset,omitempty"`
+	Spread  float64  `json:"spread,omitempty"`
+	Opacity *float64 `json:"opacity,omitempty"`
+}
+
+type vector struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type typeStyle struct {
+	FontFamily                string  `json:"fontFamily"`
+	FontWeight                float64 `json:"fontWeight"`
+	FontSize                  float64 `json:"fontSize"`
+	Italic                    bool    `json:"italic,omitempty"`
+	LetterSpacing             float64 `json:"letterSpacing,omitempty"`
+	LineHeightPx              float64 `json:"lineHeightPx,omitempty"`
+	LineHeightPercentFontSize float64 `json:"lineHeightPercentFontSize,omitempty"`
+	// LineHeightUnit is PIXELS, FONT_SIZE_% or INTRINSIC_%.
+	LineHeightUnit string `json:"lineHeightUnit,omitempty"`
+	TextCase       string `json:"textCase,omitempty"`
+	TextDecoration string `json:"textDecoration,omitempty"`
+}
+
+func visible(v *bool) bool { return v == nil || *v }
+
+// round drops float noise from Figma values, e.g. 0.30000001 -> 0.3.
+func round(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(round(v), 'f', -1, 64)
+}
+
+func px(v float64) string { return num(v) + "px" }
+
+// hexColor formats a color with its alpha scaled by opacity.
+func hexColor(c rgba, opacity float64) string {
+	ch := func(v float64) int { return int(math.Round(math.Max(0, math.Min(1, v)) * 255)) }
+	a := ch(c.A * opacity)
+	s := fmt.Sprintf("#%02x%02x%02x", ch(c.R), ch(c.G), ch(c.B))
+	if a != 255 {
+		s += fmt.Sprintf("%02x", a)
+	}
+	return s
+}
+
+// paintColor returns the color of a single visible solid paint.
+func paintColor(paints []paint) (string, error) {
+	var solid []paint
+	for _, p := range paints {
+		if visible(p.Visible) {
+			solid = append(solid, p)
+		}
+	}
+	if len(solid) != 1 || solid[0].Type != "SOLID" || solid[0].Color == nil {
+		return "", fmt.Errorf("not a single solid paint")
+	}
+	opacity := 1.0
+	if solid[0].Opacity != nil {
+		opacity = *solid[0].Opacity
+	}
+	return hexColor(*solid[0].Color, opacity), nil
+}
+
+func typographyValue(s *typeStyle) map[string]any {
+	v := map[string]any{
+		"fontFamily": s.FontFamily,
+		"fontWeight": round(s.FontWeight),
+		"fontSize":   px(s.FontSize),
+	}
+	switch {
+	case s.LineHeightUnit == "FONT_SIZE_%" && s.LineHeightPercentFontSize > 0:
+		v["lineHeight"] = round(s.LineHeightPercentFontSize / 100)
+	case s.LineHeightUnit == "INTRINSIC_%" || s.LineHeightPx == 0:
+		v["lineHeight"] = "normal"
+	default:
+		v["lineHeight"] = px(s.LineHeightPx)
+	}
+	v["letterSpacing"] = px(s.LetterSpacing)
+	if s.Italic {
+		v["fontStyle"] = "italic"
+	}
+	if s.TextCase != "" && s.TextCase != "ORIGINAL" {
+		v["textCase"] = strings.ToLower(s.TextCase)
+	}
+	if s.TextDecoration != "" && s.TextDecoration != "NONE" {
+		v["textDecoration"] = strings.ToLower(s.TextDecoration)
+	}
+	return v
+}
+
+// effectValue normalizes the visible effects of a style. Shadows keep their
+// geometry and color; blurs only have a radius.
+func effectValue(effects []effect) ([]map[string]any, error) {
+	var out []map[string]any
+	for _, e := range effects {
+		if !visible(e.Visible) {
+			continue
+		}
+		switch e.Type {
+		case "DROP_SHADOW", "INNER_SHADOW":
+			v := map[string]any{
+				"type":    map[string]string{"DROP_SHADOW": "dropShadow", "INNER_SHADOW": "innerShadow"}[e.Type],
+				"offsetX": "0px",
+				"offsetY": "0px",
+				"blur":    px(e.Radius),
+				"spread":  px(e.Spread),
+			}
+			if e.Offset != nil {
+				v["offsetX"], v["offsetY"] = px(e.Offset.X), px(e.Offset.Y)
+			}
+			if e.Color != nil {
+				v["color"] = hexColor(*e.Color, 1)
+			}
+			out = append(out, v)
+		case "LAYER_BLUR", "BACKGROUND_BLUR":
+			out = append(out, map[string]any{
+				"type":   map[string]string{"LAYER_BLUR": "layerBlur", "BACKGROUND_BLUR": "backgroundBlur"}[e.Type],
+				"radius": px(e.Radius),
+			})
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no visible effects")
+	}
+	return out, nil
+}